@@ -0,0 +1,37 @@
+package cmds
+
+import "fmt"
+
+// AliasSource is implemented by the ConfigSources that also have aliases for
+// the UserAliases of the command they're the Config of, like the "alias"
+// table of the config files of package [github.com/rgzlv/cmds/config].
+type AliasSource interface {
+	// Aliases returns the aliases, the names mapped to the arguments they're
+	// replaced by.
+	Aliases() (map[string][]string, error)
+}
+
+// aliases returns the UserAliases of cmd merged with the aliases of it's
+// Config if it's an [AliasSource], the UserAliases take precedence.
+func (cmd *Command) aliases() (map[string][]string, error) {
+	src, ok := cmd.Config.(AliasSource)
+	if !ok {
+		return cmd.UserAliases, nil
+	}
+	aliases, err := src.Aliases()
+	if err != nil {
+		return cmd.UserAliases, fmt.Errorf("%w: %w", Err, err)
+	}
+	if len(cmd.UserAliases) == 0 {
+		return aliases, nil
+	}
+
+	merged := make(map[string][]string, len(aliases)+len(cmd.UserAliases))
+	for name, args := range aliases {
+		merged[name] = args
+	}
+	for name, args := range cmd.UserAliases {
+		merged[name] = args
+	}
+	return merged, nil
+}
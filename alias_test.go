@@ -0,0 +1,103 @@
+package cmds
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"testing"
+)
+
+func TestUserAliases(t *testing.T) {
+	var short bool
+	var got []string
+	var out bytes.Buffer
+	cmd := &Command{
+		Name: "test",
+		UserAliases: map[string][]string{
+			"st":     {"status", "-s"},
+			"status": {"invalid"},
+			"bad":    {"invalid"},
+		},
+		Commands: []*Command{
+			{
+				Name:      "status",
+				ShortDesc: "show the status",
+				Flags: func() *flag.FlagSet {
+					fset := flag.NewFlagSet("status", flag.ContinueOnError)
+					fset.BoolVar(&short, "s", false, "")
+					return fset
+				}(),
+				Runner: func(cmd *Command, args []string) error {
+					got = args
+					return nil
+				},
+			},
+		},
+	}
+
+	expectErrorNone(t, cmd.ParseRun([]string{"st", "a"}))
+	expectEq(t, short, true)
+	expectEq(t, got, []string{"a"})
+
+	cmd.Reset()
+	expectErrorNone(t, cmd.ParseRun([]string{"status"}))
+	expectEq(t, short, false)
+	// The error is about the alias, not what it's replaced by.
+	err := cmd.ParseRun([]string{"bad"})
+	expectErrorIs(t, err, ErrUnknownCommand)
+	var cmdErr *CommandError
+	expectTrue(t, errors.As(err, &cmdErr))
+	expectEq(t, cmdErr.Arg, "bad")
+
+	cmd.Usage().write(&out)
+	expectEq(t, out.String(), `Usage: test <command> [command flags] [args]
+
+Commands:
+  status   show the status
+
+Aliases:
+  bad      invalid
+  st       status -s
+`)
+}
+
+// testAliases is a ConfigSource without values that is an AliasSource.
+type testAliases map[string][]string
+
+func (a testAliases) Lookup(path []string, name string) (string, bool, error) {
+	return "", false, nil
+}
+
+func (a testAliases) Aliases() (map[string][]string, error) {
+	if a == nil {
+		return nil, errors.New("no aliases")
+	}
+	return a, nil
+}
+
+func TestAliasSource(t *testing.T) {
+	var got []string
+	cmd := &Command{
+		Name:        "test",
+		Config:      testAliases{"st": {"status", "a"}, "s": {"status"}},
+		UserAliases: map[string][]string{"s": {"status", "x"}},
+		Commands: []*Command{
+			{
+				Name: "status",
+				Runner: func(cmd *Command, args []string) error {
+					got = args
+					return nil
+				},
+			},
+		},
+	}
+
+	expectErrorNone(t, cmd.ParseRun([]string{"st"}))
+	expectEq(t, got, []string{"a"})
+	expectErrorNone(t, cmd.ParseRun([]string{"s"}))
+	expectEq(t, got, []string{"x"})
+	expectEq(t, cmd.Usage().Aliases, []UsageAlias{{Name: "s", Args: []string{"status", "x"}}, {Name: "st", Args: []string{"status", "a"}}})
+
+	cmd.Config = testAliases(nil)
+	expectErrorIs(t, cmd.ParseRun([]string{"st"}), Err)
+}
@@ -0,0 +1,131 @@
+package cmds
+
+import (
+	"flag"
+	"strconv"
+	"testing"
+)
+
+// The allocation budgets of parsing the benchmark trees again, after the first
+// parse. They're what Parse allocates when it's given the same tree, so a
+// change that makes the happy path allocate more has to update them, to make
+// it a decision instead of an accident.
+const (
+	// Only the sorted flags that Visit and VisitAll of every FlagSet make.
+	allocsParseDeep = 2 * benchDepth
+	// The same, setting the flags doesn't allocate.
+	allocsParseManyFlags = 2
+	// The same, the arguments aren't copied.
+	allocsParseLongArgs = 2
+)
+
+const (
+	benchDepth = 8
+	benchWidth = 20
+	benchFlags = 100
+	benchArgs  = 1000
+)
+
+// benchDeep returns a tree that's benchDepth commands deep with benchWidth
+// sub-commands for each of the commands on the way, so that Find uses it's
+// index, and the arguments for the last leaf with a flag for every command.
+func benchDeep() (*Command, []string) {
+	var args []string
+	var build func(name string, depth int) *Command
+	build = func(name string, depth int) *Command {
+		cmd := &Command{Name: name, Flags: flag.NewFlagSet(name, flag.ContinueOnError)}
+		cmd.Flags.Bool("v"+strconv.Itoa(depth), false, "")
+		if depth == benchDepth {
+			cmd.Runner = nopRunner
+			return cmd
+		}
+		for i := 0; i < benchWidth-1; i++ {
+			name := "c" + strconv.Itoa(i)
+			cmd.Commands = append(cmd.Commands, &Command{Name: name, Runner: nopRunner})
+		}
+		cmd.Commands = append(cmd.Commands, build("c"+strconv.Itoa(benchWidth-1), depth+1))
+		return cmd
+	}
+	cmd := build("root", 1)
+	args = append(args, "-v1")
+	for depth := 2; depth <= benchDepth; depth++ {
+		args = append(args, "c"+strconv.Itoa(benchWidth-1), "-v"+strconv.Itoa(depth))
+	}
+	return cmd, args
+}
+
+// benchManyFlags returns a command with benchFlags flags and the arguments that
+// set all of them.
+func benchManyFlags() (*Command, []string) {
+	cmd := &Command{Name: "root", Runner: nopRunner, Flags: flag.NewFlagSet("root", flag.ContinueOnError)}
+	var args []string
+	for i := 0; i < benchFlags; i++ {
+		name := "flag" + strconv.Itoa(i)
+		cmd.Flags.String(name, "", "")
+		args = append(args, "-"+name, "value")
+	}
+	return cmd, args
+}
+
+// benchLongArgs returns a command and benchArgs arguments for it after a flag.
+func benchLongArgs() (*Command, []string) {
+	cmd := &Command{Name: "root", Runner: nopRunner, Flags: flag.NewFlagSet("root", flag.ContinueOnError)}
+	cmd.Flags.Bool("v", false, "")
+	args := []string{"-v"}
+	for i := 0; i < benchArgs; i++ {
+		args = append(args, "arg"+strconv.Itoa(i))
+	}
+	return cmd, args
+}
+
+func benchmarkParse(b *testing.B, tree func() (*Command, []string)) {
+	cmd, args := tree()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := cmd.Parse(args); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseDeep(b *testing.B) {
+	benchmarkParse(b, benchDeep)
+}
+
+func BenchmarkParseManyFlags(b *testing.B) {
+	benchmarkParse(b, benchManyFlags)
+}
+
+func BenchmarkParseLongArgs(b *testing.B) {
+	benchmarkParse(b, benchLongArgs)
+}
+
+func TestParseAllocs(t *testing.T) {
+	if testing.CoverMode() != "" {
+		t.Skip("coverage counters change the allocations")
+	}
+
+	tests := []struct {
+		name   string
+		tree   func() (*Command, []string)
+		budget int
+	}{
+		{"deep", benchDeep, allocsParseDeep},
+		{"many flags", benchManyFlags, allocsParseManyFlags},
+		{"long args", benchLongArgs, allocsParseLongArgs},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd, args := test.tree()
+			var err error
+			allocs := testing.AllocsPerRun(100, func() {
+				_, _, err = cmd.Parse(args)
+			})
+			expectErrorNone(t, err)
+			if int(allocs) > test.budget {
+				t.Errorf("Parse allocates %v times, the budget is %d", allocs, test.budget)
+			}
+		})
+	}
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	commandDirective = "//cmds:command "
+	flagDirective    = "//cmds:flag "
+)
+
+// command is a function annotated with a commandDirective.
+type command struct {
+	funcName  string
+	name      string
+	short     string
+	argsUsage string
+	nargs     int
+	parent    string
+	hidden    bool
+	flags     []cmdFlag
+	pos       token.Position
+}
+
+// cmdFlag is a flagDirective of a command.
+type cmdFlag struct {
+	name     string
+	typ      string
+	def      string
+	usage    string
+	required bool
+	choices  []string
+}
+
+// flagTypes are the supported flag types and the methods of [flag.FlagSet]
+// that define them.
+var flagTypes = map[string]string{
+	"string":   "String",
+	"bool":     "Bool",
+	"int":      "Int",
+	"float64":  "Float64",
+	"duration": "Duration",
+}
+
+// parsePackage returns the package name and the annotated functions of the
+// Go files in dir, except for tests and the file named skip.
+func parsePackage(dir, skip string) (string, []*command, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", nil, err
+	}
+	sort.Strings(names)
+
+	var pkg string
+	var cmds []*command
+	fset := token.NewFileSet()
+	for _, name := range names {
+		if strings.HasSuffix(name, "_test.go") || filepath.Base(name) == skip {
+			continue
+		}
+		file, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
+		if err != nil {
+			return "", nil, err
+		}
+		pkg = file.Name.Name
+
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Doc == nil || fn.Recv != nil {
+				continue
+			}
+			cmd, err := parseDirectives(fn.Name.Name, fn.Doc)
+			if err != nil {
+				return "", nil, fmt.Errorf("%s: %w", fset.Position(fn.Pos()), err)
+			}
+			if cmd != nil {
+				cmd.pos = fset.Position(fn.Pos())
+				cmds = append(cmds, cmd)
+			}
+		}
+	}
+	if pkg == "" {
+		return "", nil, fmt.Errorf("no Go files in %s", dir)
+	}
+
+	return pkg, cmds, nil
+}
+
+// parseDirectives returns the command described by the directives in doc, or
+// nil if there's no commandDirective.
+func parseDirectives(funcName string, doc *ast.CommentGroup) (*command, error) {
+	var cmd *command
+	var flags []cmdFlag
+	for _, c := range doc.List {
+		switch {
+		case strings.HasPrefix(c.Text, commandDirective):
+			if cmd != nil {
+				return nil, fmt.Errorf("more than one %s directive", strings.TrimSpace(commandDirective))
+			}
+			attrs, err := parseAttrs(strings.TrimPrefix(c.Text, commandDirective))
+			if err != nil {
+				return nil, err
+			}
+			cmd = &command{funcName: funcName, name: funcName}
+			for key, value := range attrs {
+				switch key {
+				case "name":
+					cmd.name = value
+				case "short":
+					cmd.short = value
+				case "args":
+					cmd.argsUsage = value
+				case "nargs":
+					if cmd.nargs, err = strconv.Atoi(value); err != nil {
+						return nil, fmt.Errorf("invalid nargs: %w", err)
+					}
+				case "parent":
+					cmd.parent = value
+				case "hidden":
+					if cmd.hidden, err = strconv.ParseBool(value); err != nil {
+						return nil, fmt.Errorf("invalid hidden: %w", err)
+					}
+				default:
+					return nil, fmt.Errorf("unknown command attribute \"%s\"", key)
+				}
+			}
+
+		case strings.HasPrefix(c.Text, flagDirective):
+			attrs, err := parseAttrs(strings.TrimPrefix(c.Text, flagDirective))
+			if err != nil {
+				return nil, err
+			}
+			f := cmdFlag{typ: "string"}
+			for key, value := range attrs {
+				switch key {
+				case "name":
+					f.name = value
+				case "type":
+					f.typ = value
+				case "default":
+					f.def = value
+				case "usage":
+					f.usage = value
+				case "required":
+					if f.required, err = strconv.ParseBool(value); err != nil {
+						return nil, fmt.Errorf("invalid required: %w", err)
+					}
+				case "choices":
+					f.choices = strings.Split(value, ",")
+				default:
+					return nil, fmt.Errorf("unknown flag attribute \"%s\"", key)
+				}
+			}
+			if f.name == "" {
+				return nil, fmt.Errorf("flag without a name")
+			}
+			if _, ok := flagTypes[f.typ]; !ok {
+				return nil, fmt.Errorf("unsupported type \"%s\" of flag -%s", f.typ, f.name)
+			}
+			flags = append(flags, f)
+		}
+	}
+
+	if cmd == nil {
+		if len(flags) > 0 {
+			return nil, fmt.Errorf("%s directive without a %s directive", strings.TrimSpace(flagDirective), strings.TrimSpace(commandDirective))
+		}
+		return nil, nil
+	}
+	cmd.flags = flags
+	return cmd, nil
+}
+
+// parseAttrs parses space separated key=value pairs, the values can be quoted
+// like Go strings.
+func parseAttrs(s string) (map[string]string, error) {
+	attrs := make(map[string]string)
+	for {
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+		if s == "" {
+			return attrs, nil
+		}
+
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok || key == "" || strings.ContainsFunc(key, unicode.IsSpace) {
+			return nil, fmt.Errorf("invalid attribute \"%s\", expected key=value", strings.Fields(s)[0])
+		}
+
+		var value string
+		if strings.HasPrefix(rest, "\"") || strings.HasPrefix(rest, "`") {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of \"%s\": %w", key, err)
+			}
+			value, _ = strconv.Unquote(quoted)
+			rest = rest[len(quoted):]
+		} else {
+			i := strings.IndexFunc(rest, unicode.IsSpace)
+			if i < 0 {
+				i = len(rest)
+			}
+			value, rest = rest[:i], rest[i:]
+		}
+		attrs[key] = value
+		s = rest
+	}
+}
+
+// generate returns the source of a file in package pkg with a function named
+// funcName that returns the top level commands of cmds.
+func generate(pkg, funcName string, cmds []*command) ([]byte, error) {
+	byName := make(map[string]*command)
+	for _, cmd := range cmds {
+		if other, ok := byName[cmd.name]; ok {
+			return nil, fmt.Errorf("%s: command \"%s\" already defined at %s", cmd.pos, cmd.name, other.pos)
+		}
+		byName[cmd.name] = cmd
+	}
+	for _, cmd := range cmds {
+		if cmd.parent != "" && byName[cmd.parent] == nil {
+			return nil, fmt.Errorf("%s: unknown parent \"%s\" of command \"%s\"", cmd.pos, cmd.parent, cmd.name)
+		}
+	}
+
+	var imports = map[string]bool{"github.com/rgzlv/cmds": true}
+	var types, funcs bytes.Buffer
+	fmt.Fprintf(&funcs, "func %s() []*cmds.Command {\n", funcName)
+	for _, cmd := range cmds {
+		v := cmd.funcName + "Cmd"
+		fmt.Fprintf(&funcs, "%s := &cmds.Command{\n", v)
+		fmt.Fprintf(&funcs, "Name: %q,\n", cmd.name)
+		if cmd.short != "" {
+			fmt.Fprintf(&funcs, "ShortDesc: %q,\n", cmd.short)
+		}
+		if cmd.argsUsage != "" {
+			fmt.Fprintf(&funcs, "ArgsUsage: %q,\n", cmd.argsUsage)
+		}
+		if cmd.nargs > 0 {
+			fmt.Fprintf(&funcs, "Args: cmds.ExactArgs(%d),\n", cmd.nargs)
+		}
+		if cmd.hidden {
+			fmt.Fprintf(&funcs, "Hidden: true,\n")
+		}
+		fmt.Fprintf(&funcs, "Flags: flag.NewFlagSet(%q, flag.ContinueOnError),\n", cmd.name)
+		fmt.Fprintf(&funcs, "}\n")
+		fmt.Fprintf(&funcs, "%s.Flags.Usage = %[1]s.DefaultUsage()\n", v)
+		imports["flag"] = true
+
+		if len(cmd.flags) == 0 {
+			fmt.Fprintf(&funcs, "%s.Runner = %s\n\n", v, cmd.funcName)
+			continue
+		}
+
+		// The Runner makes the struct from the flags of the command it's
+		// passed, so that the copies of ParseArgs work too.
+		typ := cmd.funcName + "Flags"
+		var runner bytes.Buffer
+		fmt.Fprintf(&types, "// %s are the flags of the %s command.\ntype %[1]s struct {\n", typ, cmd.name)
+		fmt.Fprintf(&runner, "%s.Runner = func(cmd *cmds.Command, args []string) error {\nflags := &%s{\n", v, typ)
+		for _, f := range cmd.flags {
+			field := fieldName(f.name)
+			def, goType, err := flagDefault(f)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", cmd.pos, err)
+			}
+			if f.typ == "duration" {
+				imports["time"] = true
+			}
+			fmt.Fprintf(&types, "%s %s\n", field, goType)
+			fmt.Fprintf(&funcs, "%s.Flags.%s(%q, %s, %q)\n", v, flagTypes[f.typ], f.name, def, f.usage)
+			fmt.Fprintf(&runner, "%s: cmd.Flags.Lookup(%q).Value.(flag.Getter).Get().(%s),\n", field, f.name, goType)
+			if f.required {
+				fmt.Fprintf(&funcs, "%s.Meta(%q).Required = true\n", v, f.name)
+			}
+			if len(f.choices) > 0 {
+				fmt.Fprintf(&funcs, "%s.Meta(%q).Choices = %#v\n", v, f.name, f.choices)
+			}
+		}
+		fmt.Fprintf(&types, "}\n\n")
+		fmt.Fprintf(&runner, "}\nreturn %s(cmd, args, flags)\n}\n\n", cmd.funcName)
+		funcs.Write(runner.Bytes())
+	}
+
+	var top []string
+	for _, cmd := range cmds {
+		if cmd.parent == "" {
+			top = append(top, cmd.funcName+"Cmd")
+			continue
+		}
+		fmt.Fprintf(&funcs, "%sCmd.Commands = append(%[1]sCmd.Commands, %sCmd)\n", byName[cmd.parent].funcName, cmd.funcName)
+	}
+	fmt.Fprintf(&funcs, "return []*cmds.Command{%s}\n}\n", strings.Join(top, ", "))
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by cmdsgen; DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkg)
+	paths := make([]string, 0, len(imports))
+	for path := range imports {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		// The standard library first.
+		si, sj := !strings.Contains(paths[i], "."), !strings.Contains(paths[j], ".")
+		if si != sj {
+			return si
+		}
+		return paths[i] < paths[j]
+	})
+	for i, path := range paths {
+		if i > 0 && strings.Contains(path, ".") && !strings.Contains(paths[i-1], ".") {
+			src.WriteString("\n")
+		}
+		fmt.Fprintf(&src, "%q\n", path)
+	}
+	fmt.Fprintf(&src, ")\n\n")
+	src.Write(types.Bytes())
+	src.Write(funcs.Bytes())
+
+	return format.Source(src.Bytes())
+}
+
+// flagDefault returns the Go expression of the default value of f and the
+// type of it's field.
+func flagDefault(f cmdFlag) (string, string, error) {
+	switch f.typ {
+	case "bool":
+		if f.def == "" {
+			return "false", "bool", nil
+		}
+		b, err := strconv.ParseBool(f.def)
+		return strconv.FormatBool(b), "bool", err
+	case "int":
+		if f.def == "" {
+			return "0", "int", nil
+		}
+		n, err := strconv.Atoi(f.def)
+		return strconv.Itoa(n), "int", err
+	case "float64":
+		if f.def == "" {
+			return "0", "float64", nil
+		}
+		n, err := strconv.ParseFloat(f.def, 64)
+		return strconv.FormatFloat(n, 'g', -1, 64), "float64", err
+	case "duration":
+		if f.def == "" {
+			return "0", "time.Duration", nil
+		}
+		d, err := time.ParseDuration(f.def)
+		return fmt.Sprintf("%d", d), "time.Duration", err
+	}
+	return strconv.Quote(f.def), "string", nil
+}
+
+// fieldName returns the exported Go identifier for the flag name, like
+// "DryRun" for "dry-run".
+func fieldName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// run writes the generated file for the package in dir to out.
+func run(dir, out, funcName string) error {
+	pkg, cmds, err := parsePackage(dir, out)
+	if err != nil {
+		return err
+	}
+	src, err := generate(pkg, funcName, cmds)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, out), src, 0o666)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAttrs(t *testing.T) {
+	attrs, err := parseAttrs(`name=echo short="output the \"arguments\"" args=` + "`[text...]`")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"name": "echo", "short": `output the "arguments"`, "args": "[text...]"}
+	if !reflect.DeepEqual(attrs, want) {
+		t.Errorf("expected %v, got %v", want, attrs)
+	}
+
+	for _, s := range []string{"name", `short="unterminated`, "=x"} {
+		if _, err := parseAttrs(s); err == nil {
+			t.Errorf("expected an error for %s", s)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	src := `package test
+
+import "github.com/rgzlv/cmds"
+
+//cmds:command name=remote short="manage remotes"
+func remote(cmd *cmds.Command, args []string) error { return nil }
+
+//cmds:command name=add parent=remote nargs=2
+//cmds:flag name=dry-run type=bool
+//cmds:flag name=timeout type=duration default=1m30s required=true
+//cmds:flag name=mode choices=fetch,push default=fetch
+func remoteAdd(cmd *cmds.Command, args []string, flags *remoteAddFlags) error { return nil }
+`
+	if err := os.WriteFile(filepath.Join(dir, "test.go"), []byte(src), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if err := run(dir, "cmds_gen.go", "commands"); err != nil {
+		t.Fatal(err)
+	}
+	gen, err := os.ReadFile(filepath.Join(dir, "cmds_gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"package test",
+		"\t\"flag\"\n\t\"time\"\n\n\t\"github.com/rgzlv/cmds\"\n",
+		"type remoteAddFlags struct {\n\tDryRun  bool\n\tTimeout time.Duration\n\tMode    string\n}",
+		"remoteCmd.Runner = remote\n",
+		"Args:  cmds.ExactArgs(2),",
+		`remoteAddCmd.Flags.Duration("timeout", 90000000000, "")`,
+		`Timeout: cmd.Flags.Lookup("timeout").Value.(flag.Getter).Get().(time.Duration),`,
+		"return remoteAdd(cmd, args, flags)",
+		`remoteAddCmd.Meta("timeout").Required = true`,
+		`remoteAddCmd.Meta("mode").Choices = []string{"fetch", "push"}`,
+		"remoteCmd.Commands = append(remoteCmd.Commands, remoteAddCmd)",
+		"return []*cmds.Command{remoteCmd}",
+	} {
+		if !strings.Contains(string(gen), want) {
+			t.Errorf("expected generated file to contain %q, got:\n%s", want, gen)
+		}
+	}
+
+	// The generated file is skipped when generating again.
+	if err := run(dir, "cmds_gen.go", "commands"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	for _, cmds := range [][]*command{
+		{{funcName: "a", name: "x"}, {funcName: "b", name: "x"}},
+		{{funcName: "a", name: "a", parent: "missing"}},
+	} {
+		if _, err := generate("test", "commands", cmds); err == nil {
+			t.Errorf("expected an error for %+v", cmds)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Cmdsgen generates the command tree of a package from annotated functions, for
+use with go:generate.
+
+A function is a command if it's doc comment has a //cmds:command directive and
+each //cmds:flag directive after it defines a flag of the command:
+
+	//cmds:command name=echo short="output the arguments" args="[text...]"
+	//cmds:flag name=c type=bool usage="capitalize output"
+	func echo(cmd *cmds.Command, args []string, flags *echoFlags) error
+
+The attributes are key=value pairs, the values can be quoted like Go strings.
+The command attributes are name, which defaults to the name of the function,
+short, args for the ArgsUsage, nargs for an exact number of arguments, parent
+for the name of the parent command and hidden.
+The flag attributes are name, type, which is one of string, bool, int, float64
+or duration and defaults to string, default, usage, required and choices,
+separated by commas.
+
+The generated file has a struct named after the function with a "Flags"
+suffix for the commands with flags, which the function gets a pointer to as
+it's third parameter, and a function that returns the commands without a
+parent.
+
+Usage:
+
+	//go:generate go run github.com/rgzlv/cmds/cmd/cmdsgen [flags] [dir]
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rgzlv/cmds"
+)
+
+func main() {
+	var out, funcName string
+	cmd := &cmds.Command{
+		Name:          "cmdsgen",
+		ShortDesc:     "generate the command tree of a package",
+		ArgsUsage:     "[dir]",
+		Args:          cmds.MaxArgs(1),
+		Flags:         flag.NewFlagSet("cmdsgen", flag.ContinueOnError),
+		ErrorHandling: cmds.ExitOnError,
+		Runner: func(cmd *cmds.Command, args []string) error {
+			dir := "."
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			return run(dir, out, funcName)
+		},
+	}
+	cmd.Flags.StringVar(&out, "out", "cmds_gen.go", "name of the generated file in the package directory")
+	cmd.Flags.StringVar(&funcName, "func", "commands", "name of the generated function")
+	cmd.Flags.Usage = cmd.DefaultUsage()
+
+	// The errors of the Runner are returned instead of exiting.
+	if err := cmd.ParseRun(os.Args[1:]); err != nil {
+		fmt.Fprintln(cmd.ErrOutput(), cmds.DefaultFormatError(cmd, err))
+		os.Exit(cmd.ExitCode(err))
+	}
+}
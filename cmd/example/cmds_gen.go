@@ -0,0 +1,55 @@
+// Code generated by cmdsgen; DO NOT EDIT.
+
+package main
+
+import (
+	"flag"
+
+	"github.com/rgzlv/cmds"
+)
+
+// echoFlags are the flags of the echo command.
+type echoFlags struct {
+	C bool
+}
+
+// reqFlags are the flags of the req command.
+type reqFlags struct {
+	M string
+}
+
+func commands() []*cmds.Command {
+	echoCmd := &cmds.Command{
+		Name:      "echo",
+		ShortDesc: "output the arguments",
+		ArgsUsage: "[text...]",
+		Flags:     flag.NewFlagSet("echo", flag.ContinueOnError),
+	}
+	echoCmd.Flags.Usage = echoCmd.DefaultUsage()
+	echoCmd.Flags.Bool("c", false, "capitalize output")
+	echoCmd.Runner = func(cmd *cmds.Command, args []string) error {
+		flags := &echoFlags{
+			C: cmd.Flags.Lookup("c").Value.(flag.Getter).Get().(bool),
+		}
+		return echo(cmd, args, flags)
+	}
+
+	reqCmd := &cmds.Command{
+		Name:      "req",
+		ShortDesc: "make a HTTP request",
+		ArgsUsage: "<url>",
+		Args:      cmds.ExactArgs(1),
+		Flags:     flag.NewFlagSet("req", flag.ContinueOnError),
+	}
+	reqCmd.Flags.Usage = reqCmd.DefaultUsage()
+	reqCmd.Flags.String("m", "GET", "HTTP request method")
+	reqCmd.Meta("m").Choices = []string{"GET", "HEAD"}
+	reqCmd.Runner = func(cmd *cmds.Command, args []string) error {
+		flags := &reqFlags{
+			M: cmd.Flags.Lookup("m").Value.(flag.Getter).Get().(string),
+		}
+		return req(cmd, args, flags)
+	}
+
+	return []*cmds.Command{echoCmd, reqCmd}
+}
@@ -4,15 +4,17 @@ Example command that defines 2 sub-commands, echo and req.
 Echo outputs it's arguments and capitalizes them based on the flags.
 
 Req makes a HTTP request with the method in flags and the URL in arguments.
+
+The sub-commands are generated from the annotated functions by cmdsgen.
 */
 package main
 
+//go:generate go run ../cmdsgen
+
 import (
-	"errors"
 	"flag"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -21,112 +23,75 @@ import (
 	"github.com/rgzlv/cmds"
 )
 
-type rootFlags struct {
-	verbose bool
+//cmds:command name=echo short="output the arguments" args="[text...]"
+//cmds:flag name=c type=bool usage="capitalize output"
+func echo(cmd *cmds.Command, args []string, flags *echoFlags) error {
+	cmd.Logger().Debug("echoing output")
+
+	for _, arg := range args {
+		if flags.C {
+			fmt.Fprintln(cmd.Output(), strings.ToUpper(arg))
+		} else {
+			fmt.Fprintln(cmd.Output(), arg)
+		}
+	}
 
-	echo echoFlags
-	req  reqFlags
+	return nil
 }
 
-type echoFlags struct {
-	capitalize bool
-}
+//cmds:command name=req short="make a HTTP request" args="<url>" nargs=1
+//cmds:flag name=m default=GET usage="HTTP request method" choices=GET,HEAD
+func req(cmd *cmds.Command, args []string, flags *reqFlags) error {
+	var reqFunc func(string) (*http.Response, error)
+	switch flags.M {
+	case "GET":
+		reqFunc = http.Get
+	case "HEAD":
+		reqFunc = http.Head
+	default:
+		return fmt.Errorf("unrecognized HTTP method \"%s\"", args[0])
+	}
 
-type reqFlags struct {
-	method string
+	cmd.Logger().Debug("making HTTP request", "method", flags.M, "url", args[0])
+
+	resp, err := reqFunc(args[0])
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(cmd.Output(), resp.Body)
+	return err
 }
 
 func main() {
-	flags := rootFlags{}
 	cmd := &cmds.Command{
 		Name: filepath.Base(os.Args[0]),
 
-		Flags: func() *flag.FlagSet {
-			fset := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ExitOnError)
-			fset.BoolVar(&flags.verbose, "v", false, "verbose output")
-			return fset
-		}(),
+		Flags:         flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError),
+		ErrorHandling: cmds.ExitOnError,
 
 		Runner: func(cmd *cmds.Command, args []string) error {
 			return nil
 		},
 
-		Commands: []*cmds.Command{
-			{
-				Name: "echo",
-
-				Flags: func() *flag.FlagSet {
-					fset := flag.NewFlagSet("echo", flag.ExitOnError)
-					fset.BoolVar(&flags.echo.capitalize, "c", false, "capitalize output")
-					return fset
-				}(),
-
-				Runner: func(cmd *cmds.Command, args []string) error {
-					if flags.verbose {
-						log.Println("echoing output")
-					}
-
-					for _, arg := range args {
-						if flags.echo.capitalize {
-							fmt.Println(strings.ToUpper(arg))
-						} else {
-							fmt.Println(arg)
-						}
-					}
-
-					return nil
-				},
-			},
-			{
-				Name: "req",
-
-				Flags: func() *flag.FlagSet {
-					fset := flag.NewFlagSet("echo", flag.ExitOnError)
-					fset.StringVar(&flags.req.method, "m", "GET", "HTTP request method")
-					return fset
-				}(),
-
-				Runner: func(cmd *cmds.Command, args []string) error {
-					if len(args) != 1 {
-						return errors.New("expected URL argument")
-					}
-
-					var reqFunc func(string) (*http.Response, error)
-					switch flags.req.method {
-					case "GET", "get":
-						reqFunc = http.Get
-					case "HEAD", "head":
-						reqFunc = http.Head
-					default:
-						return fmt.Errorf("unrecognized HTTP method \"%s\"", args[0])
-					}
-
-					if flags.verbose {
-						log.Println("making http request")
-					}
-
-					resp, err := reqFunc(args[0])
-					if err != nil {
-						return err
-					}
-					defer resp.Body.Close()
-					b, err := io.ReadAll(resp.Body)
-					if err != nil {
-						return err
-					}
-					fmt.Println(string(b))
-
-					return nil
-				},
-			},
-		},
+		Commands: commands(),
 	}
-	cmd.Flags.Usage = cmd.DefaultUsage()
-	for _, cmd := range cmd.Commands {
-		cmd.Flags.Usage = cmd.DefaultUsage()
+	cmd.AddLogFlags()
+	cmd.Find("req").CompleteArgs = func(args []string, toComplete string) ([]string, cmds.Directive) {
+		var completions []string
+		for _, scheme := range []string{"http://", "https://"} {
+			if len(args) == 0 && strings.HasPrefix(scheme, toComplete) {
+				completions = append(completions, scheme)
+			}
+		}
+		return completions, cmds.CompleteNoSpace
 	}
+	cmd.Commands = append(cmd.Commands, cmds.HelpCommand(), cmds.CompletionCommand())
+	cmd.Flags.Usage = cmd.DefaultUsage()
 
+	// The errors of the Runner are returned instead of exiting.
 	if err := cmd.ParseRun(os.Args[1:]); err != nil {
-		log.Fatal(err)
+		fmt.Fprintln(cmd.ErrOutput(), cmds.DefaultFormatError(cmd, err))
+		os.Exit(cmd.ExitCode(err))
 	}
 }
@@ -1,44 +1,21 @@
 package cmds
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
-)
-
-// Err is the most generic error and is used to wrap all the errors returned
-// by this package, like [ErrCmd] and [ErrFlag] but not the ones returned by a
-// call to [RunnerFunc].
-var Err = errors.New("command error")
-
-// ErrCmd indicates an error while parsing commands.
-var ErrCmd = errors.New("command parse error")
-
-// ErrFlag indicates an error while parsing flags using [flag].
-var ErrFlag = errors.New("flag parse error")
-
-// ErrorHandling defines how [Command.Parse] behaves if parsing fails.
-// This only affects errors detected in [Command.Parse] itself and whatever
-// non-exported functions that it might call in this package so flag parsing
-// errors that occur as a result of calling [flag.FlagSet.Parse] still use the
-// error handling associated with that [flag.FlagSet].
-//
-//go:generate stringer -type ErrorHandling
-type ErrorHandling int
-
-const (
-	ReturnOnError ErrorHandling = iota
-
-	// When the error is wrapped by ErrCmd, call os.Exit(3), if it's wrapped by
-	// ErrFlag, call os.Exit(2), same as the flag package, otherwise, if the
-	// Runner returned the error, call os.Exit(1).
-	ExitOnError
-
-	PanicOnError
+	"runtime/debug"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // RunnerFunc is the function that will be run for the command.
@@ -49,41 +26,510 @@ const (
 // the Runner separately.
 type RunnerFunc func(cmd *Command, args []string) error
 
+// Middleware wraps a [RunnerFunc] with another one that can do something
+// before and after calling next, or not call it at all, see [Command.Use].
+type Middleware func(next RunnerFunc) RunnerFunc
+
 // Command defines a command to run as well as groups it's sub-commands.
 //
 // The root command (the one that will have it's run method invoked) should
 // define it's Runner if there are no Commands for it, otherwise it's unused.
 // The sub-commands should define both a Name and a Runner.
 // The other fields are optional.
+//
+// A sub-command with a LongDesc but without a Runner and Commands is a help
+// topic, it can't be run but it's listed separately in the usage message and
+// it's LongDesc can be shown with the command returned by [HelpCommand].
 type Command struct {
 	Name          string
+	Aliases       []string
 	ShortDesc     string
 	LongDesc      string
 	Flags         *flag.FlagSet
 	ErrorHandling ErrorHandling
 	Runner        RunnerFunc
 
+	// ArgsUsage describes the positional arguments in the synopsis of the
+	// usage message, like "[text...]" or "<url>".
+	ArgsUsage string
+
+	CompleteArgs CompleteFunc
+	Examples     []Example
+	FlagGroups   []FlagGroup
+	FlagOrder    FlagOrder
+
+	// FlagsFunc returns the FlagSet of the command when Flags is nil, it's
+	// called once when the command is matched while parsing or it's flags
+	// are needed for the usage message or completion, so that the FlagSets of
+	// large trees are only made for the commands that are used.
+	// If the returned FlagSet has no Usage it's set to
+	// [Command.DefaultUsage].
+	FlagsFunc func() *flag.FlagSet
+
+	// LoadFunc returns the command to use instead of this one, it's called
+	// once when the command is matched while parsing or it's flags or
+	// sub-commands are needed, so that commands that are read from files or
+	// plugins are only loaded when they're used and listing them in the usage
+	// message of their parent doesn't load them, see [ManifestFile].
+	// The Name, Aliases, ShortDesc and Hidden of the command are what's
+	// listed, the loaded command keeps the Name.
+	// The loaded command doesn't replace this one in the tree, it's what's
+	// matched in it's place when parsing.
+	LoadFunc func() (*Command, error)
+
+	// Args validates the positional arguments of a leaf command, see
+	// [ExactArgs] and the other functions returning an ArgsFunc.
+	Args ArgsFunc
+
+	// UsageOnError makes [Command.Parse] output the usage message of the
+	// command that the error is about when it fails because of a missing or
+	// unknown command, after the error is handled, so after the error
+	// message under ExitOnError.
+	UsageOnError bool
+
+	// HandleError is called with the errors handled according to
+	// ErrorHandling before they're handled, so that applications can log,
+	// translate or otherwise handle them themselves.
+	// The returned error is then handled according to ErrorHandling, unless
+	// it's nil, in which case the error is treated as handled and nil is
+	// returned to the caller.
+	HandleError func(err error) error
+
+	// OnError is called with the errors handled according to ErrorHandling
+	// and the command that they're about, before HandleError, for recording
+	// them with crash reporting or telemetry, see [Command.FlagValues].
+	OnError func(cmd *Command, err error)
+
+	// FormatError returns the message that an error is output as under
+	// ExitOnError, [DefaultFormatError] is used if it's nil.
+	FormatError func(cmd *Command, err error) string
+
+	// Timeout is how long the Runner of the command and it's sub-commands
+	// can run for when run with [Command.ParseRunContext] or the variants of
+	// it, after which it's context is cancelled.
+	// If the Runner then returns an error, it's wrapped by [ErrTimeout] and
+	// handled according to ErrorHandling.
+	// It can be set by a flag, since flags are parsed before the Runner is
+	// run.
+	Timeout time.Duration
+
+	// DryRun makes [Command.Do] output what would be done instead of doing it
+	// for the command and it's sub-commands, it's set by the flag added with
+	// [Command.AddDryRunFlag].
+	DryRun bool
+
+	// Yes makes [Command.Confirm] confirm without asking for the command and
+	// it's sub-commands, it's set by the flags added with
+	// [Command.AddYesFlag].
+	Yes bool
+
+	// OutputFormat is the format that [Command.Print] outputs values in for
+	// the command and it's sub-commands, like "json" or "template={{.Name}}",
+	// it's set by the flag added with [Command.AddOutputFlag].
+	OutputFormat string
+
+	// NoPager makes [Command.Pager] write the output directly instead of
+	// piping it through a pager for the command and it's sub-commands, it's
+	// set by the flag added with [Command.AddNoPagerFlag].
+	NoPager bool
+
+	// NoEmoji makes [Command.Status] output ASCII prefixes instead of emoji
+	// for the command and it's sub-commands, it's set by the flag added with
+	// [Command.AddNoEmojiFlag].
+	NoEmoji bool
+
+	// EnvFiles of the root command are loaded with [LoadEnv] before parsing,
+	// like ".env".
+	EnvFiles []string
+
+	// ChainSeparator of the root command makes [Command.ParseRun] and the
+	// variants of it run the commands in args separated by it in order,
+	// stopping at the first error, like "tool fmt ; vet ; build" with ";",
+	// which has to be quoted or escaped in shells.
+	// The flags of the root command have to be given before the first
+	// command, flags keep the values they were set to by earlier commands.
+	ChainSeparator string
+
+	// ResponseFiles of the root command makes the arguments like "@args.txt"
+	// be replaced by the arguments read from the file before parsing, see
+	// [ExpandResponseFiles].
+	ResponseFiles bool
+
+	// ExpandEnv of the root command makes the references like "${VAR}" in
+	// the arguments be replaced by the value of the environment variable
+	// before parsing, so that they're expanded the same way on every OS, the
+	// ones of variables that aren't set are removed.
+	// "$${" is replaced by "${" instead, for escaping.
+	ExpandEnv bool
+
+	// WindowsFlags of the root command makes the flags in the style of
+	// native Windows tools, like "/v", "/out:file" and "/?", be translated to
+	// the standard form before parsing when running on Windows, see
+	// [WindowsFlagArgs].
+	WindowsFlags bool
+
+	// UserAliases maps names that end users can give instead of a
+	// sub-command of the command to the arguments they're replaced by, like
+	// "st" to "status", "-s", they're merged with the ones of Config if it's
+	// an [AliasSource], like the "alias" table of a config file.
+	// The sub-commands take precedence over the aliases, the first argument
+	// of an alias must be a sub-command, they're listed in the usage message.
+	UserAliases map[string][]string
+
+	// FlagParser parses the flags of the command instead of Flags, like a
+	// FlagSet of github.com/spf13/pflag for POSIX style flags, see
+	// [FlagParser].
+	FlagParser FlagParser
+
+	// TransformArgs is called with the arguments of the command, the ones
+	// after it's name, before they're parsed and the result is parsed
+	// instead, for rewriting them like expanding aliases or renaming flags.
+	// An error it returns is wrapped by [ErrCmd].
+	TransformArgs func(args []string) ([]string, error)
+
+	// EnvPrefix of the root command makes the flags of the command and it's
+	// sub-commands that aren't given on the command line be set from the
+	// environment variables named after it and the flag, upper-cased and with
+	// the bytes that can't be in a variable name replaced with underscores,
+	// like "TOOL_DRY_RUN" for "dry-run" with the prefix "TOOL", see [Source].
+	EnvPrefix string
+
+	// Config of the root command provides the values of the flags that
+	// aren't given on the command line, see [ConfigSource].
+	Config ConfigSource
+
+	// SecretProviders of the root command resolve the values of flags from
+	// the environment and Config that are references like
+	// "file:///run/secrets/token", by the scheme of the reference, so that
+	// credentials can be kept out of config files, see [SecretProvider].
+	SecretProviders map[string]SecretProvider
+
+	// Instrumentation of the root command is notified when parsing and
+	// running commands starts and ends, for tracing and metrics.
+	Instrumentation Instrumentation
+
+	// ExitCodeStyle sets the default exit codes that ExitOnError exits with.
+	ExitCodeStyle ExitCodeStyle
+
+	// RecoverPanics makes [Command.Run] and [Command.ParseRun] recover from
+	// panics in the Runner and handle them as a [PanicError] according to
+	// ErrorHandling, instead of crashing the program.
+	RecoverPanics bool
+
+	// Prompt makes the command prompt for the Required flags in it's
+	// FlagMeta that aren't set, and for more arguments if it's Args fail,
+	// instead of failing when it's Input is a terminal, for a friendlier
+	// first run that stays scriptable.
+	// The input for flags that are Secret isn't echoed.
+	Prompt bool
+
+	// UsageHeader and UsageFooter are output before and after the usage
+	// message by [Command.DefaultUsage], for things like a logo line, a
+	// copyright notice or a hint about where to get more help.
+	UsageHeader UsageTextFunc
+	UsageFooter UsageTextFunc
+
+	// Hidden hides the command from the usage message and shell completion
+	// of it's parent, it can still be run.
+	Hidden bool
+
+	// Deprecated marks the command as deprecated, it should say what should
+	// be used instead.
+	// A warning is output with [Command.Warnf] when a deprecated command or
+	// flag is used.
+	// Deprecated commands aren't completed unless the root command has
+	// AnnotateDeprecated set.
+	Deprecated string
+
+	// AnnotateDeprecated makes the shell completion of a root command include
+	// deprecated commands and flags, with their Deprecated message as the
+	// description where the shell can show one.
+	AnnotateDeprecated bool
+
+	// HideAliases hides the aliases of the sub-commands in the usage message.
+	HideAliases bool
+
+	// NoRunnerNames keeps the sub-commands without a Name unnamed, instead of
+	// naming them after the function of their Runner, see
+	// [Command.NameCommands].
+	NoRunnerNames bool
+
+	// DocsURL is the URL of the full documentation for the command, it's
+	// shown in the usage message.
+	DocsURL string
+
+	// Hyperlinks makes the usage message of the command and it's sub-commands
+	// output DocsURL as OSC 8 terminal hyperlinks, the names of sub-commands
+	// that have a DocsURL are also linked to it.
+	Hyperlinks bool
+
+	// Plugins makes a sub-command of the command or it's sub-commands that
+	// isn't found run the program in $PATH named after the command's Path
+	// and the sub-command joined with "-", like "git-foo", if there's one.
+	// See [Command.Plugin].
+	Plugins bool
+
+	// Stdin, Stdout and Stderr are the streams of the command and it's
+	// sub-commands unless they set their own, see [Command.Input],
+	// [Command.Output] and [Command.ErrOutput].
+	// If they're nil the streams of the parent command or finally the ones
+	// in package os are used.
+	// The output of the package, like usage, error messages and completion
+	// scripts, is written to them and Runners should use them too, so that
+	// tests can capture it.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Messages are the texts output for the command and it's sub-commands
+	// unless they set their own, if it's nil [DefaultMessages] are used.
+	Messages *Messages
+
+	// FlagMeta is information about the flags in Flags that [flag.Flag] has
+	// no place for, by flag name, see [Command.Meta].
+	FlagMeta map[string]*FlagMeta
+
 	Commands []*Command
+
+	parent     *Command
+	exitCodes  []exitCode
+	errOutput  io.Writer
+	warnOutput io.Writer
+	ctx        context.Context
+	cleanups   []func()
+	exitHooks  *exitHooks
+	sources    map[string]Source
+	middleware []Middleware
+	logger     *slog.Logger
+	logLevel   *slog.LevelVar
+	declared   []string
+
+	logFlagsLogger bool // logger was made by AddLogFlags
+	adoptGlobal    bool
+	adoptGroup     string
+	findIndex      atomic.Value // *findIndex
+	usageCache     atomic.Value // *usageCache
+	lazy           atomic.Value // *lazyState
 }
 
-// Find finds the sub-command with the given name.
-func (cmd *Command) Find(name string) *Command {
-	for _, sub := range cmd.Commands {
-		if sub.Name == name {
-			return sub
+// FlagMeta is information about a flag that [flag.Flag] has no place for.
+type FlagMeta struct {
+	// Hidden hides the flag from the usage message and shell completion.
+	Hidden bool
+
+	// Deprecated marks the flag as deprecated, same as for
+	// [Command.Deprecated].
+	Deprecated string
+
+	// Required makes parsing fail if the flag isn't set.
+	Required bool
+
+	// Secret makes [Command.FlagValues] redact the value of the flag and
+	// keeps it out of the environment of plugins, see [Command.Plugin].
+	Secret bool
+
+	// Env is the environment variable that sets the flag if it isn't given
+	// on the command line, instead of the one named after EnvPrefix.
+	Env string
+
+	// Choices are the valid values of the flag, they're offered as
+	// completions for the flag's value and parsing fails if the flag is set
+	// to any other value.
+	Choices []string
+
+	// Complete returns the completions for the flag's value, it's passed the
+	// positional arguments before the flag, which there usually are none of,
+	// and the value being completed.
+	Complete CompleteFunc
+}
+
+// deprecated returns the Deprecated message of m, which can be nil.
+func (m *FlagMeta) deprecated() string {
+	if m == nil {
+		return ""
+	}
+	return m.Deprecated
+}
+
+// Meta returns the [FlagMeta] of the flag with the given name, adding it to
+// FlagMeta first if it isn't there.
+func (cmd *Command) Meta(name string) *FlagMeta {
+	if cmd.FlagMeta == nil {
+		cmd.FlagMeta = make(map[string]*FlagMeta)
+	}
+	m := cmd.FlagMeta[name]
+	if m == nil {
+		m = &FlagMeta{}
+		cmd.FlagMeta[name] = m
+	}
+	return m
+}
+
+// FlagValues returns the values of the flags of cmd that were set, by flag
+// name, with the values of flags that have Secret set in their [FlagMeta]
+// replaced with "REDACTED".
+func (cmd *Command) FlagValues() map[string]string {
+	values := make(map[string]string)
+	if cmd.Flags == nil {
+		return values
+	}
+	cmd.visitSet(func(f *flag.Flag) {
+		if meta := cmd.FlagMeta[f.Name]; meta != nil && meta.Secret {
+			values[f.Name] = "REDACTED"
+			return
+		}
+		values[f.Name] = f.Value.String()
+	})
+	return values
+}
+
+// Use adds middleware that wraps the Runners of cmd and it's sub-commands.
+// The middleware of the commands from the root to the leaf command that's run
+// are applied in that order, each command's in the order they were added, so
+// the root command's first middleware is the outermost.
+func (cmd *Command) Use(mw ...Middleware) {
+	cmd.middleware = append(cmd.middleware, mw...)
+}
+
+// Short returns the ShortDesc of cmd, or if it's empty, the first sentence or
+// line of LongDesc, whichever is shorter, without the trailing period.
+// A sentence ends with a period followed by a space and an uppercase letter,
+// so that abbreviations like "e.g." don't end it.
+func (cmd *Command) Short() string {
+	if cmd.ShortDesc != "" {
+		return cmd.ShortDesc
+	}
+
+	desc := strings.TrimSpace(cmd.LongDesc)
+	if i := strings.IndexByte(desc, '\n'); i >= 0 {
+		desc = desc[:i]
+	}
+	for i := 0; ; i++ {
+		j := strings.Index(desc[i:], ". ")
+		if j < 0 {
+			break
+		}
+		i += j
+		if r, _ := utf8.DecodeRuneInString(desc[i+2:]); unicode.IsUpper(r) {
+			desc = desc[:i]
+			break
+		}
+	}
+	return strings.TrimSuffix(strings.TrimSpace(desc), ".")
+}
+
+// Graft adds subs as sub-commands of cmd, for commands that come from
+// elsewhere, like Go plugins.
+// Unlike appending them to Commands it returns an error and adds none of them
+// if one has the same name or alias as an existing sub-command of cmd, or if
+// one of them or their sub-commands shares it's FlagSet with another command
+// in the tree or uses [flag.CommandLine], so that their flags are isolated.
+// The FlagSets without a Usage get [Command.DefaultUsage].
+func (cmd *Command) Graft(subs ...*Command) error {
+	fsets := map[*flag.FlagSet]bool{flag.CommandLine: true}
+	var walk func(c *Command)
+	walk = func(c *Command) {
+		if c.Flags != nil {
+			fsets[c.Flags] = true
+		}
+		for _, sub := range c.Commands {
+			walk(sub)
+		}
+	}
+	walk(cmd.root())
+
+	names := map[string]bool{}
+	var check func(c *Command) error
+	check = func(c *Command) error {
+		if c.Flags != nil {
+			if fsets[c.Flags] {
+				return fmt.Errorf("command \"%s\" shares it's FlagSet with another command", c.Name)
+			}
+			fsets[c.Flags] = true
+		}
+		for _, sub := range c.Commands {
+			if err := check(sub); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, sub := range subs {
+		for _, name := range append([]string{sub.Name}, sub.Aliases...) {
+			if cmd.Find(name) != nil || names[name] {
+				return fmt.Errorf("command \"%s\" already exists", name)
+			}
+			names[name] = true
+		}
+		if err := check(sub); err != nil {
+			return err
 		}
 	}
 
+	var setUsage func(c *Command)
+	setUsage = func(c *Command) {
+		if c.Flags != nil && c.Flags.Usage == nil {
+			c.Flags.Usage = c.DefaultUsage()
+		}
+		for _, sub := range c.Commands {
+			setUsage(sub)
+		}
+	}
+	for _, sub := range subs {
+		setUsage(sub)
+	}
+	cmd.Commands = append(cmd.Commands, subs...)
 	return nil
 }
 
+// IsTopic reports whether cmd is a help topic.
+func (cmd *Command) IsTopic() bool {
+	return cmd.Runner == nil && len(cmd.Commands) == 0 && cmd.LoadFunc == nil && cmd.LongDesc != ""
+}
+
 // Parse parses the flags and commands in args and returns the leaf command
 // that mached (the last command without set Commands) as well as the arguments
 // that should be passed to it.
 func (cmd *Command) Parse(args []string) (*Command, []string, error) {
-	leafCmd, args, err := cmd.parse(args)
+	return cmd.parseHandle(args, false)
+}
+
+// parseHandle parses args, reports it to the Instrumentation and handles the
+// error, see [Command.parse] for copies.
+func (cmd *Command) parseHandle(args []string, copies bool) (*Command, []string, error) {
+	if err := LoadEnv(cmd.EnvFiles...); err != nil {
+		return nil, nil, cmd.handleErrorAt(cmd, fmt.Errorf("%w: %w", Err, err))
+	}
+
+	if cmd.ResponseFiles {
+		var err error
+		if args, err = ExpandResponseFiles(args); err != nil {
+			return nil, nil, cmd.handleErrorAt(cmd, newCommandError(cmd, "", err, err.Error()))
+		}
+	}
+
+	if cmd.ExpandEnv {
+		args = expandEnvArgs(args)
+	}
+
+	if cmd.WindowsFlags {
+		args = WindowsFlagArgs(args)
+	}
+
+	in := cmd.Instrumentation
+	var ctx context.Context
+	var start time.Time
+	if in != nil {
+		ctx = in.ParseStart(cmd.Context(), cmd, args)
+		start = time.Now()
+	}
+
+	leafCmd, args, err := cmd.parse(args, copies)
+	if in != nil {
+		in.ParseEnd(ctx, leafCmd, time.Since(start), err)
+	}
 	if err != nil {
-		err = handleError(err, cmd.ErrorHandling)
+		err = cmd.handleErrorAt(leafCmd, err)
 		return nil, nil, err
 	}
 
@@ -92,31 +538,146 @@ func (cmd *Command) Parse(args []string) (*Command, []string, error) {
 
 func (cmd *Command) Run(args []string) error {
 	if cmd.Runner == nil {
-		err := handleError(fmt.Errorf("%w: nil runner", ErrCmd), cmd.ErrorHandling)
+		err := cmd.handleError(newCommandError(cmd, "", ErrNilRunner, cmd.messages().NilRunner))
 		return err
 	}
-	return handleError(cmd.Runner(cmd, args), cmd.ErrorHandling)
+	return cmd.handleError(cmd.run(cmd, args))
 }
 
 // ParseRun parses the flags and commands in args, same as [Parse] and then
 // runs the [RunnerFunc] for the leaf command.
 func (cmd *Command) ParseRun(args []string) error {
+	return cmd.ParseRunContext(context.Background(), args)
+}
+
+// ParseRunContext is like [Command.ParseRun] but the Runner can get ctx with
+// [Command.Context].
+// If cmd has a ChainSeparator, args are split at it and each part is parsed
+// and run in order until one fails.
+func (cmd *Command) ParseRunContext(ctx context.Context, args []string) error {
+	if cmd.ChainSeparator != "" {
+		for _, args := range splitChain(args, cmd.ChainSeparator) {
+			if err := cmd.parseRunContext(ctx, args); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return cmd.parseRunContext(ctx, args)
+}
+
+// splitChain splits args at the arguments that are sep.
+func splitChain(args []string, sep string) [][]string {
+	chain := [][]string{nil}
+	for _, arg := range args {
+		if arg == sep {
+			chain = append(chain, nil)
+			continue
+		}
+		chain[len(chain)-1] = append(chain[len(chain)-1], arg)
+	}
+	return chain
+}
+
+func (cmd *Command) parseRunContext(ctx context.Context, args []string) error {
+	cmd.ctx = ctx
 	leafCmd, args, err := cmd.Parse(args)
-	if err != nil {
+	if err != nil || leafCmd == nil {
+		// The leaf command is nil without an error if HandleError handled it.
 		return err
 	}
 
+	return cmd.runLeaf(ctx, leafCmd, args)
+}
+
+// runLeaf runs leafCmd with ctx after parsing with cmd.
+func (cmd *Command) runLeaf(ctx context.Context, leafCmd *Command, args []string) error {
+	cmd.ctx = ctx
 	if leafCmd.Runner == nil {
-		err := fmt.Errorf("%w: %w", ErrCmd, errors.New("nil runner"))
-		err = handleError(err, cmd.ErrorHandling)
+		err := newCommandError(leafCmd, "", ErrNilRunner, leafCmd.messages().NilRunner)
+		err = cmd.handleErrorAt(leafCmd, err)
 		return err
 	}
 
-	return leafCmd.Runner(leafCmd, args)
+	timeout := leafCmd.timeout()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+		cmd.ctx = ctx
+	}
+
+	err := cmd.run(leafCmd, args)
+	if err != nil && timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("%w after %v: %w", ErrTimeout, timeout, err)
+	}
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) || errors.Is(err, ErrTimeout) {
+		err = cmd.handleErrorAt(leafCmd, err)
+	}
+	return err
+}
+
+// timeout returns the Timeout of cmd or the closest of it's parents.
+func (cmd *Command) timeout() time.Duration {
+	for c := cmd; c != nil; c = c.parent {
+		if c.Timeout > 0 {
+			return c.Timeout
+		}
+	}
+	return 0
+}
+
+// run runs the Runner of leafCmd, recovering from panics in it if cmd has
+// RecoverPanics set, and then the functions registered with
+// [Command.Cleanup] and [Command.OnExit].
+// The Runner is wrapped by the middleware added with [Command.Use] and
+// reported to the Instrumentation of cmd.
+func (cmd *Command) run(leafCmd *Command, args []string) (err error) {
+	if in := cmd.Instrumentation; in != nil {
+		ctx := in.RunStart(cmd.Context(), leafCmd, args)
+		start := time.Now()
+		ctxBefore := cmd.ctx
+		cmd.ctx = ctx
+		defer func() {
+			cmd.ctx = ctxBefore
+			in.RunEnd(ctx, leafCmd, time.Since(start), err)
+		}()
+	}
+	if cmd.RecoverPanics {
+		defer func() {
+			if v := recover(); v != nil {
+				err = &PanicError{Value: v, Stack: debug.Stack()}
+			}
+		}()
+	}
+	defer cmd.runExitHooks()
+	defer cmd.runCleanups()
+
+	runner := leafCmd.Runner
+	for c := leafCmd; c != nil; c = c.parent {
+		for i := len(c.middleware) - 1; i >= 0; i-- {
+			runner = c.middleware[i](runner)
+		}
+	}
+	return runner(leafCmd, args)
 }
 
-func (cmd *Command) parse(args []string) (*Command, []string, error) {
+// parse returns the command that parsing failed at along with the error.
+// If copies is set, the commands are copies made with [Command.parseCopy] so
+// that the tree isn't modified.
+func (cmd *Command) parse(args []string, copies bool) (*Command, []string, error) {
+	cmd, err := cmd.loaded()
+	if err != nil {
+		return cmd, nil, err
+	}
+	cmd.adoptGlobalFlags()
+	cmd.loadFlags()
+	if copies {
+		cmd = cmd.parseCopy(nil)
+	}
 	rootCmd := cmd
+	var errs []error
 	for {
 		if cmd.Flags == nil {
 			var errHandling flag.ErrorHandling
@@ -133,48 +694,284 @@ func (cmd *Command) parse(args []string) (*Command, []string, error) {
 			args = args[1:]
 		}
 
-		if err := cmd.Flags.Parse(args); err != nil {
-			return nil, nil, fmt.Errorf("%w: %w", ErrFlag, err)
+		if cmd.TransformArgs != nil {
+			var err error
+			args, err = cmd.TransformArgs(append([]string(nil), args...))
+			if err != nil {
+				return cmd, nil, joinErrors(errs, newCommandError(cmd, "", err, ""))
+			}
+		}
+
+		cmd.setFlagsOutput()
+		parseFlags, flagArgs := cmd.Flags.Parse, cmd.Flags.Args
+		if cmd.FlagParser != nil {
+			parseFlags, flagArgs = cmd.FlagParser.Parse, cmd.FlagParser.Args
+		}
+		if err := parseFlags(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return cmd, nil, ErrHelp
+			}
+			return cmd, nil, joinErrors(errs, newFlagError(cmd, err))
+		}
+		args = flagArgs()
+		if cs, ok := cmd.Config.(CommandConfigSource); ok && copies && cmd == rootCmd {
+			cmd.Config = cs.ForCommand(cmd)
+		}
+		if err := cmd.resolveFlags(rootCmd); err != nil {
+			return cmd, nil, joinErrors(errs, err)
 		}
-		args = cmd.Flags.Args()
+		cmd.warnDeprecated()
+		if cmd.Prompt {
+			if err := cmd.promptFlags(); err != nil {
+				return cmd, nil, joinErrors(errs, err)
+			}
+		}
+		errs = append(errs, cmd.validateFlags()...)
 
 		// Is leaf command.
 		if len(cmd.Commands) == 0 {
+			if cmd.IsTopic() && cmd != rootCmd {
+				return cmd, nil, joinErrors(errs, newCommandError(cmd, cmd.Name, ErrHelpTopic, fmt.Sprintf(cmd.messages().HelpTopic, cmd.Name)))
+			}
+			if cmd.Args != nil {
+				err := cmd.Args(args)
+				if err != nil && cmd.interactive() {
+					args, err = cmd.promptArgs(args, err)
+				}
+				if err != nil {
+					errs = append(errs, newCommandError(cmd, "", fmt.Errorf("%w: %w", ErrArgs, err), fmt.Sprintf(cmd.messages().InvalidArgs, err)))
+				}
+			}
+			if len(errs) > 0 {
+				return cmd, nil, joinErrors(errs, nil)
+			}
 			return cmd, args, nil
 		}
 
 		if len(args) == 0 {
-			var err error
-			if cmd.Name == rootCmd.Name {
-				err = errors.New("missing command")
-			} else {
-				err = fmt.Errorf("missing command for \"%s\"", cmd.Name)
+			msg := cmd.messages().MissingCommand
+			if cmd.Name != rootCmd.Name {
+				msg = fmt.Sprintf(cmd.messages().MissingCommandFor, cmd.Name)
+			}
+			return cmd, nil, joinErrors(errs, newCommandError(cmd, "", ErrMissingCommand, msg))
+		}
+
+		cmd.nameCommands()
+		name := args[0]
+		sub := cmd.Find(name)
+		if sub == nil {
+			aliases, err := cmd.aliases()
+			if err != nil {
+				return cmd, nil, joinErrors(errs, err)
+			}
+			if alias := aliases[name]; len(alias) > 0 {
+				args = append(append([]string(nil), alias...), args[1:]...)
+				sub = cmd.Find(args[0])
+			}
+		}
+		if sub == nil && cmd.plugins() {
+			sub = cmd.Plugin(args[0])
+			if sub != nil {
+				if len(errs) > 0 {
+					return sub, nil, joinErrors(errs, nil)
+				}
+				return sub, args[1:], nil
 			}
-			return nil, nil, fmt.Errorf("%w: %w", ErrCmd, err)
 		}
+		if sub == nil {
+			return cmd, nil, joinErrors(errs, cmd.unknownCommandError(name))
+		}
+		sub, err := sub.loaded()
+		if err != nil {
+			return sub, nil, joinErrors(errs, err)
+		}
+		sub.loadFlags()
+		if copies {
+			sub = sub.parseCopy(cmd)
+		} else {
+			sub.parent = cmd
+		}
+		if sub.Deprecated != "" {
+			sub.Warnf(sub.messages().DeprecatedCommand, sub.Name, sub.Deprecated)
+		}
+		cmd = sub
+	}
+}
+
+// Reset restores the flags of cmd and it's sub-commands to their default
+// values and clears what was left from parsing, so that [Command.ParseRun]
+// and the others can be run again as if the tree was new, like in tests.
+// The FlagSets are reset in place so references to them stay valid, flag
+// values that can't be Set to their default value are left as they are.
+// The FlagParser is reset too if it has a Reset method, so that the flags
+// aren't reported as set on the command line by [Command.ValueSource].
+func (cmd *Command) Reset() {
+	cmd.parent = nil
+	cmd.ctx = nil
+	cmd.cleanups = nil
+	cmd.sources = nil
+	cmd.usageCache.Store((*usageCache)(nil))
+	if r, ok := cmd.FlagParser.(flagParserResetter); ok {
+		r.Reset()
+	}
+
+	if cmd.Flags != nil {
+		fset := flag.NewFlagSet(cmd.Flags.Name(), cmd.Flags.ErrorHandling())
+		fset.SetOutput(cmd.Flags.Output())
+		fset.Usage = cmd.Flags.Usage
+		cmd.Flags.VisitAll(func(f *flag.Flag) {
+			f.Value.Set(f.DefValue)
+			fset.Var(f.Value, f.Name, f.Usage)
+			fset.Lookup(f.Name).DefValue = f.DefValue
+		})
+		*cmd.Flags = *fset
+	}
+
+	for _, sub := range cmd.Commands {
+		if l, _ := sub.lazy.Load().(*lazyState); l != nil && l.loadedCmd != nil {
+			l.loadedCmd.Reset()
+		}
+		sub.Reset()
+	}
+}
+
+// Parent returns the command that cmd was matched as a sub-command of during
+// the last parse, or nil if it wasn't.
+func (cmd *Command) Parent() *Command {
+	return cmd.parent
+}
+
+// hyperlinks reports whether cmd or any of it's parents has Hyperlinks set.
+func (cmd *Command) hyperlinks() bool {
+	for c := cmd; c != nil; c = c.parent {
+		if c.Hyperlinks {
+			return true
+		}
+	}
+	return false
+}
+
+// loaded returns the command that LoadFunc returns for cmd, calling it the
+// first time, or cmd itself if it has no LoadFunc or LoadFunc failed, the
+// error is returned again by the later calls.
+// The loaded command has the Name of cmd and isn't put in the tree in place of
+// cmd, so that loading doesn't modify the tree while it's parsed with
+// [Command.ParseArgs] from multiple goroutines.
+func (cmd *Command) loaded() (*Command, error) {
+	if cmd.LoadFunc == nil {
+		return cmd, nil
+	}
+	l := cmd.lazyState()
+	l.load.Do(func() {
+		c, err := cmd.LoadFunc()
+		if err == nil && c == nil {
+			err = errors.New("LoadFunc returned no command")
+		}
+		if err != nil {
+			l.loadErr = newCommandError(cmd, "", err, fmt.Sprintf("%s: %v", cmd.Name, err))
+			return
+		}
+		c.Name = cmd.Name
+		if c.Flags != nil && isDefaultUsage(c.Flags.Usage) {
+			c.Flags.Usage = c.DefaultUsage()
+		}
+		l.loadedCmd = c
+	})
+	if l.loadErr != nil {
+		return cmd, l.loadErr
+	}
+	return l.loadedCmd, nil
+}
 
-		cmd = cmd.Find(args[0])
-		if cmd == nil {
-			return nil, nil, fmt.Errorf("%w: %w", ErrCmd, fmt.Errorf("no such command \"%s\"", args[0]))
+// loadFlags sets Flags to what FlagsFunc returns if it's nil.
+// FlagsFunc is only called once, even when cmd is parsed with
+// [Command.ParseArgs] from multiple goroutines.
+func (cmd *Command) loadFlags() {
+	if cmd.FlagsFunc == nil {
+		return
+	}
+	cmd.lazyState().flags.Do(func() {
+		if cmd.Flags != nil {
+			return
+		}
+		cmd.Flags = cmd.FlagsFunc()
+		if cmd.Flags != nil && cmd.Flags.Usage == nil {
+			cmd.Flags.Usage = cmd.DefaultUsage()
 		}
+	})
+}
+
+// lazyState is what's set up for a command when it's first needed, it's
+// shared by the copies that [Command.ParseArgs] makes of the command.
+type lazyState struct {
+	flags sync.Once
+	names sync.Once
+
+	load      sync.Once
+	loadedCmd *Command
+	loadErr   error
+}
+
+// lazyState returns the lazyState of cmd, making it the first time.
+func (cmd *Command) lazyState() *lazyState {
+	if l, _ := cmd.lazy.Load().(*lazyState); l != nil {
+		return l
+	}
+	cmd.lazy.CompareAndSwap(nil, &lazyState{})
+	return cmd.lazy.Load().(*lazyState)
+}
+
+// Path returns the names of the commands from the root to cmd, as matched
+// during the last parse.
+func (cmd *Command) Path() []string {
+	n := 0
+	for c := cmd; c != nil; c = c.parent {
+		n++
+	}
+	path := make([]string, n)
+	for c := cmd; c != nil; c = c.parent {
+		n--
+		path[n] = c.Name
 	}
+	return path
 }
 
 // Default is the default command with some convenience functions, similar to
 // how the [flag] package has a [flag.CommandLine] for the default
 // [flag.FlagSet].
+// It's Name, if it's empty, and it's Flags, if they're nil, are set to the
+// base name of os.Args[0] and flag.CommandLine at it's first use, not when the
+// package is initialized, so that tests and programs embedding others can
+// replace them before.
 var Default = &Command{
-	Name:          filepath.Base(os.Args[0]),
 	ErrorHandling: ExitOnError,
 }
 
+var defaultOnce sync.Once
+
 func init() {
-	Default.Flags = flag.CommandLine
-	Default.Flags.Usage = Default.DefaultUsage()
+	Default.FlagsFunc = func() *flag.FlagSet {
+		initDefault()
+		return Default.Flags
+	}
+}
+
+// initDefault sets the Name and Flags of Default.
+func initDefault() {
+	defaultOnce.Do(func() {
+		if Default.Name == "" {
+			Default.Name = filepath.Base(os.Args[0])
+		}
+		if Default.Flags == nil {
+			Default.Flags = flag.CommandLine
+			Default.Flags.Usage = Default.DefaultUsage()
+		}
+	})
 }
 
 // Parse runs [Command.Parse] on the [Default] command.
 func Parse() (*Command, []string, error) {
+	initDefault()
 	leafCmd, args, err := Default.Parse(os.Args[1:])
 	if err != nil {
 		return nil, nil, err
@@ -184,110 +981,82 @@ func Parse() (*Command, []string, error) {
 }
 
 func Run(args []string) error {
+	initDefault()
 	return Default.Run(args)
 }
 
 // ParseRun runs [Command.ParseRun] on the [Default] command.
 func ParseRun() error {
+	initDefault()
 	return Default.ParseRun(os.Args[1:])
 }
 
+// ParseRunWithSignals runs [Command.ParseRunWithSignals] on the [Default]
+// command.
+func ParseRunWithSignals(signals ...os.Signal) error {
+	initDefault()
+	return Default.ParseRunWithSignals(os.Args[1:], signals...)
+}
+
 // Flags returns the [flag.FlagSet] of the [Default] command.
 func Flags() *flag.FlagSet {
+	initDefault()
 	return Default.Flags
 }
 
 // Add adds the commands to the [Default] command.
 func Add(cmds ...*Command) {
+	initDefault()
 	Default.Commands = append(Default.Commands, cmds...)
 }
 
-// DefaultUsage returns a usage message for use in [flag.FlagSet.Usage] that
-// outputs the command name on the first line followed by the long description,
-// the sub-command names and short descriptions on the right of the names, and
-// finally the flags for the current command.
-func (cmd *Command) DefaultUsage() func() {
-	return func() {
-		var w io.Writer
-		if cmd.Flags != nil {
-			w = cmd.Flags.Output()
-		} else {
-			w = os.Stderr
-		}
-
-		if cmd.Name == "" {
-			fmt.Fprintf(w, "Usage:\n")
-		} else {
-			fmt.Fprintf(w, "Usage of %s:\n", cmd.Name)
-		}
-
-		if cmd.LongDesc != "" {
-			fmt.Fprintf(w, "\n%s\n", cmd.LongDesc)
-		}
-
-		if len(cmd.Commands) > 0 {
-			var longest int
-			for _, cmd := range cmd.Commands {
-				if l := len(cmd.Name); l > longest {
-					longest = l
-				}
-			}
-
-			fmt.Fprintf(w, "\nCommands:\n")
-			for _, sub := range cmd.Commands {
-				if sub.Name != "" {
-					fmt.Fprintf(w, "  %-*s  %s\n", longest+1, sub.Name, sub.ShortDesc)
-				}
-			}
-		}
-
-		if cmd.Flags != nil {
-			var longest int
-			cmd.Flags.VisitAll(func(f *flag.Flag) {
-				if l := len(f.Name); l > longest {
-					longest = l
-				}
-			})
-
-			if longest != 0 {
-				fmt.Fprintf(w, "\nFlags:\n")
-
-				cmd.Flags.VisitAll(func(f *flag.Flag) {
-					// So that flags with and without usage string are aligned equally.
-					usage := f.Usage
-					if usage != "" {
-						usage += " "
-					}
+// AddAt adds the commands to the [Default] command with [Command.AddAt].
+func AddAt(path string, cmds ...*Command) {
+	initDefault()
+	Default.AddAt(path, cmds...)
+}
 
-					fmt.Fprintf(w, "  -%-*s  %s(default: %s)\n", longest+1, f.Name, usage, f.DefValue)
-				})
-			}
+// AddAt adds the commands as sub-commands of the command at path under cmd,
+// the names of the commands from cmd separated by spaces like "db migrate",
+// the ones that don't exist are added without a Runner, so that separate
+// files or packages can add commands under the same one.
+// The commands with these names are looked up with [Command.Find].
+func (cmd *Command) AddAt(path string, cmds ...*Command) {
+	parent := cmd
+	for _, name := range strings.Fields(path) {
+		sub := parent.Find(name)
+		if sub == nil {
+			sub = &Command{Name: name}
+			parent.Commands = append(parent.Commands, sub)
 		}
+		parent = sub
 	}
+	parent.Commands = append(parent.Commands, cmds...)
+	parent.NameCommands()
 }
 
-func handleError(err error, errorHandling ErrorHandling) error {
-	if err == nil {
-		return nil
-	}
+// Register adds a sub-command to the [Default] command with
+// [Command.Register].
+func Register(name, short string, runner RunnerFunc, flagSetup func(*flag.FlagSet)) *Command {
+	initDefault()
+	return Default.Register(name, short, runner, flagSetup)
+}
 
-	if errors.Is(err, ErrCmd) || errors.Is(err, ErrFlag) {
-		err = fmt.Errorf("%w: %w", Err, err)
+// Register adds a sub-command named name with the ShortDesc short and runner
+// to cmd and returns it, for small tools that don't need anything else.
+// If flagSetup isn't nil it's called with the FlagSet of the sub-command to
+// define it's flags.
+func (cmd *Command) Register(name, short string, runner RunnerFunc, flagSetup func(*flag.FlagSet)) *Command {
+	sub := &Command{
+		Name:      name,
+		ShortDesc: short,
+		Runner:    runner,
+		Flags:     flag.NewFlagSet(name, flag.ContinueOnError),
 	}
-
-	switch errorHandling {
-	case ExitOnError:
-		log.Println(err)
-		if errors.Is(err, ErrCmd) {
-			os.Exit(3)
-		}
-		if errors.Is(err, ErrFlag) {
-			os.Exit(2)
-		}
-		os.Exit(1)
-	case PanicOnError:
-		panic(err)
+	sub.Flags.Usage = sub.DefaultUsage()
+	if flagSetup != nil {
+		flagSetup(sub.Flags)
 	}
-
-	return err
+	cmd.Commands = append(cmd.Commands, sub)
+	return sub
 }
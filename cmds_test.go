@@ -5,13 +5,12 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"os"
 	"reflect"
 	"strings"
 	"testing"
 )
 
-// TODO: Test idempotence.
-
 func TestRunnerNil(t *testing.T) {
 	cmd := &Command{}
 	expectError(t, cmd.ParseRun(nil))
@@ -107,6 +106,47 @@ func TestCmdNoFlags(t *testing.T) {
 	expectFalse(t, sub1Ran)
 }
 
+func TestCmdAliases(t *testing.T) {
+	var ran string
+	cmd := &Command{
+		Commands: []*Command{
+			{
+				Name:    "remove",
+				Aliases: []string{"rm"},
+				Runner: func(cmd *Command, args []string) error {
+					ran = cmd.Name
+					return nil
+				},
+			},
+			{
+				Name:   "rm",
+				Runner: nopRunner,
+			},
+		},
+	}
+
+	expectEq(t, cmd.Find("rm"), cmd.Commands[1])
+	cmd.Commands = cmd.Commands[:1]
+	expectEq(t, cmd.Find("rm"), cmd.Commands[0])
+	expectErrorNone(t, cmd.ParseRun([]string{"rm"}))
+	expectEq(t, ran, "remove")
+}
+
+func TestShort(t *testing.T) {
+	cmd := &Command{LongDesc: "Do things. And more things."}
+	expectEq(t, cmd.Short(), "Do things")
+	cmd.LongDesc = "Do things, e.g. these\nand more things."
+	expectEq(t, cmd.Short(), "Do things, e.g. these")
+	cmd.LongDesc = "Do things, e.g. these. And more things."
+	expectEq(t, cmd.Short(), "Do things, e.g. these")
+	cmd.LongDesc = "Do things\nand more things."
+	expectEq(t, cmd.Short(), "Do things")
+	cmd.LongDesc = "Do things."
+	expectEq(t, cmd.Short(), "Do things")
+	cmd.ShortDesc = "short"
+	expectEq(t, cmd.Short(), "short")
+}
+
 func TestFlagsSimple(t *testing.T) {
 	type flags struct {
 		A bool
@@ -223,6 +263,192 @@ func TestErrReturn(t *testing.T) {
 	expectErrorNot(t, err, ErrFlag)
 }
 
+func TestTransformArgs(t *testing.T) {
+	var gotArgs []string
+	var method string
+	cmd := &Command{
+		Name: "test",
+		TransformArgs: func(args []string) ([]string, error) {
+			if len(args) > 0 && args[0] == "get" {
+				return append([]string{"req", "-m", "GET"}, args[1:]...), nil
+			}
+			return args, nil
+		},
+		Commands: []*Command{
+			{
+				Name: "req",
+				Flags: func() *flag.FlagSet {
+					fset := flag.NewFlagSet("req", flag.ContinueOnError)
+					fset.StringVar(&method, "m", "", "")
+					return fset
+				}(),
+				TransformArgs: func(args []string) ([]string, error) {
+					for i, arg := range args {
+						if arg == "-method" {
+							args[i] = "-m"
+						}
+						if arg == "-x" {
+							return nil, errors.New("-x was removed")
+						}
+					}
+					return args, nil
+				},
+				Runner: func(cmd *Command, args []string) error {
+					gotArgs = args
+					return nil
+				},
+			},
+		},
+	}
+
+	expectErrorNone(t, cmd.ParseRun([]string{"get", "url"}))
+	expectEq(t, method, "GET")
+	expectEq(t, gotArgs, []string{"url"})
+
+	args := []string{"req", "-method", "HEAD", "url"}
+	expectErrorNone(t, cmd.ParseRun(args))
+	expectEq(t, method, "HEAD")
+	expectEq(t, args, []string{"req", "-method", "HEAD", "url"})
+
+	err := cmd.ParseRun([]string{"req", "-x"})
+	expectErrorIs(t, err, ErrCmd)
+	expectEq(t, err.Error(), "command error: command parse error: -x was removed")
+}
+
+func TestGraft(t *testing.T) {
+	shared := flag.NewFlagSet("shared", flag.ContinueOnError)
+	cmd := &Command{
+		Name:     "test",
+		Commands: []*Command{{Name: "sub", Aliases: []string{"s"}, Flags: shared}},
+	}
+
+	expectError(t, cmd.Graft(&Command{Name: "s"}))
+	expectError(t, cmd.Graft(&Command{Name: "a"}, &Command{Name: "b", Aliases: []string{"a"}}))
+	expectError(t, cmd.Graft(&Command{Name: "a", Commands: []*Command{{Name: "b", Flags: shared}}}))
+	expectError(t, cmd.Graft(&Command{Name: "a", Flags: flag.CommandLine}))
+	expectError(t, cmd.Graft(&Command{Name: "a"}, &Command{Name: "b", Flags: shared}))
+	expectEq(t, len(cmd.Commands), 1)
+
+	plugin := &Command{Name: "plugin", Runner: nopRunner, Flags: flag.NewFlagSet("plugin", flag.ContinueOnError)}
+	expectErrorNone(t, cmd.Graft(plugin))
+	expectEq(t, cmd.Find("plugin"), plugin)
+	expectEq(t, plugin.Flags.Usage != nil, true)
+	expectErrorNone(t, cmd.ParseRun([]string{"plugin"}))
+}
+
+func TestReset(t *testing.T) {
+	var verbose bool
+	var name string
+	fset := flag.NewFlagSet("sub", flag.ContinueOnError)
+	fset.StringVar(&name, "n", "default", "")
+	cmd := &Command{
+		Name: "test",
+		Flags: func() *flag.FlagSet {
+			fset := flag.NewFlagSet("test", flag.ContinueOnError)
+			fset.BoolVar(&verbose, "v", false, "")
+			return fset
+		}(),
+		Commands: []*Command{
+			{Name: "sub", Flags: fset, Runner: nopRunner},
+		},
+	}
+	cmd.Meta("v").Required = true
+
+	expectErrorNone(t, cmd.ParseRun([]string{"-v", "sub", "-n", "x"}))
+	expectTrue(t, verbose)
+	expectEq(t, name, "x")
+
+	cmd.Reset()
+	expectFalse(t, verbose)
+	expectEq(t, name, "default")
+	expectEq(t, cmd.Commands[0].Parent(), (*Command)(nil))
+	expectTrue(t, cmd.Commands[0].Flags == fset)
+	expectFalse(t, fset.Parsed())
+	expectEq(t, fset.Lookup("n").DefValue, "default")
+
+	// The required flag isn't set anymore.
+	expectErrorIs(t, cmd.ParseRun([]string{"sub"}), ErrFlagRequired)
+	cmd.Reset()
+	expectErrorNone(t, cmd.ParseRun([]string{"-v", "sub"}))
+	expectEq(t, name, "default")
+}
+
+func TestChain(t *testing.T) {
+	var ran []string
+	errFailed := errors.New("failed")
+	runner := func(cmd *Command, args []string) error {
+		ran = append(ran, strings.Join(append([]string{cmd.Name}, args...), " "))
+		if cmd.Name == "fail" {
+			return errFailed
+		}
+		return nil
+	}
+	cmd := &Command{
+		Name:           "test",
+		ChainSeparator: ";",
+		Commands: []*Command{
+			{Name: "fmt", Runner: runner},
+			{Name: "vet", Runner: runner},
+			{Name: "fail", Runner: runner},
+		},
+	}
+
+	expectErrorNone(t, cmd.ParseRun([]string{"fmt", "a", ";", "vet"}))
+	expectEq(t, ran, []string{"fmt a", "vet"})
+
+	ran = nil
+	expectErrorIs(t, cmd.ParseRun([]string{"fmt", ";", "fail", ";", "vet"}), errFailed)
+	expectEq(t, ran, []string{"fmt", "fail"})
+
+	ran = nil
+	expectErrorIs(t, cmd.ParseRun([]string{"fmt", ";", ";", "vet"}), ErrMissingCommand)
+	expectEq(t, ran, []string{"fmt"})
+
+	expectEq(t, splitChain(nil, ";"), [][]string{nil})
+}
+
+func TestMiddleware(t *testing.T) {
+	var calls []string
+	mw := func(name string) Middleware {
+		return func(next RunnerFunc) RunnerFunc {
+			return func(cmd *Command, args []string) error {
+				calls = append(calls, name)
+				return next(cmd, args)
+			}
+		}
+	}
+	errDenied := errors.New("denied")
+	cmd := &Command{
+		Name: "test",
+		Commands: []*Command{
+			{
+				Name: "sub",
+				Commands: []*Command{
+					{Name: "leaf", Runner: func(cmd *Command, args []string) error {
+						calls = append(calls, "runner "+strings.Join(args, " "))
+						return nil
+					}},
+				},
+			},
+		},
+	}
+	cmd.Use(mw("root 1"), mw("root 2"))
+	cmd.Commands[0].Commands[0].Use(mw("leaf"))
+	cmd.Commands[0].Use(mw("sub"))
+
+	expectErrorNone(t, cmd.ParseRun([]string{"sub", "leaf", "a"}))
+	expectEq(t, calls, []string{"root 1", "root 2", "sub", "leaf", "runner a"})
+
+	calls = nil
+	cmd.Commands[0].Use(func(next RunnerFunc) RunnerFunc {
+		return func(cmd *Command, args []string) error {
+			return errDenied
+		}
+	})
+	expectErrorIs(t, cmd.ParseRun([]string{"sub", "leaf"}), errDenied)
+	expectEq(t, calls, []string{"root 1", "root 2", "sub"})
+}
+
 func nopRunner(*Command, []string) error {
 	return nil
 }
@@ -344,3 +570,144 @@ func refFlagSet(flags any, fsets ...*flag.FlagSet) *flag.FlagSet {
 
 	return fset
 }
+
+func TestFlagsFunc(t *testing.T) {
+	var calls []string
+	var name string
+	flagsFunc := func(cmdName string) func() *flag.FlagSet {
+		return func() *flag.FlagSet {
+			calls = append(calls, cmdName)
+			fset := flag.NewFlagSet(cmdName, flag.ContinueOnError)
+			fset.StringVar(&name, "name", "", "")
+			return fset
+		}
+	}
+	cmd := &Command{
+		Name: "test",
+		Commands: []*Command{
+			{Name: "a", FlagsFunc: flagsFunc("a"), Runner: nopRunner},
+			{Name: "b", FlagsFunc: flagsFunc("b"), Runner: nopRunner},
+		},
+	}
+
+	expectErrorNone(t, cmd.ParseRun([]string{"a", "-name", "x"}))
+	expectEq(t, name, "x")
+	expectEq(t, calls, []string{"a"})
+	expectTrue(t, cmd.Commands[0].Flags.Usage != nil)
+
+	// The FlagSet is only made once.
+	expectErrorNone(t, cmd.ParseRun([]string{"a"}))
+	expectEq(t, calls, []string{"a"})
+
+	expectEq(t, cmd.Commands[1].Synopsis(), "b [-name string]")
+	expectEq(t, calls, []string{"a", "b"})
+}
+
+func TestLoadFunc(t *testing.T) {
+	var calls []string
+	var name string
+	loadFunc := func(cmdName string, err error) func() (*Command, error) {
+		return func() (*Command, error) {
+			calls = append(calls, cmdName)
+			if err != nil {
+				return nil, err
+			}
+			fset := flag.NewFlagSet(cmdName, flag.ContinueOnError)
+			fset.StringVar(&name, "name", "", "")
+			return &Command{Name: "loaded", ShortDesc: "loaded", Flags: fset, Runner: nopRunner}, nil
+		}
+	}
+	errLoad := errors.New("load failed")
+	cmd := &Command{
+		Name:  "test",
+		Flags: flag.NewFlagSet("test", flag.ContinueOnError),
+		Commands: []*Command{
+			{Name: "a", ShortDesc: "a", LoadFunc: loadFunc("a", nil)},
+			{Name: "b", ShortDesc: "b", LoadFunc: loadFunc("b", errLoad)},
+		},
+	}
+
+	// Listing the commands doesn't load them.
+	cmd.UsageString()
+	expectEq(t, len(calls), 0)
+
+	expectErrorNone(t, cmd.ParseRun([]string{"a", "-name", "x"}))
+	expectEq(t, name, "x")
+	expectEq(t, calls, []string{"a"})
+	expectEq(t, cmd.Commands[0].ShortDesc, "a")
+	r, err := cmd.ParseArgs([]string{"a"})
+	expectErrorNone(t, err)
+	expectEq(t, r.Command.Name, "a")
+	expectEq(t, r.Command.ShortDesc, "loaded")
+	expectEq(t, r.Command.Synopsis(), "test a [-name string]")
+
+	// It's only loaded once.
+	expectErrorNone(t, cmd.ParseRun([]string{"a"}))
+	expectEq(t, calls, []string{"a"})
+
+	for i := 0; i < 2; i++ {
+		err := cmd.ParseRun([]string{"b"})
+		expectErrorIs(t, err, errLoad)
+		expectErrorIs(t, err, ErrCmd)
+	}
+	expectEq(t, calls, []string{"a", "b"})
+}
+
+func TestDefault(t *testing.T) {
+	args, commandLine := os.Args, flag.CommandLine
+	t.Cleanup(func() {
+		os.Args, flag.CommandLine = args, commandLine
+		Default.ErrorHandling = ExitOnError
+	})
+
+	// Replaced before the first use, as tests of programs do.
+	os.Args = []string{"/bin/tool", "-x", "value"}
+	flag.CommandLine = flag.NewFlagSet("tool", flag.ContinueOnError)
+	x := flag.String("x", "", "")
+
+	Default.ErrorHandling = ReturnOnError
+	leafCmd, rest, err := Parse()
+	expectErrorNone(t, err)
+	expectTrue(t, leafCmd == Default)
+	expectEq(t, len(rest), 0)
+	expectEq(t, *x, "value")
+	expectEq(t, Default.Name, "tool")
+	expectTrue(t, Flags() == flag.CommandLine)
+	expectEq(t, Default.Synopsis(), "tool [-x string]")
+}
+
+func TestRegister(t *testing.T) {
+	cmd := &Command{Name: "test"}
+	var got string
+	var name *string
+	sub := cmd.Register("greet", "greet someone", func(cmd *Command, args []string) error {
+		got = *name
+		return nil
+	}, func(fset *flag.FlagSet) {
+		name = fset.String("name", "world", "who to greet")
+	})
+	cmd.Register("nop", "do nothing", nopRunner, nil)
+
+	expectEq(t, len(cmd.Commands), 2)
+	expectTrue(t, cmd.Find("greet") == sub)
+	expectEq(t, sub.ShortDesc, "greet someone")
+	expectErrorNone(t, cmd.ParseRun([]string{"greet", "-name", "you"}))
+	expectEq(t, got, "you")
+	expectErrorNone(t, cmd.ParseRun([]string{"nop"}))
+}
+
+func TestAddAt(t *testing.T) {
+	cmd := &Command{Name: "test"}
+	cmd.AddAt("db migrate", &Command{Name: "up", Runner: nopRunner})
+	cmd.AddAt("db  migrate", &Command{Name: "down", Runner: nopRunner})
+	cmd.AddAt("db", &Command{Name: "dump", Runner: nopRunner})
+	cmd.AddAt("", &Command{Name: "version", Runner: nopRunner})
+
+	expectEq(t, len(cmd.Commands), 2)
+	db := cmd.Find("db")
+	expectEq(t, len(db.Commands), 2)
+	expectEq(t, len(db.Find("migrate").Commands), 2)
+	expectErrorNone(t, cmd.ParseRun([]string{"db", "migrate", "down"}))
+	expectErrorNone(t, cmd.ParseRun([]string{"db", "dump"}))
+	expectErrorNone(t, cmd.ParseRun([]string{"version"}))
+}
@@ -0,0 +1,134 @@
+// Package cmdtest contains helpers for testing programs that use package
+// cmds.
+package cmdtest
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rgzlv/cmds"
+)
+
+// Execute runs [cmds.Command.ParseRun] with args on cmd and returns what was
+// output to the Stdout and Stderr streams of cmd and the error.
+//
+// The Runners run on cmd itself, so that the variables that the flags were
+// defined with are set like when the program runs.
+// The tree is [cmds.Command.Reset] before and after, so that invocations
+// don't affect each other, which means that invocations on the same tree
+// can't run in parallel.
+// While it runs Stdin of cmd is empty, the FlagSets of the tree are
+// ContinueOnError and the ErrorHandling of cmd is ReturnOnError, so that
+// errors don't exit the test, they're restored afterwards.
+// The errors are output to Stderr unless cmd has it's own writer for them
+// set with [cmds.Command.SetErrOutput].
+func Execute(t testing.TB, cmd *cmds.Command, args ...string) (stdout, stderr string, err error) {
+	t.Helper()
+
+	var out, errOut strings.Builder
+	stdin, stdoutW, stderrW, errHandling := cmd.Stdin, cmd.Stdout, cmd.Stderr, cmd.ErrorHandling
+	cmd.Reset()
+	restore := continueOnError(cmd)
+	defer func() {
+		cmd.Reset()
+		restore()
+		cmd.Stdin, cmd.Stdout, cmd.Stderr, cmd.ErrorHandling = stdin, stdoutW, stderrW, errHandling
+	}()
+
+	cmd.Stdin = strings.NewReader("")
+	cmd.Stdout = &out
+	cmd.Stderr = &errOut
+	cmd.ErrorHandling = cmds.ReturnOnError
+	err = cmd.ParseRun(args)
+	return out.String(), errOut.String(), err
+}
+
+// continueOnError makes the FlagSets of cmd and it's sub-commands that aren't
+// ContinueOnError be ones that are with the same flags, in place so that
+// references to them stay valid, and returns a function that restores them
+// and removes the ones made for the commands without one.
+func continueOnError(cmd *cmds.Command) (restore func()) {
+	var restores []func()
+	var walk func(c *cmds.Command)
+	walk = func(c *cmds.Command) {
+		if c.Flags == nil {
+			restores = append(restores, func() { c.Flags = nil })
+		}
+		if fset := c.Flags; fset != nil && fset.ErrorHandling() != flag.ContinueOnError {
+			saved := *fset
+			clone := flag.NewFlagSet(fset.Name(), flag.ContinueOnError)
+			clone.SetOutput(fset.Output())
+			clone.Usage = fset.Usage
+			fset.VisitAll(func(f *flag.Flag) {
+				clone.Var(f.Value, f.Name, f.Usage)
+				clone.Lookup(f.Name).DefValue = f.DefValue
+			})
+			*fset = *clone
+			restores = append(restores, func() { *fset = saved })
+		}
+		for _, sub := range c.Commands {
+			walk(sub)
+		}
+	}
+	walk(cmd)
+	// The FlagSets made while parsing for the commands without one have the
+	// ErrorHandling of the one of cmd.
+	if cmd.Flags == nil {
+		cmd.Flags = flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+		cmd.Flags.Usage = cmd.DefaultUsage()
+	}
+	return func() {
+		for _, restore := range restores {
+			restore()
+		}
+	}
+}
+
+// update is the flag of the test binary that makes AssertUsage write the
+// golden files, like "go test -update".
+var update = flag.Bool("update", false, "update the golden files of cmdtest.AssertUsage")
+
+// AssertUsage fails t if the [cmds.Command.UsageString] of cmd isn't the same
+// as the content of the golden file, so that changes to the usage message of
+// a program are noticed and looked at in review.
+// When the test binary is run with the -update flag the file is written
+// instead, with the directories it's in, which makes -update a flag that the
+// tests of a package that imports cmdtest can't define themselves.
+func AssertUsage(t testing.TB, cmd *cmds.Command, golden string) {
+	t.Helper()
+
+	got := cmd.UsageString()
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	b, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v, run the test with -update to write it", err)
+	}
+	if want := string(b); got != want {
+		t.Errorf("usage of %s differs from %s at line %d, run the test with -update to update it\ngot:\n%s\nwant:\n%s",
+			cmd.Name, golden, diffLine(got, want), got, want)
+	}
+}
+
+// diffLine returns the number of the first line from 1 that differs between
+// a and b.
+func diffLine(a, b string) int {
+	al, bl := strings.Split(a, "\n"), strings.Split(b, "\n")
+	for i := range al {
+		if i >= len(bl) || al[i] != bl[i] {
+			return i + 1
+		}
+	}
+	return len(al) + 1
+}
@@ -0,0 +1,135 @@
+package cmdtest
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/rgzlv/cmds"
+)
+
+func testCmd() (*cmds.Command, *bool) {
+	var upper bool
+	fset := flag.NewFlagSet("echo", flag.ExitOnError)
+	fset.BoolVar(&upper, "u", false, "")
+
+	return &cmds.Command{
+		Name:          "tool",
+		ErrorHandling: cmds.ExitOnError,
+		Commands: []*cmds.Command{
+			{
+				Name:  "echo",
+				Flags: fset,
+				Runner: func(cmd *cmds.Command, args []string) error {
+					s := strings.Join(args, " ")
+					if upper {
+						s = strings.ToUpper(s)
+					}
+					fmt.Fprintln(cmd.Output(), s)
+					return nil
+				},
+			},
+			{
+				Name: "fail",
+				Runner: func(cmd *cmds.Command, args []string) error {
+					fmt.Fprintln(cmd.ErrOutput(), "failing")
+					return errors.New("failed")
+				},
+			},
+		},
+	}, &upper
+}
+
+func TestExecute(t *testing.T) {
+	cmd, upper := testCmd()
+
+	stdout, stderr, err := Execute(t, cmd, "echo", "-u", "a", "b")
+	expectEq(t, []any{stdout, stderr, err}, []any{"A B\n", "", nil})
+
+	// The flags are reset afterwards.
+	expectEq(t, *upper, false)
+	stdout, _, err = Execute(t, cmd, "echo", "a")
+	expectEq(t, []any{stdout, err}, []any{"a\n", nil})
+
+	_, stderr, err = Execute(t, cmd, "fail")
+	expectEq(t, []any{stderr, err.Error()}, []any{"failing\n", "failed"})
+
+	// Errors are returned instead of exiting.
+	_, stderr, err = Execute(t, cmd, "echo", "-x")
+	expectEq(t, errors.Is(err, cmds.ErrFlag), true)
+	expectEq(t, strings.HasPrefix(stderr, "flag provided but not defined: -x\nUsage of echo:"), true)
+
+	_, _, err = Execute(t, cmd, "fail", "-x")
+	expectEq(t, errors.Is(err, cmds.ErrFlag), true)
+
+	// The tree is restored.
+	expectEq(t, cmd.Commands[0].Flags.Output(), io.Writer(os.Stderr))
+	expectEq(t, cmd.Commands[0].Flags.ErrorHandling(), flag.ExitOnError)
+	expectEq(t, cmd.ErrorHandling, cmds.ExitOnError)
+	expectEq(t, cmd.Flags, (*flag.FlagSet)(nil))
+	expectEq(t, cmd.Commands[1].Flags, (*flag.FlagSet)(nil))
+	expectEq(t, cmd.Stdout, io.Writer(nil))
+}
+
+// recorder records the failures of a test instead of failing it.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+// record returns the failures of fn, which is run in it's own goroutine so
+// that Fatalf can stop it.
+func record(t *testing.T, fn func(t testing.TB)) []string {
+	r := &recorder{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(r)
+	}()
+	<-done
+	return r.failures
+}
+
+func TestAssertUsage(t *testing.T) {
+	cmd, _ := testCmd()
+	golden := filepath.Join(t.TempDir(), "testdata", "tool_usage.golden")
+
+	failures := record(t, func(t testing.TB) { AssertUsage(t, cmd, golden) })
+	expectEq(t, len(failures), 1)
+	expectEq(t, strings.Contains(failures[0], "run the test with -update"), true)
+
+	*update = true
+	AssertUsage(t, cmd, golden)
+	*update = false
+	b, err := os.ReadFile(golden)
+	expectEq(t, []any{string(b), err}, []any{cmd.UsageString(), nil})
+	AssertUsage(t, cmd, golden)
+
+	cmd.Commands[0].ShortDesc = "echo the arguments"
+	failures = record(t, func(t testing.TB) { AssertUsage(t, cmd, golden) })
+	expectEq(t, len(failures), 1)
+	expectEq(t, strings.Contains(failures[0], "at line 4"), true)
+}
+
+func expectEq(t *testing.T, a, b any) {
+	t.Helper()
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("expected equal values, got %#+v != %#+v", a, b)
+	}
+}
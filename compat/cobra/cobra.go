@@ -0,0 +1,89 @@
+// Package cobra converts between [upstream.Command] trees of
+// github.com/spf13/cobra and [cmds.Command] trees, so that programs can be
+// migrated one command at a time or embed commands of one in the other.
+//
+// The commands keep being parsed by the package that they were written for,
+// the converted commands only pass their arguments on to it.
+package cobra
+
+import (
+	"strings"
+
+	"github.com/rgzlv/cmds"
+	upstream "github.com/spf13/cobra"
+)
+
+// FromCobra returns a command tree with the same names, descriptions and
+// structure as c and it's sub-commands, whose leaf commands run the cobra
+// commands, with their arguments and flags parsed by cobra.
+// A cobra command that is both runnable and has sub-commands is converted to
+// a leaf command, so that cobra runs the sub-commands.
+// The commands are run by executing the root of c with the path of the
+// command and the arguments, with the context and streams of the command.
+func FromCobra(c *upstream.Command) *cmds.Command {
+	cmd := &cmds.Command{
+		Name:       c.Name(),
+		Aliases:    c.Aliases,
+		ShortDesc:  c.Short,
+		LongDesc:   c.Long,
+		ArgsUsage:  argsUsage(c.Use),
+		Hidden:     c.Hidden,
+		Deprecated: c.Deprecated,
+	}
+
+	if !c.Runnable() && c.HasSubCommands() {
+		for _, sub := range c.Commands() {
+			cmd.Commands = append(cmd.Commands, FromCobra(sub))
+		}
+		return cmd
+	}
+
+	// The arguments are passed as they are since cobra parses the flags.
+	cmd.TransformArgs = func(args []string) ([]string, error) {
+		return append([]string{"--"}, args...), nil
+	}
+	cmd.Runner = func(cmd *cmds.Command, args []string) error {
+		root := c.Root()
+		path := strings.Fields(c.CommandPath())[1:]
+		root.SetArgs(append(path, args...))
+		root.SetIn(cmd.Input())
+		root.SetOut(cmd.Output())
+		root.SetErr(cmd.ErrOutput())
+		return root.ExecuteContext(cmd.Context())
+	}
+	return cmd
+}
+
+// ToCobra returns a cobra command with the name and descriptions of cmd that
+// parses and runs it's arguments with cmd, with the context and streams of
+// the cobra command, which are set as the Stdin, Stdout and Stderr of cmd.
+// Cobra's flag parsing is disabled for it, cmd does that.
+func ToCobra(cmd *cmds.Command) *upstream.Command {
+	use := cmd.Name
+	if cmd.ArgsUsage != "" {
+		use += " " + cmd.ArgsUsage
+	}
+
+	return &upstream.Command{
+		Use:                use,
+		Aliases:            cmd.Aliases,
+		Short:              cmd.ShortDesc,
+		Long:               cmd.LongDesc,
+		Hidden:             cmd.Hidden,
+		Deprecated:         cmd.Deprecated,
+		DisableFlagParsing: true,
+		SilenceUsage:       true,
+		RunE: func(c *upstream.Command, args []string) error {
+			cmd.Stdin = c.InOrStdin()
+			cmd.Stdout = c.OutOrStdout()
+			cmd.Stderr = c.ErrOrStderr()
+			return cmd.ParseRunContext(c.Context(), args)
+		},
+	}
+}
+
+// argsUsage returns the part of the Use of a cobra command after it's name.
+func argsUsage(use string) string {
+	_, args, _ := strings.Cut(strings.TrimSpace(use), " ")
+	return strings.TrimSpace(args)
+}
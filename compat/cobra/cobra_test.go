@@ -0,0 +1,99 @@
+package cobra
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rgzlv/cmds"
+	upstream "github.com/spf13/cobra"
+)
+
+func TestFromCobra(t *testing.T) {
+	var ctxValue any
+	type key struct{}
+	root := &upstream.Command{Use: "tool"}
+	root.PersistentFlags().BoolP("verbose", "v", false, "")
+	remote := &upstream.Command{Use: "remote", Short: "manage remotes", Aliases: []string{"r"}}
+	add := &upstream.Command{
+		Use:   "add <name> <url>",
+		Short: "add a remote",
+		Args:  upstream.ExactArgs(2),
+		RunE: func(c *upstream.Command, args []string) error {
+			verbose, _ := c.Flags().GetBool("verbose")
+			fetch, _ := c.Flags().GetBool("fetch")
+			ctxValue = c.Context().Value(key{})
+			fmt.Fprintf(c.OutOrStdout(), "%v %v %s\n", verbose, fetch, strings.Join(args, " "))
+			return nil
+		},
+	}
+	add.Flags().Bool("fetch", false, "")
+	remote.AddCommand(add)
+	root.AddCommand(remote)
+
+	var out bytes.Buffer
+	cmd := FromCobra(remote)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if cmd.Name != "remote" || cmd.ShortDesc != "manage remotes" || len(cmd.Commands) != 1 {
+		t.Fatalf("unexpected command %+v", cmd)
+	}
+	if sub := cmd.Commands[0]; sub.Name != "add" || sub.ArgsUsage != "<name> <url>" || sub.ShortDesc != "add a remote" {
+		t.Fatalf("unexpected command %+v", sub)
+	}
+
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	if err := cmd.ParseRunContext(ctx, []string{"add", "--fetch", "-v", "origin", "url"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "true true origin url\n" {
+		t.Errorf("unexpected output %q", got)
+	}
+	if ctxValue != "value" {
+		t.Errorf("expected the context to be passed, got %v", ctxValue)
+	}
+
+	out.Reset()
+	cmd.Commands[0].Reset()
+	if err := cmd.ParseRun([]string{"add", "origin"}); err == nil {
+		t.Error("expected error from cobra for missing arguments")
+	}
+}
+
+func TestToCobra(t *testing.T) {
+	var name string
+	cmd := &cmds.Command{
+		Name:      "greet",
+		ShortDesc: "greet someone",
+		ArgsUsage: "[text...]",
+		Flags: func() *flag.FlagSet {
+			fset := flag.NewFlagSet("greet", flag.ContinueOnError)
+			fset.StringVar(&name, "name", "", "")
+			return fset
+		}(),
+		Runner: func(cmd *cmds.Command, args []string) error {
+			fmt.Fprintf(cmd.Output(), "hello %s %s\n", name, strings.Join(args, " "))
+			return nil
+		},
+	}
+
+	root := &upstream.Command{Use: "tool"}
+	c := ToCobra(cmd)
+	root.AddCommand(c)
+	if c.Use != "greet [text...]" || c.Short != "greet someone" {
+		t.Fatalf("unexpected command %+v", c)
+	}
+
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetArgs([]string{"greet", "-name", "x", "a", "b"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "hello x a b\n" {
+		t.Errorf("unexpected output %q", got)
+	}
+}
@@ -0,0 +1,9 @@
+module github.com/rgzlv/cmds/compat/pflag
+
+go 1.21
+
+require github.com/rgzlv/cmds v0.0.0
+
+require github.com/spf13/pflag v1.0.9
+
+replace github.com/rgzlv/cmds => ../../
@@ -0,0 +1,66 @@
+// Package pflag makes the flags of a [cmds.Command] be parsed by a FlagSet of
+// github.com/spf13/pflag, for POSIX style flags like "--name" and "-abc" and
+// the flag.Values of programs that use pflag.
+package pflag
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/rgzlv/cmds"
+	upstream "github.com/spf13/pflag"
+)
+
+// Bind makes fset the [cmds.FlagParser] of cmd and adds it's flags to the
+// FlagSet of cmd, creating it if there's none, with the same Values so that
+// the usage message, completion, validation and config files work with them,
+// the shorthands are only known to fset.
+// The flags have to be defined in fset before Bind is called, the usage of
+// fset is replaced with the one of cmd.
+func Bind(cmd *cmds.Command, fset *upstream.FlagSet) {
+	if cmd.Flags == nil {
+		cmd.Flags = flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+		cmd.Flags.Usage = cmd.DefaultUsage()
+	}
+
+	fset.VisitAll(func(f *upstream.Flag) {
+		cmd.Flags.Var(f.Value, f.Name, f.Usage)
+		cmd.Flags.Lookup(f.Name).DefValue = f.DefValue
+	})
+	fset.Usage = func() {
+		if cmd.Flags.Usage != nil {
+			cmd.Flags.Usage()
+		}
+	}
+	cmd.FlagParser = parser{cmd: cmd, fset: fset}
+}
+
+type parser struct {
+	cmd  *cmds.Command
+	fset *upstream.FlagSet
+}
+
+func (p parser) Parse(args []string) error {
+	p.fset.SetOutput(p.cmd.Flags.Output())
+	err := p.fset.Parse(args)
+	if errors.Is(err, upstream.ErrHelp) {
+		return fmt.Errorf("%w", flag.ErrHelp)
+	}
+	return err
+}
+
+func (p parser) Args() []string {
+	return p.fset.Args()
+}
+
+func (p parser) Changed(name string) bool {
+	return p.fset.Changed(name)
+}
+
+// Reset marks the flags of fset as not changed, for [cmds.Command.Reset].
+func (p parser) Reset() {
+	p.fset.VisitAll(func(f *upstream.Flag) {
+		f.Changed = false
+	})
+}
@@ -0,0 +1,76 @@
+package pflag
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/rgzlv/cmds"
+	upstream "github.com/spf13/pflag"
+)
+
+func TestBind(t *testing.T) {
+	t.Setenv("TEST_NAME", "env")
+	t.Setenv("TEST_VERBOSE", "true")
+
+	var verbose, all bool
+	var name string
+	var tags []string
+	var got []string
+	testCmd := func() *cmds.Command {
+		cmd := &cmds.Command{
+			Name:      "test",
+			EnvPrefix: "test",
+			Runner: func(cmd *cmds.Command, args []string) error {
+				got = args
+				return nil
+			},
+		}
+		fset := upstream.NewFlagSet("test", upstream.ContinueOnError)
+		fset.BoolVarP(&verbose, "verbose", "v", false, "")
+		fset.BoolVarP(&all, "all", "a", false, "")
+		fset.StringVarP(&name, "name", "n", "", "")
+		fset.StringSliceVar(&tags, "tag", nil, "")
+		Bind(cmd, fset)
+		cmd.Flags.SetOutput(io.Discard)
+		cmd.Meta("name").Required = true
+		return cmd
+	}
+
+	cmd := testCmd()
+	if err := cmd.ParseRun([]string{"-va", "--name=x", "--tag", "a,b", "arg", "--tag", "c"}); err != nil {
+		t.Fatal(err)
+	}
+	if !verbose || !all || name != "x" || !reflect.DeepEqual(tags, []string{"a", "b", "c"}) || !reflect.DeepEqual(got, []string{"arg"}) {
+		t.Errorf("unexpected values %v %v %s %v %v", verbose, all, name, tags, got)
+	}
+	if cmd.ValueSource("name") != cmds.SourceCommandLine {
+		t.Errorf("expected -name from the command line, got %v", cmd.ValueSource("name"))
+	}
+
+	// After Reset the flag isn't from the command line anymore.
+	cmd.Reset()
+	if err := cmd.ParseRun(nil); err != nil {
+		t.Fatal(err)
+	}
+	if name != "env" || cmd.ValueSource("name") != cmds.SourceEnv {
+		t.Errorf("expected -name from the environment after Reset, got %s from %v", name, cmd.ValueSource("name"))
+	}
+
+	verbose = false
+	cmd = testCmd()
+	if err := cmd.ParseRun(nil); err != nil {
+		t.Fatal(err)
+	}
+	if name != "env" || !verbose || cmd.ValueSource("name") != cmds.SourceEnv {
+		t.Errorf("expected -name from the environment, got %s from %v", name, cmd.ValueSource("name"))
+	}
+
+	if err := testCmd().ParseRun([]string{"--help"}); !errors.Is(err, cmds.ErrHelp) {
+		t.Errorf("expected ErrHelp, got %v", err)
+	}
+	if err := testCmd().ParseRun([]string{"--invalid"}); !errors.Is(err, cmds.ErrFlag) {
+		t.Errorf("expected ErrFlag, got %v", err)
+	}
+}
@@ -0,0 +1,552 @@
+package cmds
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Directive tells the shell completion scripts how to treat the completions
+// returned by [Command.Complete], it's a bit set.
+type Directive int
+
+const (
+	// CompleteDefault completes just the returned completions and adds a
+	// space after them.
+	CompleteDefault Directive = 0
+
+	// CompleteNoSpace doesn't add a space after the completion, like when
+	// completing the start of a URL.
+	CompleteNoSpace Directive = 1 << (iota - 1)
+
+	// CompleteFiles also completes file names.
+	CompleteFiles
+
+	// CompleteDirs also completes directory names.
+	CompleteDirs
+)
+
+// CompleteFunc returns the completions for the positional argument toComplete
+// when args are the positional arguments before it, as well as a [Directive]
+// for the completions.
+// The returned completions should start with toComplete.
+type CompleteFunc func(args []string, toComplete string) ([]string, Directive)
+
+// completeName is the name of the hidden command that the dynamic completion
+// scripts run to get the completions.
+const completeName = "__complete"
+
+// GenBashCompletion writes a bash completion script for cmd and it's
+// sub-commands to w.
+//
+// If the tree of cmd contains the command returned by [CompletionCommand], the
+// script runs it's hidden "__complete" sub-command to get the completions from
+// [Command.Complete] every time, so they stay correct as the tree changes and
+// can be dynamic.
+// Otherwise the script statically completes the names of sub-commands and the
+// flags of the command that the words before the cursor match, as they are
+// when the script is generated.
+func (cmd *Command) GenBashCompletion(w io.Writer) error {
+	fn := "_" + shellIdent(cmd.Name) + "_completion"
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# bash completion for %s\n\n", cmd.Name)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	if path := cmd.completePath(); path != nil {
+		b.WriteString("\tlocal IFS=$'\\n' cur out directive\n")
+		b.WriteString("\tcur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+		b.WriteString("\tout=($(\"${COMP_WORDS[0]}\"")
+		for _, name := range path {
+			b.WriteString(" " + shellQuote(name))
+		}
+		b.WriteString(" -- \"${COMP_WORDS[@]:1:COMP_CWORD}\" 2>/dev/null))\n")
+		b.WriteString("\t((${#out[@]} > 0)) || return\n")
+		b.WriteString("\tdirective=\"${out[${#out[@]}-1]#:}\"\n")
+		b.WriteString("\tunset 'out[${#out[@]}-1]'\n")
+		b.WriteString("\tCOMPREPLY=(\"${out[@]%%$'\\t'*}\")\n")
+		fmt.Fprintf(&b, "\tif ((directive & %d)); then\n", CompleteFiles)
+		b.WriteString("\t\tcompopt -o filenames\n")
+		b.WriteString("\t\tCOMPREPLY+=($(compgen -f -- \"$cur\"))\n")
+		fmt.Fprintf(&b, "\telif ((directive & %d)); then\n", CompleteDirs)
+		b.WriteString("\t\tcompopt -o filenames\n")
+		b.WriteString("\t\tCOMPREPLY+=($(compgen -d -- \"$cur\"))\n")
+		b.WriteString("\tfi\n")
+		fmt.Fprintf(&b, "\tif ((directive & %d)); then\n", CompleteNoSpace)
+		b.WriteString("\t\tcompopt -o nospace\n")
+		b.WriteString("\tfi\n")
+	} else {
+		cmd.genBashStatic(&b)
+	}
+	b.WriteString("}\n\n")
+	fmt.Fprintf(&b, "complete -F %s %s\n", fn, shellQuote(cmd.Name))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (cmd *Command) genBashStatic(b *strings.Builder) {
+	b.WriteString("\tlocal cur path i\n")
+	b.WriteString("\tcur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	fmt.Fprintf(b, "\tpath=%s\n", shellQuote(cmd.Name))
+	b.WriteString("\tfor ((i = 1; i < COMP_CWORD; i++)); do\n")
+	b.WriteString("\t\tcase \"$path ${COMP_WORDS[i]}\" in\n")
+	var v treeView
+	cmd.walk(cmd.Name, func(path string, c *Command) {
+		for _, sub := range v.commands(c) {
+			var patterns []string
+			for _, name := range append([]string{sub.Name}, sub.Aliases...) {
+				patterns = append(patterns, shellQuote(path+" "+name))
+			}
+			fmt.Fprintf(b, "\t\t%s) path=%s ;;\n", strings.Join(patterns, "|"), shellQuote(path+" "+sub.Name))
+		}
+	})
+	b.WriteString("\t\tesac\n")
+	b.WriteString("\tdone\n")
+	b.WriteString("\tcase \"$path\" in\n")
+	cmd.walk(cmd.Name, func(path string, c *Command) {
+		var words []string
+		for _, sub := range v.commands(c) {
+			words = append(words, sub.Name)
+		}
+		for _, f := range v.flags(c) {
+			words = append(words, "-"+f.Name)
+		}
+		if len(words) == 0 {
+			return
+		}
+		fmt.Fprintf(b, "\t%s) COMPREPLY=($(compgen -W %s -- \"$cur\")) ;;\n", shellQuote(path), shellQuote(strings.Join(words, " ")))
+	})
+	b.WriteString("\tesac\n")
+}
+
+// completePath returns the names of the commands after cmd up to and
+// including the hidden "__complete" command of the command returned by
+// [CompletionCommand], or nil if cmd's tree doesn't contain it.
+func (cmd *Command) completePath() []string {
+	var path []string
+	cmd.walk(cmd.Name, func(p string, c *Command) {
+		if path == nil && c.Name == completeName && c.Hidden {
+			path = strings.Fields(p)[1:]
+		}
+	})
+	return path
+}
+
+// Complete returns the completions for the last of args, which are the
+// arguments that would be passed to [Command.Parse] if the user ran the command
+// with the word currently being completed as the last argument.
+// The completions are the sub-commands or flags of the command that the other
+// arguments match, the completions from the [FlagMeta] of a flag for it's
+// value, or the completions returned by it's CompleteArgs for positional
+// arguments.
+// Hidden commands and flags aren't completed and neither are deprecated ones
+// unless cmd has AnnotateDeprecated set, then they are followed by a tab and
+// the description "deprecated: " and their Deprecated message.
+func (cmd *Command) Complete(args []string) ([]string, Directive) {
+	v := treeView{deprecated: cmd.AnnotateDeprecated}
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	words, toComplete := args[:len(args)-1], args[len(args)-1]
+
+	c := cmd
+	var positional []string
+	var onlyArgs bool
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		if !onlyArgs && word == "--" {
+			onlyArgs = true
+			continue
+		}
+		if !onlyArgs && len(positional) == 0 && len(word) > 1 && word[0] == '-' {
+			if f := c.lookupFlag(word); f != nil && !isBoolFlag(f) && !strings.Contains(word, "=") {
+				if i == len(words)-1 {
+					return c.completeFlagValue(f, positional, "", toComplete)
+				}
+				// Skip the flag's value.
+				i++
+			}
+			continue
+		}
+		if len(c.Commands) > 0 && len(positional) == 0 {
+			sub := c.Find(word)
+			if sub == nil {
+				return nil, CompleteDefault
+			}
+			c, _ = sub.loaded()
+			onlyArgs = false
+			continue
+		}
+		positional = append(positional, word)
+	}
+
+	var candidates, descs []string
+	if !onlyArgs && len(positional) == 0 && strings.HasPrefix(toComplete, "-") && strings.Contains(toComplete, "=") {
+		if f := c.lookupFlag(toComplete); f != nil {
+			i := strings.IndexByte(toComplete, '=')
+			return c.completeFlagValue(f, positional, toComplete[:i+1], toComplete[i+1:])
+		}
+		return nil, CompleteDefault
+	} else if !onlyArgs && len(positional) == 0 && strings.HasPrefix(toComplete, "-") {
+		prefix := "-"
+		if strings.HasPrefix(toComplete, "--") {
+			prefix = "--"
+		}
+		for _, f := range v.flags(c) {
+			candidates = append(candidates, prefix+f.Name)
+			descs = append(descs, deprecatedDesc(c.FlagMeta[f.Name].deprecated()))
+		}
+	} else if len(c.Commands) > 0 && len(positional) == 0 {
+		for _, sub := range v.commands(c) {
+			candidates = append(candidates, sub.Name)
+			descs = append(descs, deprecatedDesc(sub.Deprecated))
+		}
+	} else if c.CompleteArgs != nil {
+		return c.CompleteArgs(positional, toComplete)
+	}
+
+	var completions []string
+	for i, candidate := range candidates {
+		if strings.HasPrefix(candidate, toComplete) {
+			completions = append(completions, candidate+descs[i])
+		}
+	}
+	return completions, CompleteDefault
+}
+
+// deprecatedDesc returns the completion description for a command or flag
+// with the given Deprecated message.
+func deprecatedDesc(msg string) string {
+	if msg == "" {
+		return ""
+	}
+	return "\tdeprecated: " + msg
+}
+
+// completeFlagValue returns the completions for the value of f from it's
+// [FlagMeta], with prefix prepended to them.
+func (cmd *Command) completeFlagValue(f *flag.Flag, positional []string, prefix, toComplete string) ([]string, Directive) {
+	meta := cmd.FlagMeta[f.Name]
+	if meta == nil {
+		return nil, CompleteDefault
+	}
+
+	var completions []string
+	directive := CompleteDefault
+	for _, choice := range meta.Choices {
+		if strings.HasPrefix(choice, toComplete) {
+			completions = append(completions, choice)
+		}
+	}
+	if meta.Complete != nil {
+		var c []string
+		c, directive = meta.Complete(positional, toComplete)
+		completions = append(completions, c...)
+	}
+
+	if prefix != "" {
+		for i := range completions {
+			completions[i] = prefix + completions[i]
+		}
+	}
+	return completions, directive
+}
+
+// lookupFlag returns the flag of cmd that arg, like "-name" or "--name=value",
+// refers to or nil.
+func (cmd *Command) lookupFlag(arg string) *flag.Flag {
+	cmd.loadFlags()
+	if cmd.Flags == nil {
+		return nil
+	}
+	name := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+	if i := strings.IndexByte(name, '='); i >= 0 {
+		name = name[:i]
+	}
+	return cmd.Flags.Lookup(name)
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	bf, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && bf.IsBoolFlag()
+}
+
+// walk calls fn for cmd and all of it's sub-commands recursively, path is the
+// names of the commands up to and including the command passed to fn.
+func (cmd *Command) walk(path string, fn func(path string, cmd *Command)) {
+	cmd, _ = cmd.loaded()
+	cmd.nameCommands()
+	fn(path, cmd)
+	for _, sub := range cmd.Commands {
+		if sub.Name != "" {
+			sub.walk(path+" "+sub.Name, fn)
+		}
+	}
+}
+
+// errNoComplete is returned by the script generators that need the hidden
+// "__complete" command when the tree doesn't contain it.
+var errNoComplete = fmt.Errorf("%w: %w", ErrCmd, errors.New("missing completion command"))
+
+// GenZshCompletion writes a zsh completion script for cmd and it's
+// sub-commands to w.
+// The script can be put in a directory in $fpath as "_<name>" or sourced.
+// It gets the completions from the hidden "__complete" command of the command
+// returned by [CompletionCommand], which must be in the tree of cmd.
+func (cmd *Command) GenZshCompletion(w io.Writer) error {
+	path := cmd.completePath()
+	if path == nil {
+		return errNoComplete
+	}
+	fn := "_" + shellIdent(cmd.Name)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "#compdef %s\n\n", cmd.Name)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	b.WriteString("\tlocal -a out opts vals descs\n")
+	b.WriteString("\tlocal directive line\n")
+	b.WriteString("\tout=(\"${(@f)$(\"${words[1]}\"")
+	for _, name := range path {
+		b.WriteString(" " + shellQuote(name))
+	}
+	b.WriteString(" -- \"${(@)words[2,CURRENT]}\" 2>/dev/null)}\")\n")
+	b.WriteString("\t(( ${#out} > 0 )) || return 1\n")
+	b.WriteString("\tdirective=\"${out[-1]#:}\"\n")
+	b.WriteString("\tout=(\"${(@)out[1,-2]}\")\n")
+	fmt.Fprintf(&b, "\t(( directive & %d )) && opts=(-S '')\n", CompleteNoSpace)
+	fmt.Fprintf(&b, "\tif (( directive & %d )); then\n", CompleteFiles)
+	b.WriteString("\t\t_files\n")
+	fmt.Fprintf(&b, "\telif (( directive & %d )); then\n", CompleteDirs)
+	b.WriteString("\t\t_files -/\n")
+	b.WriteString("\tfi\n")
+	b.WriteString("\tfor line in \"${out[@]}\"; do\n")
+	b.WriteString("\t\tvals+=(\"${line%%$'\\t'*}\")\n")
+	b.WriteString("\t\tdescs+=(\"${line/$'\\t'/  -- }\")\n")
+	b.WriteString("\tdone\n")
+	b.WriteString("\tcompadd \"${opts[@]}\" -d descs -- \"${vals[@]}\"\n")
+	b.WriteString("}\n\n")
+	fmt.Fprintf(&b, "if [ \"$funcstack[1]\" = %s ]; then\n", shellQuote(fn))
+	fmt.Fprintf(&b, "\t%s \"$@\"\n", fn)
+	b.WriteString("else\n")
+	fmt.Fprintf(&b, "\tcompdef %s %s\n", fn, shellQuote(cmd.Name))
+	b.WriteString("fi\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// GenFishCompletion writes a fish completion script for cmd and it's
+// sub-commands to w.
+// It gets the completions from the hidden "__complete" command of the command
+// returned by [CompletionCommand], which must be in the tree of cmd.
+func (cmd *Command) GenFishCompletion(w io.Writer) error {
+	path := cmd.completePath()
+	if path == nil {
+		return errNoComplete
+	}
+	fn := "__" + shellIdent(cmd.Name) + "_complete"
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# fish completion for %s\n\n", cmd.Name)
+	fmt.Fprintf(&b, "function %s\n", fn)
+	b.WriteString("\tset -l words (commandline -opc) (commandline -ct)\n")
+	b.WriteString("\tset -l out ($words[1]")
+	for _, name := range path {
+		b.WriteString(" " + shellQuote(name))
+	}
+	b.WriteString(" -- $words[2..-1] 2>/dev/null)\n")
+	b.WriteString("\tset -q out[1]; or return\n")
+	b.WriteString("\tset -l directive (string replace ':' '' -- $out[-1])\n")
+	b.WriteString("\tset -e out[-1]\n")
+	b.WriteString("\tset -q out[1]; and printf '%s\\n' $out\n")
+	fmt.Fprintf(&b, "\tif test (math \"bitand($directive, %d)\") -ne 0\n", CompleteFiles)
+	b.WriteString("\t\t__fish_complete_path (commandline -ct)\n")
+	fmt.Fprintf(&b, "\telse if test (math \"bitand($directive, %d)\") -ne 0\n", CompleteDirs)
+	b.WriteString("\t\t__fish_complete_directories (commandline -ct)\n")
+	b.WriteString("\tend\n")
+	b.WriteString("end\n\n")
+	fmt.Fprintf(&b, "complete -c %s -f -a '(%s)'\n", shellQuote(cmd.Name), fn)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// completionShells are the shells that scripts can be generated for, by name.
+var completionShells = map[string]func(cmd *Command, w io.Writer) error{
+	"bash": (*Command).GenBashCompletion,
+	"zsh":  (*Command).GenZshCompletion,
+	"fish": (*Command).GenFishCompletion,
+}
+
+// completionInstallPath returns the path that the completion script for the
+// root command of cmd should be installed at for the shell, the errors are
+// about cmd.
+func completionInstallPath(cmd *Command, shell string) (string, error) {
+	name := cmd.root().Name
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	xdg := func(env, def string) string {
+		if dir := os.Getenv(env); filepath.IsAbs(dir) {
+			return dir
+		}
+		return filepath.Join(home, def)
+	}
+
+	switch shell {
+	case "bash":
+		return filepath.Join(xdg("XDG_DATA_HOME", ".local/share"), "bash-completion", "completions", name), nil
+	case "zsh":
+		return filepath.Join(home, ".zsh", "completions", "_"+name), nil
+	case "fish":
+		return filepath.Join(xdg("XDG_CONFIG_HOME", ".config"), "fish", "completions", name+".fish"), nil
+	}
+
+	return "", newCommandError(cmd, shell, ErrArgs, fmt.Sprintf(cmd.messages().UnsupportedShell, shell))
+}
+
+// installCompletion writes the completion script for the root command of cmd
+// to the location that the shell loads it from and returns the path it was
+// written to, the errors are about cmd.
+// If shell is empty, it's detected from $SHELL.
+func installCompletion(cmd *Command, shell string) (string, error) {
+	root := cmd.root()
+	if shell == "" {
+		shell = filepath.Base(os.Getenv("SHELL"))
+		if shell == "." || shell == string(filepath.Separator) {
+			return "", newCommandError(cmd, "", ErrArgs, cmd.messages().NoShell)
+		}
+	}
+
+	gen := completionShells[shell]
+	if gen == nil {
+		return "", newCommandError(cmd, shell, ErrArgs, fmt.Sprintf(cmd.messages().UnsupportedShell, shell))
+	}
+
+	path, err := completionInstallPath(cmd, shell)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := gen(root, &b); err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, []byte(b.String()), 0o644)
+}
+
+// CompletionCommand returns a "completion" command to add to the Commands of
+// the root command.
+// It has "bash", "zsh" and "fish" sub-commands that output the completion
+// script for the root command for that shell to standard output, an
+// "install" sub-command that writes the script to where the shell loads it
+// from, and a hidden "__complete" sub-command that the scripts run to output
+// the completions from [Command.Complete] for it's arguments, one per line,
+// followed by a line with a colon and the [Directive].
+// Completions can contain a tab followed by a description of the completion,
+// which is shown by the shells that support it.
+func CompletionCommand() *Command {
+	// The hints are in the usage footer since they have the name of the root
+	// command, which is only known once the command is in a tree.
+	gen := func(shell, hint string) *Command {
+		return &Command{
+			Name:      shell,
+			ShortDesc: "output the " + shell + " completion script",
+			LongDesc:  "Output the " + shell + " completion script.",
+			UsageFooter: func(cmd *Command) string {
+				if cmd.parent == nil {
+					return ""
+				}
+				return fmt.Sprintf("To load it, "+hint+".", cmd.root().Name)
+			},
+			Runner: func(cmd *Command, args []string) error {
+				root := cmd.root()
+				if root == cmd {
+					return fmt.Errorf("%w: %w", ErrCmd, fmt.Errorf(cmd.messages().NoParent, "completion"))
+				}
+				return completionShells[shell](root, cmd.Output())
+			},
+		}
+	}
+
+	return &Command{
+		Name:      "completion",
+		ShortDesc: "output shell completion scripts",
+		Commands: []*Command{
+			gen("bash", "add \"source <(%[1]s completion bash)\" to ~/.bashrc"),
+			gen("zsh", "save it as \"_%[1]s\" in a directory in $fpath"),
+			gen("fish", "save it as \"%[1]s.fish\" in ~/.config/fish/completions"),
+			{
+				Name:      "install",
+				ShortDesc: "install the completion script for the current shell",
+				LongDesc: "Install the completion script for the shell given as the argument, " +
+					"or for the shell in $SHELL, where the shell loads it from.",
+				ArgsUsage: "[shell]",
+				Args:      MaxArgs(1),
+				Runner: func(cmd *Command, args []string) error {
+					var shell string
+					if len(args) == 1 {
+						shell = args[0]
+					}
+
+					path, err := installCompletion(cmd, shell)
+					if err != nil {
+						return err
+					}
+					m := cmd.messages()
+					fmt.Fprintf(cmd.Output(), m.CompletionInstalled+"\n", path)
+					if filepath.Base(path)[0] == '_' {
+						fmt.Fprintf(cmd.Output(), m.CompletionFpath+"\n", filepath.Dir(path))
+					}
+					return nil
+				},
+			},
+			{
+				Name:   completeName,
+				Hidden: true,
+				Runner: func(cmd *Command, args []string) error {
+					completions, directive := cmd.root().Complete(args)
+					for _, c := range completions {
+						fmt.Fprintln(cmd.Output(), c)
+					}
+					fmt.Fprintf(cmd.Output(), ":%d\n", directive)
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// root returns the root command of cmd as matched during the last parse.
+func (cmd *Command) root() *Command {
+	for cmd.parent != nil {
+		cmd = cmd.parent
+	}
+	return cmd
+}
+
+// shellIdent returns s with all the bytes that can't be in a shell function
+// name replaced with underscores.
+func shellIdent(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// shellQuote returns s quoted for use as a single word in a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
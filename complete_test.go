@@ -0,0 +1,295 @@
+package cmds
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testCompletionCmd() *Command {
+	return &Command{
+		Name: "tool",
+		Flags: func() *flag.FlagSet {
+			fset := flag.NewFlagSet("tool", flag.ContinueOnError)
+			fset.Bool("v", false, "")
+			return fset
+		}(),
+		Commands: []*Command{
+			{
+				Name:    "echo",
+				Aliases: []string{"e"},
+				Runner:  nopRunner,
+				Flags: func() *flag.FlagSet {
+					fset := flag.NewFlagSet("echo", flag.ContinueOnError)
+					fset.Bool("c", false, "")
+					return fset
+				}(),
+			},
+			{
+				Name:   "req",
+				Runner: nopRunner,
+			},
+			{
+				Name:     "topic",
+				LongDesc: "About.",
+			},
+		},
+	}
+}
+
+func TestGenBashCompletion(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not found")
+	}
+
+	var b strings.Builder
+	expectErrorNone(t, testCompletionCmd().GenBashCompletion(&b))
+
+	complete := func(words ...string) string {
+		t.Helper()
+		script := b.String() + `
+COMP_WORDS=("$@")
+COMP_CWORD=$(($# - 1))
+_tool_completion
+echo "${COMPREPLY[@]}"
+`
+		out, err := exec.Command(bash, append([]string{"-c", script, "bash"}, words...)...).Output()
+		expectErrorNone(t, err)
+		return strings.TrimSpace(string(out))
+	}
+
+	expectEq(t, complete("tool", ""), "echo req -v")
+	expectEq(t, complete("tool", "e"), "echo")
+	expectEq(t, complete("tool", "echo", ""), "-c")
+	expectEq(t, complete("tool", "-v", "e", "-"), "-c")
+	expectEq(t, complete("tool", "req", ""), "")
+}
+
+func TestComplete(t *testing.T) {
+	cmd := testCompletionCmd()
+	cmd.Commands[1].Flags = flag.NewFlagSet("req", flag.ContinueOnError)
+	cmd.Commands[1].Flags.String("m", "GET", "")
+	cmd.Commands = append(cmd.Commands, &Command{Name: "secret", Hidden: true, Runner: nopRunner})
+
+	expectComplete(t, cmd, nil, []string{"echo", "req"}, CompleteDefault)
+	expectComplete(t, cmd, []string{"r"}, []string{"req"}, CompleteDefault)
+	expectComplete(t, cmd, []string{"-"}, []string{"-v"}, CompleteDefault)
+	expectComplete(t, cmd, []string{"--"}, []string{"--v"}, CompleteDefault)
+	expectComplete(t, cmd, []string{"-v", "e", "-"}, []string{"-c"}, CompleteDefault)
+	expectComplete(t, cmd, []string{"req", "-m", "-c", "-"}, []string{"-m"}, CompleteDefault)
+	expectComplete(t, cmd, []string{"req", "url", "-"}, nil, CompleteDefault)
+	expectComplete(t, cmd, []string{"invalid", ""}, nil, CompleteDefault)
+	expectComplete(t, cmd, []string{"s"}, nil, CompleteDefault)
+}
+
+func TestGenBashCompletionDynamic(t *testing.T) {
+	cmd := testCompletionCmd()
+	cmd.Commands = append(cmd.Commands, CompletionCommand())
+	expectEq(t, cmd.completePath(), []string{"completion", "__complete"})
+	expectComplete(t, cmd, []string{"c"}, []string{"completion"}, CompleteDefault)
+	expectComplete(t, cmd, []string{"completion", ""}, []string{"bash", "zsh", "fish", "install"}, CompleteDefault)
+
+	var b strings.Builder
+	expectErrorNone(t, cmd.GenBashCompletion(&b))
+	expectTrue(t, strings.Contains(b.String(), `out=($("${COMP_WORDS[0]}" 'completion' '__complete' -- "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))`))
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not found")
+	}
+
+	// The tool function stands in for the executable and outputs what the
+	// __complete command would.
+	script := `tool() { [ "$1 $2 $3 $4 $5" = "completion __complete -- a b" ] && printf 'one\ntwo three\tdesc\n:0\n'; }
+` + b.String() + `
+COMP_WORDS=(tool a b)
+COMP_CWORD=2
+_tool_completion
+printf '%s|' "${COMPREPLY[@]}"
+`
+	out, err := exec.Command(bash, "-c", script).Output()
+	expectErrorNone(t, err)
+	expectEq(t, string(out), "one|two three|")
+}
+
+func TestGenZshFishCompletion(t *testing.T) {
+	cmd := testCompletionCmd()
+	var b strings.Builder
+	expectErrorIs(t, cmd.GenZshCompletion(&b), ErrCmd)
+	expectErrorIs(t, cmd.GenFishCompletion(&b), ErrCmd)
+
+	cmd.Commands = append(cmd.Commands, CompletionCommand())
+	expectErrorNone(t, cmd.GenZshCompletion(&b))
+	expectTrue(t, strings.HasPrefix(b.String(), "#compdef tool\n"))
+	expectTrue(t, strings.Contains(b.String(), `"${words[1]}" 'completion' '__complete' --`))
+	b.Reset()
+	expectErrorNone(t, cmd.GenFishCompletion(&b))
+	expectTrue(t, strings.Contains(b.String(), "set -l out ($words[1] 'completion' '__complete' -- $words[2..-1] 2>/dev/null)"))
+	expectTrue(t, strings.Contains(b.String(), "complete -c 'tool' -f -a '(__tool_complete)'"))
+}
+
+func TestInstallCompletion(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "config"))
+	t.Setenv("SHELL", "/bin/bash")
+
+	cmd := testCompletionCmd()
+	cmd.Commands = append(cmd.Commands, CompletionCommand())
+
+	path, err := installCompletion(cmd, "")
+	expectErrorNone(t, err)
+	expectEq(t, path, filepath.Join(home, ".local/share/bash-completion/completions/tool"))
+	b, err := os.ReadFile(path)
+	expectErrorNone(t, err)
+	expectTrue(t, strings.HasPrefix(string(b), "# bash completion for tool\n"))
+
+	path, err = installCompletion(cmd, "zsh")
+	expectErrorNone(t, err)
+	expectEq(t, path, filepath.Join(home, ".zsh/completions/_tool"))
+
+	path, err = installCompletion(cmd, "fish")
+	expectErrorNone(t, err)
+	expectEq(t, path, filepath.Join(home, "config/fish/completions/tool.fish"))
+
+	_, err = installCompletion(cmd, "csh")
+	expectErrorIs(t, err, ErrArgs)
+	var cmdErr *CommandError
+	expectTrue(t, errors.As(err, &cmdErr))
+	expectEq(t, cmdErr.Arg, "csh")
+	t.Setenv("SHELL", "")
+	_, err = installCompletion(cmd, "")
+	expectErrorIs(t, err, ErrCmd)
+
+	m := DefaultMessages
+	m.UnsupportedShell = "neatbalstīta čaula \"%s\""
+	cmd.Messages = &m
+	_, err = completionInstallPath(cmd, "csh")
+	expectErrorIs(t, err, ErrArgs)
+	expectTrue(t, strings.Contains(err.Error(), `neatbalstīta čaula "csh"`))
+}
+
+func TestCompletionHints(t *testing.T) {
+	cmd := testCompletionCmd()
+	cmd.Name = "mytool"
+	cmd.Commands = append(cmd.Commands, CompletionCommand())
+
+	r, err := cmd.ParseArgs([]string{"completion", "bash"})
+	expectErrorNone(t, err)
+	expectTrue(t, strings.Contains(r.Command.UsageString(), `To load it, add "source <(mytool completion bash)" to ~/.bashrc.`))
+
+	r, err = cmd.ParseArgs([]string{"completion", "fish"})
+	expectErrorNone(t, err)
+	expectTrue(t, strings.Contains(r.Command.UsageString(), `save it as "mytool.fish" in ~/.config/fish/completions.`))
+}
+
+func TestCompleteArgs(t *testing.T) {
+	cmd := testCompletionCmd()
+	cmd.Commands[1].CompleteArgs = func(args []string, toComplete string) ([]string, Directive) {
+		if len(args) > 0 {
+			return nil, CompleteFiles
+		}
+		return []string{toComplete + "://"}, CompleteNoSpace
+	}
+
+	expectComplete(t, cmd, []string{"req", "http"}, []string{"http://"}, CompleteNoSpace)
+	expectComplete(t, cmd, []string{"req", "url", ""}, nil, CompleteFiles)
+	expectComplete(t, cmd, []string{"req", "-"}, nil, CompleteDefault)
+	expectComplete(t, cmd, []string{"echo", "a"}, nil, CompleteDefault)
+}
+
+func TestCompleteFlagValue(t *testing.T) {
+	cmd := testCompletionCmd()
+	req := cmd.Commands[1]
+	req.Flags = flag.NewFlagSet("req", flag.ContinueOnError)
+	req.Flags.String("m", "GET", "")
+	req.Flags.String("o", "", "")
+	req.Flags.String("x", "", "")
+	req.Meta("m").Choices = []string{"GET", "HEAD", "POST"}
+	req.Meta("o").Complete = func(args []string, toComplete string) ([]string, Directive) {
+		return nil, CompleteFiles
+	}
+
+	expectComplete(t, cmd, []string{"req", "-m", ""}, []string{"GET", "HEAD", "POST"}, CompleteDefault)
+	expectComplete(t, cmd, []string{"req", "-m", "H"}, []string{"HEAD"}, CompleteDefault)
+	expectComplete(t, cmd, []string{"req", "--m=P"}, []string{"--m=POST"}, CompleteDefault)
+	expectComplete(t, cmd, []string{"req", "-o", ""}, nil, CompleteFiles)
+	expectComplete(t, cmd, []string{"req", "-x", ""}, nil, CompleteDefault)
+	expectComplete(t, cmd, []string{"req", "-m", "GET", "-"}, []string{"-m", "-o", "-x"}, CompleteDefault)
+}
+
+// testCompletionTree returns a tree with hidden and deprecated commands and
+// flags at each level.
+func testCompletionTree() *Command {
+	fset := func(name string, flags ...string) *flag.FlagSet {
+		fset := flag.NewFlagSet(name, flag.ContinueOnError)
+		for _, f := range flags {
+			fset.Bool(f, false, "")
+		}
+		return fset
+	}
+
+	cmd := &Command{
+		Name:  "tool",
+		Flags: fset("tool", "v", "debug", "old"),
+		Commands: []*Command{
+			{
+				Name:  "db",
+				Flags: fset("db", "dsn", "legacy"),
+				Commands: []*Command{
+					{Name: "migrate", Runner: nopRunner},
+					{Name: "upgrade", Deprecated: "use \"migrate\"", Runner: nopRunner},
+					{Name: "repair", Hidden: true, Runner: nopRunner},
+				},
+			},
+			{Name: "serve", Runner: nopRunner},
+			{Name: "start", Deprecated: "use \"serve\"", Runner: nopRunner},
+			{Name: "internal", Hidden: true, Runner: nopRunner},
+			{Name: "topic", LongDesc: "About."},
+		},
+	}
+	cmd.Meta("debug").Hidden = true
+	cmd.Meta("old").Deprecated = "use -v"
+	cmd.Commands[0].Meta("legacy").Deprecated = "use -dsn"
+	return cmd
+}
+
+func TestCompleteHiddenDeprecated(t *testing.T) {
+	cmd := testCompletionTree()
+
+	expectComplete(t, cmd, nil, []string{"db", "serve"}, CompleteDefault)
+	expectComplete(t, cmd, []string{"-"}, []string{"-v"}, CompleteDefault)
+	expectComplete(t, cmd, []string{"db", ""}, []string{"migrate"}, CompleteDefault)
+	expectComplete(t, cmd, []string{"db", "-"}, []string{"-dsn"}, CompleteDefault)
+
+	// Hidden and deprecated commands still work when typed.
+	expectComplete(t, cmd, []string{"internal", ""}, nil, CompleteDefault)
+	expectComplete(t, cmd, []string{"db", "repair", ""}, nil, CompleteDefault)
+
+	cmd.AnnotateDeprecated = true
+	expectComplete(t, cmd, nil, []string{"db", "serve", "start\tdeprecated: use \"serve\""}, CompleteDefault)
+	expectComplete(t, cmd, []string{"-"}, []string{"-old\tdeprecated: use -v", "-v"}, CompleteDefault)
+	expectComplete(t, cmd, []string{"db", "u"}, []string{"upgrade\tdeprecated: use \"migrate\""}, CompleteDefault)
+	expectComplete(t, cmd, []string{"db", "-"}, []string{"-dsn", "-legacy\tdeprecated: use -dsn"}, CompleteDefault)
+
+	var b strings.Builder
+	expectErrorNone(t, cmd.GenBashCompletion(&b))
+	for _, name := range []string{"internal", "repair", "debug", "start", "upgrade", "old", "legacy"} {
+		if strings.Contains(b.String(), name) {
+			t.Errorf("expected static script not to contain \"%s\"", name)
+		}
+	}
+}
+
+func expectComplete(t *testing.T, cmd *Command, args, completions []string, directive Directive) {
+	t.Helper()
+	c, d := cmd.Complete(args)
+	expectEq(t, c, completions)
+	expectEq(t, d, directive)
+}
@@ -0,0 +1,24 @@
+package cmds
+
+// ConfigSource provides the values of flags that aren't given on the command
+// line, like from a config file, see package
+// [github.com/rgzlv/cmds/config].
+// The flags of each command are set from it right after they're parsed, in
+// the order described in [Source].
+type ConfigSource interface {
+	// Lookup returns the value of the flag name of the command with the
+	// [Command.Path] path and whether it has one.
+	Lookup(path []string, name string) (value string, ok bool, err error)
+}
+
+// CommandConfigSource is implemented by the ConfigSources that flags of the
+// root command configure, like the -config flag of package
+// [github.com/rgzlv/cmds/config] that sets the file to load.
+// The flags of the copies that [Command.ParseArgs] parses don't set the
+// variables they were defined with, so after the flags of the copy of the
+// root command are parsed the ConfigSource returned by ForCommand with it is
+// used for the copies instead.
+type CommandConfigSource interface {
+	ConfigSource
+	ForCommand(root *Command) ConfigSource
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// aliasKey is the section of a config file that has the aliases of the root
+// command, each alias is a string of arguments separated by white space or an
+// array of them:
+//
+//	[alias]
+//	st = "status -s"
+//	lg = ["log", "--format", "%h %s"]
+const aliasKey = "alias"
+
+// Aliases returns the aliases in the "alias" section of v, see
+// [File.Aliases].
+func (v Values) Aliases() (map[string][]string, error) {
+	section, ok := v[aliasKey]
+	if !ok {
+		return nil, nil
+	}
+	values, ok := section.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("config: \"%s\" isn't a section", aliasKey)
+	}
+
+	aliases := make(map[string][]string, len(values))
+	for _, name := range sortedKeys(values) {
+		var args []string
+		switch value := values[name].(type) {
+		case string:
+			args = strings.Fields(value)
+		case []any:
+			for _, arg := range value {
+				s, ok := arg.(string)
+				if !ok {
+					return nil, fmt.Errorf("config: argument of type %T in alias \"%s\"", arg, name)
+				}
+				args = append(args, s)
+			}
+		default:
+			return nil, fmt.Errorf("config: unsupported value of type %T for alias \"%s\"", value, name)
+		}
+		if len(args) == 0 {
+			return nil, fmt.Errorf("config: alias \"%s\" without arguments", name)
+		}
+		aliases[name] = args
+	}
+	return aliases, nil
+}
+
+// Aliases loads the config file and returns the aliases in it's "alias"
+// section like [Values.Aliases], File is a [cmds.AliasSource] so they're
+// used as UserAliases of the command it's the Config of.
+func (f *File) Aliases() (map[string][]string, error) {
+	if err := f.Load(); err != nil {
+		return nil, err
+	}
+	return f.Values().Aliases()
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/rgzlv/cmds"
+)
+
+func TestAliases(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	data := "[alias]\nst = \"status -s\"\nlg = [\"log\", \"a b\"]\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	cmd := &cmds.Command{
+		Name: "test",
+		Commands: []*cmds.Command{
+			{Name: "log", Runner: func(cmd *cmds.Command, args []string) error {
+				got = args
+				return nil
+			}},
+		},
+	}
+	f := AddFlag(cmd, path)
+	if err := cmd.ParseRun([]string{"lg", "c"}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, []string{"a b", "c"}) {
+		t.Errorf("expected the arguments of the alias, got %q", got)
+	}
+	if err := f.Check(cmd); err != nil {
+		t.Errorf("expected the aliases to be valid, got %v", err)
+	}
+
+	aliases, err := f.Aliases()
+	want := map[string][]string{"st": {"status", "-s"}, "lg": {"log", "a b"}}
+	if err != nil || !reflect.DeepEqual(aliases, want) {
+		t.Errorf("expected %v, got %v, %v", want, aliases, err)
+	}
+
+	for _, data := range []string{`{"alias": 1}`, `{"alias": {"st": 1}}`, `{"alias": {"st": [1]}}`, `{"alias": {"st": ""}}`} {
+		values, err := Parse([]byte(data), JSON)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := values.Aliases(); err == nil {
+			t.Errorf("expected an error for %s", data)
+		}
+	}
+}
@@ -0,0 +1,512 @@
+// Package config loads config files in JSON, TOML or YAML as the
+// [cmds.ConfigSource] of a command, so that the flags that aren't given on
+// the command line are set from them.
+//
+// The top level keys of a config file are the flags of the root command and
+// the tables or objects are the flags of it's sub-commands, by name, so they
+// only apply to the command they're under:
+//
+//	verbose = true
+//
+//	[req]
+//	m = "HEAD"
+//
+//	[remote.add]
+//	fetch = true
+//
+// [Values.Check] reports the keys that don't match any command or flag.
+//
+// The sections under "profiles" are named profiles with the same structure,
+// the values of the active one take precedence, see [AddProfileFlag]:
+//
+//	[profiles.staging]
+//	url = "https://staging.example.com"
+//
+// The "alias" section has the aliases of the root command, which are used with
+// it's UserAliases, see [Values.Aliases]:
+//
+//	[alias]
+//	st = "status -s"
+//
+// [LoadManifest] and [ManifestFile] load YAML versions of the [cmds.Manifest]
+// of a command.
+//
+// Importing it also registers the "yaml" output format of
+// [cmds.Command.Print].
+//
+// It's separate from package cmds so that it doesn't need any dependencies.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"github.com/rgzlv/cmds"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the format of a config file.
+type Format string
+
+const (
+	JSON Format = "json"
+	TOML Format = "toml"
+	YAML Format = "yaml"
+)
+
+// Values are the values of a config file, they're a [cmds.ConfigSource].
+type Values map[string]any
+
+// Load reads the config file at path, the format is detected with
+// [DetectFormat].
+func Load(path string) (Values, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values, err := Parse(data, DetectFormat(path, data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return values, nil
+}
+
+// Parse parses data in format.
+func Parse(data []byte, format Format) (Values, error) {
+	values := Values{}
+	var err error
+	switch format {
+	case JSON:
+		err = json.Unmarshal(data, &values)
+	case TOML:
+		err = toml.Unmarshal(data, (*map[string]any)(&values))
+	case YAML:
+		err = yaml.Unmarshal(data, (*map[string]any)(&values))
+	default:
+		err = fmt.Errorf("unknown format \"%s\"", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// DetectFormat returns the format of the config file at path with data, by
+// the extension of path, or for other extensions JSON if data starts with
+// "{", TOML if it parses as TOML or otherwise YAML.
+func DetectFormat(path string, data []byte) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return JSON
+	case ".toml":
+		return TOML
+	case ".yaml", ".yml":
+		return YAML
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return JSON
+	}
+	var m map[string]any
+	if toml.Unmarshal(data, &m) == nil {
+		return TOML
+	}
+	return YAML
+}
+
+// Lookup returns the value of the flag name of the command with path, the
+// root command is the first element of path and it's name isn't used.
+// Strings, numbers and booleans are values, other values are errors.
+func (v Values) Lookup(path []string, name string) (string, bool, error) {
+	m := map[string]any(v)
+	for i := 1; i < len(path); i++ {
+		sub, ok := m[path[i]].(map[string]any)
+		if !ok {
+			return "", false, nil
+		}
+		m = sub
+	}
+
+	value, ok := m[name]
+	if !ok {
+		return "", false, nil
+	}
+	switch value := value.(type) {
+	case string:
+		return value, true, nil
+	case bool, int, int64, uint64:
+		return fmt.Sprint(value), true, nil
+	case float64:
+		// JSON numbers are float64, without the exponent that fmt.Sprint
+		// makes for large ones.
+		return strconv.FormatFloat(value, 'f', -1, 64), true, nil
+	}
+	return "", false, fmt.Errorf("config: unsupported value of type %T for flag -%s of command \"%s\"", value, name, strings.Join(path, " "))
+}
+
+// Check returns an error for every key of v that isn't a flag or the name of
+// a sub-command whose section only has valid keys, starting from the root
+// command cmd, so that typos in config files can be reported.
+func (v Values) Check(cmd *cmds.Command) error {
+	return errors.Join(check(cmd, nil, v)...)
+}
+
+func check(cmd *cmds.Command, prefix []string, values map[string]any) []error {
+	keys := sortedKeys(values)
+
+	var errs []error
+	for _, key := range keys {
+		path := append(prefix[:len(prefix):len(prefix)], key)
+		if key == aliasKey && prefix == nil {
+			if _, err := Values(values).Aliases(); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if key == profilesKey && prefix == nil {
+			profiles, ok := values[key].(map[string]any)
+			if !ok {
+				errs = append(errs, fmt.Errorf("config: \"%s\" isn't a section", key))
+				continue
+			}
+			for _, name := range sortedKeys(profiles) {
+				profile, ok := profiles[name].(map[string]any)
+				if !ok {
+					errs = append(errs, fmt.Errorf("config: profile \"%s\" isn't a section", name))
+					continue
+				}
+				errs = append(errs, check(cmd, []string{key, name}, profile)...)
+			}
+			continue
+		}
+		if cmd.Flags != nil && cmd.Flags.Lookup(key) != nil {
+			continue
+		}
+		section, ok := values[key].(map[string]any)
+		if !ok {
+			errs = append(errs, fmt.Errorf("config: unknown flag \"%s\"", strings.Join(path, ".")))
+			continue
+		}
+		var sub *cmds.Command
+		for _, c := range cmd.Commands {
+			if c.Name == key {
+				sub = c
+			}
+		}
+		if sub == nil {
+			errs = append(errs, fmt.Errorf("config: unknown command \"%s\"", strings.Join(path, ".")))
+			continue
+		}
+		errs = append(errs, check(sub, path, section)...)
+	}
+	return errs
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// File is a [cmds.ConfigSource] that loads a config file the first time a
+// value is looked up, so that it's Path can be set by a flag.
+type File struct {
+	// Path is the config file to load, it's an error if it doesn't exist.
+	Path string
+
+	// Paths are the config files to load the first existing one of if Path
+	// is empty, like the ones from [DefaultPaths].
+	Paths []string
+
+	// Merge makes all the existing files of Paths be loaded and merged in
+	// order instead, the values of later files override the ones of earlier
+	// files, like the system, user and project files of [DefaultLayers].
+	Merge bool
+
+	// Profile is the name of the profile whose values take precedence over
+	// the other values, see [AddProfileFlag].
+	// If it's empty the one stored in ProfileFile is used.
+	Profile string
+
+	// ProfileFile is the file that the name of the active profile is stored
+	// in by [ProfileCommand].
+	ProfileFile string
+
+	skip   []string
+	once   sync.Once
+	err    error
+	mu     sync.RWMutex
+	layers []layer
+	values Values
+}
+
+// layer is a config file that a [File] loaded.
+type layer struct {
+	path   string
+	info   fs.FileInfo
+	values Values
+}
+
+// DefaultLayers returns the paths of the config files of the program name for
+// a [File] with Merge, the system ones in /etc on Unix systems or
+// %ProgramData% on Windows, then the ones from [DefaultPaths] and finally the
+// project ones in the working directory named like ".tool.toml", like the
+// system, global and local config files of git.
+func DefaultLayers(name string) []string {
+	var paths []string
+	add := func(dir, base string) {
+		for _, ext := range []string{".toml", ".yaml", ".yml", ".json"} {
+			paths = append(paths, filepath.Join(dir, base+ext))
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("ProgramData"); dir != "" {
+			add(filepath.Join(dir, name), "config")
+		}
+	} else {
+		add(filepath.Join("/etc", name), "config")
+	}
+	paths = append(paths, DefaultPaths(name)...)
+	add(".", "."+name)
+	return paths
+}
+
+// DefaultPaths returns the paths of the config file named "config" with the
+// extension of any of the formats in the [cmds.ConfigDir] of the program
+// name, like "~/.config/tool/config.toml", for [File].
+func DefaultPaths(name string) []string {
+	dir, err := cmds.ConfigDir(name)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, ext := range []string{".toml", ".yaml", ".yml", ".json"} {
+		paths = append(paths, filepath.Join(dir, "config"+ext))
+	}
+	return paths
+}
+
+// AddFlag adds the -config flag that sets the Path of the returned [File] to
+// the flags of cmd, creating them if there are none, and sets the File as the
+// Config of cmd, which should be the root command.
+// The File loads the first existing file of paths if the flag isn't given.
+func AddFlag(cmd *cmds.Command, paths ...string) *File {
+	if cmd.Flags == nil {
+		cmd.Flags = flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+		cmd.Flags.Usage = cmd.DefaultUsage()
+	}
+
+	f := &File{Paths: paths, skip: []string{"config"}}
+	cmd.Flags.StringVar(&f.Path, "config", "", "path of the config file")
+	cmd.Config = f
+	return f
+}
+
+// ForCommand returns the File to use for the copy root of the root command
+// that [cmds.Command.ParseArgs] parsed, since it's -config and -profile flags
+// don't set the Path and Profile of f: f itself if the flags are the same as
+// them, or a new File with the values of the flags and the other settings of
+// f otherwise.
+func (f *File) ForCommand(root *cmds.Command) cmds.ConfigSource {
+	path, profile := f.Path, f.Profile
+	if fl := root.Flags.Lookup("config"); fl != nil && f.skips("config") {
+		path = fl.Value.String()
+	}
+	if fl := root.Flags.Lookup("profile"); fl != nil && f.skips("profile") {
+		profile = fl.Value.String()
+	}
+	if path == f.Path && profile == f.Profile {
+		return f
+	}
+	return &File{
+		Path:        path,
+		Paths:       f.Paths,
+		Merge:       f.Merge,
+		Profile:     profile,
+		ProfileFile: f.ProfileFile,
+		skip:        f.skip,
+	}
+}
+
+// Load loads the config file if it wasn't already and returns the error from
+// loading it, it's not an error if none of Paths exist.
+func (f *File) Load() error {
+	f.once.Do(func() {
+		var layers []layer
+		layers, f.err = f.load()
+		f.setLayers(layers)
+	})
+	return f.err
+}
+
+func (f *File) load() ([]layer, error) {
+	if f.Path != "" {
+		l, err := loadLayer(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		return []layer{l}, nil
+	}
+
+	var layers []layer
+	for _, path := range f.Paths {
+		l, err := loadLayer(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, l)
+		if !f.Merge {
+			break
+		}
+	}
+	return layers, nil
+}
+
+func loadLayer(path string) (layer, error) {
+	// The file is stat'ed before it's read so that Watch doesn't miss changes
+	// made while reading it.
+	info, _ := os.Stat(path)
+	values, err := Load(path)
+	return layer{path: path, info: info, values: values}, err
+}
+
+func (f *File) setLayers(layers []layer) {
+	values := Values{}
+	for _, l := range layers {
+		merge(values, l.values)
+	}
+	f.mu.Lock()
+	f.layers = layers
+	f.values = values
+	f.mu.Unlock()
+}
+
+// merge merges src into dst, the sections are merged recursively and the
+// other values of src replace the ones of dst.
+func merge(dst, src map[string]any) {
+	for key, value := range src {
+		section, ok := value.(map[string]any)
+		if !ok {
+			dst[key] = value
+			continue
+		}
+		dstSection, ok := dst[key].(map[string]any)
+		if !ok {
+			dstSection = map[string]any{}
+			dst[key] = dstSection
+		}
+		merge(dstSection, section)
+	}
+}
+
+// Check loads the config file and checks it like [Values.Check].
+func (f *File) Check(cmd *cmds.Command) error {
+	if err := f.Load(); err != nil {
+		return err
+	}
+	return f.Values().Check(cmd)
+}
+
+// Values returns the values of the config files that were loaded, merged,
+// they're replaced when they're reloaded by [File.Watch].
+func (f *File) Values() Values {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.values
+}
+
+// Used returns the path of the config file that was loaded, the last one if
+// Merge is set, or an empty string if none was.
+func (f *File) Used() string {
+	loaded := f.Loaded()
+	if len(loaded) == 0 {
+		return ""
+	}
+	return loaded[len(loaded)-1]
+}
+
+// Loaded returns the paths of the config files that were loaded, in the order
+// they were merged in.
+func (f *File) Loaded() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	var paths []string
+	for _, l := range f.layers {
+		paths = append(paths, l.path)
+	}
+	return paths
+}
+
+// Origin returns the path of the config file that the value of the flag name
+// of the command with path comes from, or an empty string if it's not in any.
+func (f *File) Origin(path []string, name string) string {
+	if f.Load() != nil {
+		return ""
+	}
+	f.mu.RLock()
+	layers := f.layers
+	f.mu.RUnlock()
+	for i := len(layers) - 1; i >= 0; i-- {
+		if _, ok, _ := f.lookup(layers[i].values, path, name); ok {
+			return layers[i].path
+		}
+	}
+	return ""
+}
+
+// Lookup loads the config file and looks up the value in it like
+// [Values.Lookup], in the active profile first, the flags added by [AddFlag]
+// and [AddProfileFlag] aren't looked up.
+func (f *File) Lookup(path []string, name string) (string, bool, error) {
+	if len(path) == 1 && f.skips(name) {
+		return "", false, nil
+	}
+	if err := f.Load(); err != nil {
+		return "", false, err
+	}
+
+	return f.lookup(f.Values(), path, name)
+}
+
+// lookup looks up the value in values, in the active profile first.
+func (f *File) lookup(values Values, path []string, name string) (string, bool, error) {
+	profile, err := f.profile(values)
+	if err != nil {
+		return "", false, err
+	}
+	if profile != nil {
+		if value, ok, err := profile.Lookup(path, name); ok || err != nil {
+			return value, ok, err
+		}
+	}
+	return values.Lookup(path, name)
+}
+
+// skips reports whether the flag name of the root command isn't looked up.
+func (f *File) skips(name string) bool {
+	for _, skip := range f.skip {
+		if name == skip {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,246 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/rgzlv/cmds"
+)
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	files := []struct{ name, data, token string }{
+		{"config.json", `{"token": "json", "n": 2, "req": {"m": "HEAD", "v": true}}`, "json"},
+		{"config.toml", "token = \"toml\"\nn = 2\n[req]\nm = \"HEAD\"\nv = true\n", "toml"},
+		{"config.yaml", "token: yaml\nn: 2\nreq:\n  m: HEAD\n  v: true\n", "yaml"},
+		{"detected-json", `{"token": "json", "req": {"m": "HEAD", "v": true}}`, "json"},
+		{"detected-toml", "token = \"toml\"\n[req]\nm = \"HEAD\"\nv = true\n", "toml"},
+		{"detected-yaml", "token: yaml\nreq:\n  m: HEAD\n  v: true\n", "yaml"},
+	}
+	for _, file := range files {
+		name := file.name
+		path := write(name, file.data)
+
+		var token, method string
+		var n int
+		var v bool
+		cmd := testCmd(&token, &n, &method, &v)
+		AddFlag(cmd, filepath.Join(dir, "missing.toml"), path)
+		if err := cmd.ParseRun([]string{"-n", "3", "req"}); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		got := []any{token, n, method, v}
+		want := []any{file.token, 3, "HEAD", true}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+		if f := cmd.Config.(*File); f.Used() != path {
+			t.Errorf("%s: expected %s to be used, got %s", name, path, f.Used())
+		}
+	}
+
+	var token, method string
+	var n int
+	var v bool
+	other := write("other.yml", "token: other\nreq:\n  m: [GET]\n")
+	cmd := testCmd(&token, &n, &method, &v)
+	AddFlag(cmd, filepath.Join(dir, "config.json"))
+	err := cmd.ParseRun([]string{"-config", other, "req"})
+	if err == nil || !errors.Is(err, cmds.Err) {
+		t.Fatalf("expected error for the unsupported value, got %v", err)
+	}
+	if token != "other" {
+		t.Errorf("expected token from %s, got %s", other, token)
+	}
+
+	// The -config flag of the copies of ParseArgs is used too.
+	cmd = testCmd(&token, &n, &method, &v)
+	AddFlag(cmd, filepath.Join(dir, "config.json"))
+	r, err := cmd.ParseArgs([]string{"-config", filepath.Join(dir, "config.toml"), "req"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Command.Parent().Flags.Lookup("token").Value.String(); got != "toml" {
+		t.Errorf("expected token from config.toml, got %s", got)
+	}
+	if f := cmd.Config.(*File); f.Path != "" || f.Used() != "" {
+		t.Errorf("expected the File of cmd to be unchanged, got %s and %s", f.Path, f.Used())
+	}
+
+	cmd = testCmd(&token, &n, &method, &v)
+	AddFlag(cmd)
+	if err := cmd.ParseRun([]string{"-config", filepath.Join(dir, "missing.toml"), "req"}); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected error for the missing config file, got %v", err)
+	}
+}
+
+func testCmd(token *string, n *int, method *string, v *bool) *cmds.Command {
+	cmd := &cmds.Command{
+		Name: "test",
+		Flags: func() *flag.FlagSet {
+			fset := flag.NewFlagSet("test", flag.ContinueOnError)
+			fset.StringVar(token, "token", "", "")
+			fset.IntVar(n, "n", 1, "")
+			return fset
+		}(),
+		Commands: []*cmds.Command{
+			{
+				Name: "req",
+				Flags: func() *flag.FlagSet {
+					fset := flag.NewFlagSet("req", flag.ContinueOnError)
+					fset.StringVar(method, "m", "GET", "")
+					fset.BoolVar(v, "v", false, "")
+					return fset
+				}(),
+				Runner: func(cmd *cmds.Command, args []string) error {
+					return nil
+				},
+			},
+		},
+	}
+	cmd.Meta("token").Required = true
+	return cmd
+}
+
+func TestDefaultPaths(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/config")
+	t.Setenv("HOME", "/home/u")
+	dir, err := cmds.ConfigDir("tool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := DefaultPaths("tool")
+	if len(paths) == 0 || paths[0] != filepath.Join(dir, "config.toml") {
+		t.Errorf("expected %s first, got %v", filepath.Join(dir, "config.toml"), paths)
+	}
+}
+
+func TestSections(t *testing.T) {
+	var fetch, verbose bool
+	cmd := &cmds.Command{
+		Name: "test",
+		Flags: func() *flag.FlagSet {
+			fset := flag.NewFlagSet("test", flag.ContinueOnError)
+			fset.BoolVar(&verbose, "v", false, "")
+			return fset
+		}(),
+		Commands: []*cmds.Command{
+			{
+				Name: "remote",
+				Commands: []*cmds.Command{
+					{
+						Name:   "add",
+						Runner: func(cmd *cmds.Command, args []string) error { return nil },
+						Flags: func() *flag.FlagSet {
+							fset := flag.NewFlagSet("add", flag.ContinueOnError)
+							fset.BoolVar(&fetch, "fetch", false, "")
+							return fset
+						}(),
+					},
+				},
+			},
+		},
+	}
+
+	values, err := Parse([]byte("fetch = false\n[remote.add]\nfetch = true\nv = true\n"), TOML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd.Config = values
+	if err := cmd.ParseRun([]string{"remote", "add"}); err != nil {
+		t.Fatal(err)
+	}
+	if !fetch || verbose {
+		t.Errorf("expected only fetch to be set, got fetch=%v v=%v", fetch, verbose)
+	}
+
+	err = values.Check(cmd)
+	want := "config: unknown flag \"fetch\"\nconfig: unknown flag \"remote.add.v\""
+	if err == nil || err.Error() != want {
+		t.Errorf("expected %q, got %v", want, err)
+	}
+
+	values, _ = Parse([]byte(`{"v": true, "remote": {"add": {"fetch": true}}, "other": {}}`), JSON)
+	if err := values.Check(cmd); err == nil || err.Error() != "config: unknown command \"other\"" {
+		t.Errorf("expected unknown command error, got %v", err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	system := write("system.toml", "token = \"system\"\nn = 1\n[req]\nm = \"HEAD\"\nv = true\n")
+	user := write("user.yaml", "token: user\n")
+	project := write("project.json", `{"req": {"m": "GET"}}`)
+
+	var token, method string
+	var n int
+	var v bool
+	cmd := testCmd(&token, &n, &method, &v)
+	f := &File{Paths: []string{system, filepath.Join(dir, "missing.toml"), user, project}, Merge: true}
+	cmd.Config = f
+	if err := cmd.ParseRun([]string{"req"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := []any{token, n, method, v}, []any{"user", 1, "GET", true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got, want := f.Loaded(), []string{system, user, project}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v to be loaded, got %v", want, got)
+	}
+	if f.Used() != project {
+		t.Errorf("expected %s to be used, got %s", project, f.Used())
+	}
+	for _, test := range []struct {
+		path []string
+		flag string
+		want string
+	}{
+		{[]string{"test"}, "token", user},
+		{[]string{"test"}, "n", system},
+		{[]string{"test", "req"}, "m", project},
+		{[]string{"test", "req"}, "v", system},
+		{[]string{"test", "req"}, "x", ""},
+	} {
+		if got := f.Origin(test.path, test.flag); got != test.want {
+			t.Errorf("expected -%s from %s, got %s", test.flag, test.want, got)
+		}
+	}
+
+	layers := DefaultLayers("tool")
+	if len(layers) == 0 || layers[len(layers)-1] != filepath.Join(".", ".tool.json") {
+		t.Errorf("expected the project files last, got %v", layers)
+	}
+}
+
+func TestLookupNumbers(t *testing.T) {
+	values, err := Parse([]byte(`{"n": 10000000, "f": 0.5, "i": -3}`), JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"n": "10000000", "f": "0.5", "i": "-3"} {
+		got, ok, err := values.Lookup([]string{"test"}, name)
+		if err != nil || !ok || got != want {
+			t.Errorf("expected %s for %s, got %q, %v, %v", want, name, got, ok, err)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+module github.com/rgzlv/cmds/config
+
+go 1.21
+
+require (
+	github.com/BurntSushi/toml v1.6.0
+	github.com/rgzlv/cmds v0.0.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+replace github.com/rgzlv/cmds => ../
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rgzlv/cmds"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadManifest is like [cmds.LoadManifest] but reads a YAML manifest, with the
+// same keys as the JSON one:
+//
+//	name: greet
+//	shortDesc: greet someone
+//	flags:
+//	  - name: greeting
+//	    default: hello
+//	exec: [echo, "{{.Flags.greeting}}", "{{.Args}}"]
+func LoadManifest(r io.Reader) (*cmds.Command, error) {
+	var m any
+	if err := yaml.NewDecoder(r).Decode(&m); err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+	return cmds.LoadManifest(bytes.NewReader(data))
+}
+
+// ManifestFile is like [cmds.ManifestFile] but the file is a YAML manifest
+// loaded with [LoadManifest].
+func ManifestFile(name, shortDesc, path string) *cmds.Command {
+	return &cmds.Command{
+		Name:      name,
+		ShortDesc: shortDesc,
+		LoadFunc: func() (*cmds.Command, error) {
+			f, err := os.Open(path)
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+			return LoadManifest(f)
+		},
+	}
+}
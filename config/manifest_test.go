@@ -0,0 +1,58 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rgzlv/cmds"
+)
+
+func TestLoadManifest(t *testing.T) {
+	manifest := `
+name: tool
+commands:
+  - name: greet
+    shortDesc: greet someone
+    aliases: [hi]
+    flags:
+      - name: loud
+        type: bool
+`
+	cmd, err := LoadManifest(strings.NewReader(manifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	greet := cmd.Find("hi")
+	if greet == nil || greet.ShortDesc != "greet someone" || greet.Flags.Lookup("loud") == nil {
+		t.Fatalf("unexpected command %+v", greet)
+	}
+
+	for _, manifest := range []string{"name: [", "name: tool\nunknown: true", "shortDesc: no name"} {
+		if _, err := LoadManifest(strings.NewReader(manifest)); err == nil || !strings.HasPrefix(err.Error(), "manifest: ") {
+			t.Errorf("expected manifest error for %q, got %v", manifest, err)
+		}
+	}
+}
+
+func TestManifestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "greet.yaml")
+	if err := os.WriteFile(path, []byte("name: hello\ncommands:\n  - name: world\n    shortDesc: say hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := &cmds.Command{
+		Name:     "test",
+		Commands: []*cmds.Command{ManifestFile("greet", "greet someone", path)},
+	}
+	if err := cmd.ParseRun([]string{"greet"}); !strings.Contains(cmd.UsageString(), "greet someone") || err == nil {
+		t.Errorf("expected the missing command of the manifest, got %v", err)
+	}
+	r, err := cmd.ParseArgs([]string{"greet", "world"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Command.ShortDesc != "say hello" {
+		t.Errorf("expected the command from the manifest, got %+v", r.Command)
+	}
+}
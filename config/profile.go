@@ -0,0 +1,136 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rgzlv/cmds"
+)
+
+// profilesKey is the section of a config file that has the profiles, each
+// profile is a section named after it with the same structure as the file:
+//
+//	[profiles.staging]
+//	url = "https://staging.example.com"
+const profilesKey = "profiles"
+
+// Profiles returns the names of the profiles in v, in lexical order.
+func (v Values) Profiles() []string {
+	profiles, _ := v[profilesKey].(map[string]any)
+	return sortedKeys(profiles)
+}
+
+// Profile returns the values of the profile name of v and whether it exists.
+func (v Values) Profile(name string) (Values, bool) {
+	profiles, _ := v[profilesKey].(map[string]any)
+	profile, ok := profiles[name].(map[string]any)
+	return profile, ok
+}
+
+// ActiveProfile returns the name of the profile whose values are used, which
+// is Profile or the one stored in ProfileFile, or an empty string if there's
+// none.
+func (f *File) ActiveProfile() (string, error) {
+	if f.Profile != "" || f.ProfileFile == "" {
+		return f.Profile, nil
+	}
+	data, err := os.ReadFile(f.ProfileFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	return strings.TrimSpace(string(data)), err
+}
+
+// profile returns the values of the active profile in values, it's an error
+// if Profile doesn't exist but the one in ProfileFile is ignored if it
+// doesn't, so that [ProfileCommand] can still be used to fix it.
+func (f *File) profile(values Values) (Values, error) {
+	name, err := f.ActiveProfile()
+	if err != nil || name == "" {
+		return nil, err
+	}
+	profile, ok := values.Profile(name)
+	if !ok && f.Profile != "" {
+		return nil, fmt.Errorf("config: no such profile \"%s\"", name)
+	}
+	return profile, nil
+}
+
+// AddProfileFlag adds the -profile flag that sets the Profile of f to the
+// flags of cmd, creating them if there are none, and sets the ProfileFile of
+// f to the file named "profile" in the [cmds.ConfigDir] of cmd if it isn't
+// set.
+func AddProfileFlag(cmd *cmds.Command, f *File) {
+	if cmd.Flags == nil {
+		cmd.Flags = flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+		cmd.Flags.Usage = cmd.DefaultUsage()
+	}
+	if f.ProfileFile == "" {
+		if dir, err := cmd.ConfigDir(); err == nil {
+			f.ProfileFile = filepath.Join(dir, "profile")
+		}
+	}
+	f.skip = append(f.skip, "profile")
+	cmd.Flags.StringVar(&f.Profile, "profile", f.Profile, "name of the config profile to use")
+}
+
+// ProfileCommand returns a "profile" command to add to the Commands of the
+// root command, with the "list" sub-command that outputs the profiles of f
+// with the active one marked with "*" and the "use" sub-command that stores
+// the name of the active profile in the ProfileFile of f.
+func ProfileCommand(f *File) *cmds.Command {
+	return &cmds.Command{
+		Name:      "profile",
+		ShortDesc: "list or switch config profiles",
+		Commands: []*cmds.Command{
+			{
+				Name:      "list",
+				ShortDesc: "list the profiles",
+				Args:      cmds.ExactArgs(0),
+				Runner: func(cmd *cmds.Command, args []string) error {
+					if err := f.Load(); err != nil {
+						return err
+					}
+					active, err := f.ActiveProfile()
+					if err != nil {
+						return err
+					}
+					for _, name := range f.Values().Profiles() {
+						mark := " "
+						if name == active {
+							mark = "*"
+						}
+						fmt.Fprintf(cmd.Output(), "%s %s\n", mark, name)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "use",
+				ShortDesc: "switch the active profile",
+				ArgsUsage: "<name>",
+				Args:      cmds.ExactArgs(1),
+				Runner: func(cmd *cmds.Command, args []string) error {
+					if err := f.Load(); err != nil {
+						return err
+					}
+					if _, ok := f.Values().Profile(args[0]); !ok {
+						return fmt.Errorf("config: no such profile \"%s\"", args[0])
+					}
+					if f.ProfileFile == "" {
+						return errors.New("config: no file to store the profile in")
+					}
+					if err := os.MkdirAll(filepath.Dir(f.ProfileFile), 0o755); err != nil {
+						return err
+					}
+					return os.WriteFile(f.ProfileFile, []byte(args[0]+"\n"), 0o644)
+				},
+			},
+		},
+	}
+}
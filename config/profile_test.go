@@ -0,0 +1,94 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rgzlv/cmds"
+)
+
+func TestProfiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	data := "url = \"prod\"\nn = 1\n[profiles.staging]\nurl = \"staging\"\n[profiles.dev]\nurl = \"dev\"\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var url string
+	var n int
+	var out bytes.Buffer
+	testCmd := func() *cmds.Command {
+		cmd := &cmds.Command{
+			Name:   "test",
+			Stdout: &out,
+			Flags: func() *flag.FlagSet {
+				fset := flag.NewFlagSet("test", flag.ContinueOnError)
+				fset.StringVar(&url, "url", "", "")
+				fset.IntVar(&n, "n", 0, "")
+				return fset
+			}(),
+			Commands: []*cmds.Command{
+				{Name: "run", Runner: func(cmd *cmds.Command, args []string) error { return nil }},
+			},
+		}
+		f := AddFlag(cmd, path)
+		f.ProfileFile = filepath.Join(dir, "state", "profile")
+		AddProfileFlag(cmd, f)
+		cmd.Commands = append(cmd.Commands, ProfileCommand(f))
+		if err := f.Check(cmd); err != nil {
+			t.Fatal(err)
+		}
+		return cmd
+	}
+
+	run := func(args ...string) {
+		t.Helper()
+		out.Reset()
+		if err := testCmd().ParseRun(args); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expect := func(got, want any) {
+		t.Helper()
+		if got != want {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	}
+
+	run("run")
+	expect(url, "prod")
+	run("-profile", "staging", "run")
+	expect(url, "staging")
+	expect(n, 1)
+
+	// The -profile flag of the copies of ParseArgs is used too.
+	r, err := testCmd().ParseArgs([]string{"-profile", "staging", "run"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect(r.Command.Parent().Flags.Lookup("url").Value.String(), "staging")
+
+	run("profile", "use", "dev")
+	run("run")
+	expect(url, "dev")
+	run("profile", "list")
+	expect(out.String(), "* dev\n  staging\n")
+
+	if err := testCmd().ParseRun([]string{"-profile", "missing", "run"}); err == nil {
+		t.Error("expected error for a missing profile")
+	}
+	if err := testCmd().ParseRun([]string{"profile", "use", "missing"}); err == nil {
+		t.Error("expected error for switching to a missing profile")
+	}
+
+	// A stored profile that doesn't exist anymore is ignored.
+	if err := os.WriteFile(filepath.Join(dir, "state", "profile"), []byte("removed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run("run")
+	expect(url, "prod")
+}
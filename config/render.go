@@ -0,0 +1,22 @@
+package config
+
+import (
+	"io"
+
+	"github.com/rgzlv/cmds"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	cmds.RegisterRenderer("yaml", renderYAML)
+}
+
+// renderYAML is the [cmds.Renderer] of the "yaml" output format.
+func renderYAML(w io.Writer, v any, arg string) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rgzlv/cmds"
+)
+
+func TestRenderYAML(t *testing.T) {
+	var b strings.Builder
+	cmd := &cmds.Command{
+		Name:   "test",
+		Stdout: &b,
+		Runner: func(cmd *cmds.Command, args []string) error {
+			return cmd.Print(map[string]any{"name": "ann", "tags": []string{"a"}})
+		},
+	}
+	cmd.AddOutputFlag()
+	if err := cmd.ParseRun([]string{"-output", "yaml"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "name: ann\ntags:\n  - a\n"; b.String() != want {
+		t.Errorf("expected %q, got %q", want, b.String())
+	}
+}
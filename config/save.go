@@ -0,0 +1,97 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/BurntSushi/toml"
+	"github.com/rgzlv/cmds"
+	"gopkg.in/yaml.v3"
+)
+
+// Save writes the flags of cmd and it's parents that were given on the command
+// line to the config file at path, so that they're used as the defaults from
+// then on, cmd is usually the command whose Runner is running.
+// The values are merged into the values already in the file, which is
+// rewritten in it's format or the one detected from path, so comments in it
+// are lost.
+// The flags that are Secret in their [cmds.FlagMeta] and the ones added by
+// [AddFlag] and [AddProfileFlag] aren't written.
+func Save(cmd *cmds.Command, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	format := DetectFormat(path, data)
+	values := Values{}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if values, err = Parse(data, format); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	var chain []*cmds.Command
+	for c := cmd; c != nil; c = c.Parent() {
+		chain = append([]*cmds.Command{c}, chain...)
+	}
+	file, _ := chain[0].Config.(*File)
+
+	for i, c := range chain {
+		if c.Flags == nil {
+			continue
+		}
+		section := map[string]any(values)
+		for _, name := range c.Path()[1:] {
+			sub, ok := section[name].(map[string]any)
+			if !ok {
+				sub = map[string]any{}
+				section[name] = sub
+			}
+			section = sub
+		}
+		c.Flags.Visit(func(f *flag.Flag) {
+			if meta := c.FlagMeta[f.Name]; meta != nil && meta.Secret {
+				return
+			}
+			if i == 0 && file != nil && file.skips(f.Name) {
+				return
+			}
+			if c.ValueSource(f.Name) != cmds.SourceCommandLine {
+				return
+			}
+			section[f.Name] = flagValue(f)
+		})
+	}
+
+	var b bytes.Buffer
+	switch format {
+	case JSON:
+		enc := json.NewEncoder(&b)
+		enc.SetIndent("", "\t")
+		err = enc.Encode(values)
+	case TOML:
+		err = toml.NewEncoder(&b).Encode(map[string]any(values))
+	case YAML:
+		err = yaml.NewEncoder(&b).Encode(map[string]any(values))
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b.Bytes(), 0o644)
+}
+
+// flagValue returns the value of f as the type it has in config files.
+func flagValue(f *flag.Flag) any {
+	if getter, ok := f.Value.(flag.Getter); ok {
+		switch v := getter.Get().(type) {
+		case bool, int, int64, uint, uint64, float64:
+			return v
+		}
+	}
+	return f.Value.String()
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rgzlv/cmds"
+)
+
+func TestSave(t *testing.T) {
+	for _, name := range []string{"config.toml", "config.yaml", "config.json"} {
+		path := filepath.Join(t.TempDir(), name)
+
+		var saveErr error
+		testCmd := func() *cmds.Command {
+			cmd := &cmds.Command{
+				Name: "test",
+				Flags: func() *flag.FlagSet {
+					fset := flag.NewFlagSet("test", flag.ContinueOnError)
+					fset.Bool("v", false, "")
+					fset.String("token", "", "")
+					fset.String("url", "", "")
+					return fset
+				}(),
+				Commands: []*cmds.Command{
+					{
+						Name: "req",
+						Flags: func() *flag.FlagSet {
+							fset := flag.NewFlagSet("req", flag.ContinueOnError)
+							fset.String("m", "GET", "")
+							fset.Int("n", 1, "")
+							return fset
+						}(),
+						Runner: func(cmd *cmds.Command, args []string) error {
+							saveErr = Save(cmd, path)
+							return nil
+						},
+					},
+				},
+			}
+			cmd.Meta("token").Secret = true
+			AddFlag(cmd, path)
+			return cmd
+		}
+
+		if err := testCmd().ParseRun([]string{"-v", "-token", "x", "req", "-n", "3"}); err != nil || saveErr != nil {
+			t.Fatalf("%s: %v, %v", name, err, saveErr)
+		}
+		if err := testCmd().ParseRun([]string{"-url", "u", "req", "-m", "HEAD"}); err != nil || saveErr != nil {
+			t.Fatalf("%s: %v, %v", name, err, saveErr)
+		}
+
+		values, err := Load(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for _, test := range []struct {
+			path []string
+			flag string
+			want string
+		}{
+			{[]string{"test"}, "v", "true"},
+			{[]string{"test"}, "url", "u"},
+			{[]string{"test", "req"}, "m", "HEAD"},
+			{[]string{"test", "req"}, "n", "3"},
+		} {
+			got, ok, err := values.Lookup(test.path, test.flag)
+			if err != nil || !ok || got != test.want {
+				t.Errorf("%s: expected %s for -%s, got %s, %v, %v", name, test.want, test.flag, got, ok, err)
+			}
+		}
+		for _, flag := range []string{"token", "config"} {
+			if _, ok, _ := values.Lookup([]string{"test"}, flag); ok {
+				t.Errorf("%s: expected -%s not to be saved", name, flag)
+			}
+		}
+
+		if data, _ := os.ReadFile(path); len(data) == 0 {
+			t.Errorf("%s: expected a config file", name)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"strconv"
+
+	"github.com/rgzlv/cmds"
+)
+
+// schemaURI is the JSON Schema version of the schemas returned by [Schema].
+const schemaURI = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema returns a JSON Schema of the config files of the root command cmd,
+// with the flags of the commands, their usage strings, defaults and choices,
+// so that editors can validate and complete config files.
+// The types of the flags are the ones of the values returned by
+// [flag.Getter] if the flag.Value implements it, otherwise they're strings.
+// Only the commands that have a FlagSet are included, the flags added by
+// [AddFlag] and [AddProfileFlag] aren't, the profiles have the same schema as
+// the file.
+func Schema(cmd *cmds.Command) ([]byte, error) {
+	schema := commandSchema(cmd, true)
+	profile := commandSchema(cmd, true)
+	schema["properties"].(map[string]any)[profilesKey] = map[string]any{
+		"type":                 "object",
+		"additionalProperties": profile,
+	}
+	schema["$schema"] = schemaURI
+	schema["title"] = cmd.Name
+	return json.MarshalIndent(schema, "", "\t")
+}
+
+func commandSchema(cmd *cmds.Command, root bool) map[string]any {
+	properties := map[string]any{}
+	if cmd.Flags != nil {
+		file, _ := cmd.Config.(*File)
+		cmd.Flags.VisitAll(func(f *flag.Flag) {
+			if !root || file == nil || !file.skips(f.Name) {
+				properties[f.Name] = flagSchema(cmd, f)
+			}
+		})
+	}
+	for _, sub := range cmd.Commands {
+		if sub.Flags == nil && len(sub.Commands) == 0 {
+			continue
+		}
+		properties[sub.Name] = commandSchema(sub, false)
+	}
+
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if desc := cmd.Short(); desc != "" && !root {
+		schema["description"] = desc
+	}
+	return schema
+}
+
+func flagSchema(cmd *cmds.Command, f *flag.Flag) map[string]any {
+	schema := map[string]any{}
+	if usage := cmds.FlagUsage(f); usage != "" {
+		schema["description"] = usage
+	}
+
+	var value any = f.DefValue
+	typ := "string"
+	if getter, ok := f.Value.(flag.Getter); ok {
+		switch getter.Get().(type) {
+		case bool:
+			typ = "boolean"
+			value, _ = strconv.ParseBool(f.DefValue)
+		case int, int64, uint, uint64:
+			typ = "integer"
+			value, _ = strconv.ParseInt(f.DefValue, 0, 64)
+		case float64:
+			typ = "number"
+			value, _ = strconv.ParseFloat(f.DefValue, 64)
+		}
+	}
+	schema["type"] = typ
+	schema["default"] = value
+
+	if meta := cmd.FlagMeta[f.Name]; meta != nil {
+		if len(meta.Choices) > 0 {
+			schema["enum"] = meta.Choices
+		}
+		if meta.Deprecated != "" {
+			schema["deprecated"] = true
+		}
+	}
+	return schema
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"reflect"
+	"testing"
+
+	"github.com/rgzlv/cmds"
+)
+
+func TestSchema(t *testing.T) {
+	cmd := &cmds.Command{
+		Name: "test",
+		Flags: func() *flag.FlagSet {
+			fset := flag.NewFlagSet("test", flag.ContinueOnError)
+			fset.Bool("v", false, "verbose output")
+			fset.Int("n", 3, "")
+			return fset
+		}(),
+		Commands: []*cmds.Command{
+			{
+				Name:      "req",
+				ShortDesc: "make a request",
+				Flags: func() *flag.FlagSet {
+					fset := flag.NewFlagSet("req", flag.ContinueOnError)
+					fset.String("m", "GET", "method")
+					fset.Float64("timeout", 1.5, "")
+					return fset
+				}(),
+			},
+			{Name: "version"},
+		},
+	}
+	cmd.Commands[0].Meta("m").Choices = []string{"GET", "HEAD"}
+	AddProfileFlag(cmd, AddFlag(cmd))
+
+	data, err := Schema(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	file := map[string]any{
+		"$schema":              schemaURI,
+		"title":                "test",
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"v": map[string]any{"type": "boolean", "default": false, "description": "verbose output"},
+			"n": map[string]any{"type": "integer", "default": 3.0},
+			"req": map[string]any{
+				"type":                 "object",
+				"description":          "make a request",
+				"additionalProperties": false,
+				"properties": map[string]any{
+					"m":       map[string]any{"type": "string", "default": "GET", "description": "method", "enum": []any{"GET", "HEAD"}},
+					"timeout": map[string]any{"type": "number", "default": 1.5},
+				},
+			},
+		},
+	}
+	want := map[string]any{}
+	for k, v := range file {
+		want[k] = v
+	}
+	want["properties"] = map[string]any{}
+	for k, v := range file["properties"].(map[string]any) {
+		want["properties"].(map[string]any)[k] = v
+	}
+	delete(file, "$schema")
+	delete(file, "title")
+	want["properties"].(map[string]any)["profiles"] = map[string]any{
+		"type":                 "object",
+		"additionalProperties": file,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
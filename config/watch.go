@@ -0,0 +1,97 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/rgzlv/cmds"
+)
+
+// Watch checks the config files that were loaded every interval until ctx is
+// done and when any of them was changed, reloads them and calls fn with the new values
+// after checking them with [Values.Check] against the root command of cmd,
+// or with the error, for Runners that serve or watch and want to reload
+// without restarting.
+// The values returned by [File.Values] are replaced only if they're valid,
+// flags aren't set from them since the Runner may be using them, it's up to
+// fn to apply them.
+// Files that didn't exist when they were loaded aren't watched.
+// It returns an error if no config file was loaded, otherwise it blocks until
+// ctx is done and returns nil.
+func (f *File) Watch(ctx context.Context, cmd *cmds.Command, interval time.Duration, fn func(Values, error)) error {
+	if err := f.Load(); err != nil {
+		return err
+	}
+	f.mu.RLock()
+	layers := f.layers
+	f.mu.RUnlock()
+	if len(layers) == 0 {
+		return errors.New("config: no config file to watch")
+	}
+	root := cmd
+	for root.Parent() != nil {
+		root = root.Parent()
+	}
+
+	last := make([]fs.FileInfo, len(layers))
+	for i, l := range layers {
+		last[i] = l.info
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		changed := false
+		for i, l := range layers {
+			info, _ := os.Stat(l.path)
+			if !sameFile(info, last[i]) {
+				changed = true
+			}
+			last[i] = info
+		}
+		if !changed {
+			continue
+		}
+
+		reloaded := make([]layer, 0, len(layers))
+		var err error
+		for _, l := range layers {
+			var rl layer
+			if rl, err = loadLayer(l.path); err != nil {
+				break
+			}
+			reloaded = append(reloaded, rl)
+		}
+		if err != nil {
+			fn(nil, err)
+			continue
+		}
+		values := Values{}
+		for _, l := range reloaded {
+			merge(values, l.values)
+		}
+		if err := values.Check(root); err != nil {
+			fn(nil, err)
+			continue
+		}
+		f.setLayers(reloaded)
+		fn(f.Values(), nil)
+	}
+}
+
+// sameFile reports whether a and b, which can be nil for files that don't
+// exist, are the same version of a file.
+func sameFile(a, b fs.FileInfo) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.ModTime().Equal(b.ModTime()) && a.Size() == b.Size()
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rgzlv/cmds"
+)
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	write := func(data string, mtime time.Time) {
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Now().Add(-time.Hour)
+	write("port = 1\n", start)
+
+	cmd := &cmds.Command{
+		Name: "test",
+		Flags: func() *flag.FlagSet {
+			fset := flag.NewFlagSet("test", flag.ContinueOnError)
+			fset.Int("port", 0, "")
+			return fset
+		}(),
+		Runner: func(cmd *cmds.Command, args []string) error { return nil },
+	}
+	f := AddFlag(cmd, path)
+	if err := cmd.ParseRun(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		values Values
+		err    error
+	}
+	results := make(chan result)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- f.Watch(ctx, cmd, time.Millisecond, func(values Values, err error) {
+			results <- result{values, err}
+		})
+	}()
+
+	write("port = 2\n", start.Add(time.Minute))
+	if r := <-results; r.err != nil || r.values["port"] != int64(2) {
+		t.Errorf("expected port 2, got %v, %v", r.values, r.err)
+	}
+
+	write("invalid = 3\n", start.Add(2*time.Minute))
+	if r := <-results; r.err == nil {
+		t.Errorf("expected error for the invalid config, got %v", r.values)
+	}
+	if got := f.Values()["port"]; got != int64(2) {
+		t.Errorf("expected the valid values to be kept, got %v", got)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+
+	if err := (&File{}).Watch(context.Background(), cmd, time.Millisecond, nil); err == nil {
+		t.Error("expected error without a config file")
+	}
+}
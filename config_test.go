@@ -0,0 +1,65 @@
+package cmds
+
+import (
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+)
+
+type testConfig map[string]string
+
+func (c testConfig) Lookup(path []string, name string) (string, bool, error) {
+	if name == "fail" {
+		return "", false, errors.New("lookup failed")
+	}
+	value, ok := c[strings.Join(append(path, name), ".")]
+	return value, ok, nil
+}
+
+func TestConfig(t *testing.T) {
+	var token, method string
+	testCmd := func(config ConfigSource) *Command {
+		cmd := &Command{
+			Name:   "test",
+			Config: config,
+			Flags: func() *flag.FlagSet {
+				fset := flag.NewFlagSet("test", flag.ContinueOnError)
+				fset.StringVar(&token, "token", "", "")
+				return fset
+			}(),
+			Commands: []*Command{
+				{
+					Name:   "req",
+					Runner: nopRunner,
+					Flags: func() *flag.FlagSet {
+						fset := flag.NewFlagSet("req", flag.ContinueOnError)
+						fset.StringVar(&method, "m", "GET", "")
+						return fset
+					}(),
+				},
+			},
+		}
+		cmd.Meta("token").Required = true
+		cmd.Commands[0].Meta("m").Choices = []string{"GET", "HEAD"}
+		return cmd
+	}
+
+	config := testConfig{"test.token": "config", "test.req.m": "HEAD"}
+	expectErrorNone(t, testCmd(config).ParseRun([]string{"req"}))
+	expectEq(t, token, "config")
+	expectEq(t, method, "HEAD")
+
+	expectErrorNone(t, testCmd(config).ParseRun([]string{"-token", "flag", "req", "-m", "GET"}))
+	expectEq(t, token, "flag")
+	expectEq(t, method, "GET")
+
+	expectErrorIs(t, testCmd(testConfig{"test.token": "x", "test.req.m": "PUT"}).ParseRun([]string{"req"}), ErrFlagChoice)
+	expectErrorIs(t, testCmd(nil).ParseRun([]string{"req"}), ErrFlagRequired)
+
+	cmd := testCmd(config)
+	cmd.Flags.String("fail", "", "")
+	err := cmd.ParseRun([]string{"req"})
+	expectErrorIs(t, err, Err)
+	expectEq(t, err.Error(), "command error: lookup failed")
+}
@@ -0,0 +1,72 @@
+package cmds
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// ErrNoConfirm is returned by [Command.Confirm] when it can't ask for
+// confirmation since it's Input isn't a terminal, wrapped by [Err].
+var ErrNoConfirm = errors.New("can't confirm without a terminal")
+
+// AddYesFlag adds the -yes and -y flags that set Yes to the flags of cmd,
+// creating them if there are none.
+func (cmd *Command) AddYesFlag() {
+	if cmd.Flags == nil {
+		cmd.Flags = flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+		cmd.Flags.Usage = cmd.DefaultUsage()
+	}
+	usage := cmd.messages().YesUsage
+	cmd.Flags.BoolVar(&cmd.Yes, "yes", cmd.Yes, usage)
+	cmd.Flags.BoolVar(&cmd.Yes, "y", cmd.Yes, usage)
+}
+
+// IsYes reports whether cmd or any of it's parents has Yes set.
+func (cmd *Command) IsYes() bool {
+	for c := cmd; c != nil; c = c.parent {
+		if c.Yes {
+			return true
+		}
+	}
+	return false
+}
+
+// Confirm asks for confirmation with prompt, like "remove all files?", and
+// reports whether "y" or "yes" was answered, for destructive commands.
+// If [Command.IsYes] it reports true without asking.
+// Otherwise it fails closed, if the Input of cmd isn't a terminal it returns
+// an error wrapping [ErrNoConfirm] that says to use -yes.
+func (cmd *Command) Confirm(prompt string) (bool, error) {
+	if cmd.IsYes() {
+		return true, nil
+	}
+	if !isTerminal(cmd.Input()) {
+		return false, fmt.Errorf("%w: %w", Err, &confirmError{fmt.Sprintf(cmd.messages().NoConfirm, prompt)})
+	}
+
+	answer, err := cmd.prompt(fmt.Sprintf(cmd.messages().Confirm, prompt), false)
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// confirmError is the error of [Command.Confirm] without a terminal, with
+// it's message from [Messages].
+type confirmError struct {
+	msg string
+}
+
+func (err *confirmError) Error() string {
+	return err.msg
+}
+
+func (err *confirmError) Unwrap() error {
+	return ErrNoConfirm
+}
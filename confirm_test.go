@@ -0,0 +1,46 @@
+package cmds
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfirm(t *testing.T) {
+	var stderr strings.Builder
+	var confirmed bool
+	cmd := &Command{
+		Name:   "test",
+		Stderr: &stderr,
+		Commands: []*Command{
+			{Name: "rm", Runner: func(cmd *Command, args []string) error {
+				var err error
+				confirmed, err = cmd.Confirm("remove everything?")
+				return err
+			}},
+		},
+	}
+	cmd.AddYesFlag()
+	expectEq(t, cmd.Flags.Lookup("y").Usage, "confirm without asking")
+
+	// Fails closed without a terminal.
+	cmd.Stdin = strings.NewReader("y\n")
+	err := cmd.ParseRun([]string{"rm"})
+	expectErrorIs(t, err, ErrNoConfirm)
+	expectEq(t, err.Error(), "command error: can't confirm \"remove everything?\" without a terminal, use -yes to confirm")
+	expectTrue(t, !confirmed)
+
+	expectErrorNone(t, cmd.ParseRun([]string{"-y", "rm"}))
+	expectTrue(t, confirmed)
+	expectTrue(t, cmd.Commands[0].IsYes())
+
+	defer func(f func(any) bool) { isTerminal = f }(isTerminal)
+	isTerminal = func(any) bool { return true }
+	cmd.Yes = false
+	for input, want := range map[string]bool{"y\n": true, "YES\n": true, "n\n": false, "\n": false, "": false} {
+		stderr.Reset()
+		cmd.Stdin = strings.NewReader(input)
+		expectErrorNone(t, cmd.ParseRun([]string{"rm"}))
+		expectEq(t, confirmed, want)
+		expectEq(t, stderr.String(), "remove everything? [y/N]: ")
+	}
+}
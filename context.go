@@ -0,0 +1,128 @@
+package cmds
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// Context returns the context passed to [Command.ParseRunContext] of cmd or
+// the closest of it's parents, or [context.Background] if there's none.
+func (cmd *Command) Context() context.Context {
+	for c := cmd; c != nil; c = c.parent {
+		if c.ctx != nil {
+			return c.ctx
+		}
+	}
+	return context.Background()
+}
+
+// Cleanup registers a function to be called after the Runner returns, for
+// releasing resources even when the Runner was cancelled by a signal with
+// [Command.ParseRunWithSignals].
+// The functions are called in the reverse order they were registered in, from
+// [Command.Run], [Command.ParseRun] and the variants of it.
+func (cmd *Command) Cleanup(f func()) {
+	root := cmd.root()
+	root.cleanups = append(root.cleanups, f)
+}
+
+// OnExit registers a function to be called once when the program is done with
+// the command, after the Runner returns and the functions registered with
+// [Command.Cleanup] are called, or before the package exits the program, in
+// [ExitOnError] or when a second signal is received by
+// [Command.ParseRunWithSignals].
+// The functions are called in the reverse order they were registered in.
+// Unlike Cleanup, they're for releasing what must be released even when the
+// program is forced to exit, like lock files, so they should be quick.
+func (cmd *Command) OnExit(f func()) {
+	root := cmd.root()
+	if root.exitHooks == nil {
+		root.exitHooks = &exitHooks{}
+	}
+	root.exitHooks.mu.Lock()
+	root.exitHooks.funcs = append(root.exitHooks.funcs, f)
+	root.exitHooks.mu.Unlock()
+}
+
+// exitHooks are the functions registered with [Command.OnExit], they can be
+// run from the goroutine that handles signals.
+type exitHooks struct {
+	mu    sync.Mutex
+	funcs []func()
+}
+
+// runExitHooks calls the functions registered with [Command.OnExit] of cmd.
+func (cmd *Command) runExitHooks() {
+	if cmd.exitHooks == nil {
+		return
+	}
+	cmd.exitHooks.mu.Lock()
+	funcs := cmd.exitHooks.funcs
+	cmd.exitHooks.funcs = nil
+	cmd.exitHooks.mu.Unlock()
+
+	for i := len(funcs) - 1; i >= 0; i-- {
+		funcs[i]()
+	}
+}
+
+func (cmd *Command) runCleanups() {
+	for len(cmd.cleanups) > 0 {
+		f := cmd.cleanups[len(cmd.cleanups)-1]
+		cmd.cleanups = cmd.cleanups[:len(cmd.cleanups)-1]
+		f()
+	}
+}
+
+// ParseRunWithSignals is like [Command.ParseRunContext] but the context is
+// cancelled when one of the signals is received, so that the Runner can stop
+// gracefully, after which the functions registered with [Command.Cleanup]
+// are called.
+// If another signal is received before the Runner returns the program exits
+// right away with code 128 plus the signal number, like shells do, and 1 for
+// signals without a number, after calling the functions registered with
+// [Command.OnExit].
+// If no signals are given [os.Interrupt] and SIGTERM are used.
+func (cmd *Command) ParseRunWithSignals(args []string, signals ...os.Signal) error {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, signals...)
+	defer signal.Stop(sigs)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-sigs:
+			cancel()
+		case <-done:
+			return
+		}
+
+		select {
+		case sig := <-sigs:
+			cmd.runExitHooks()
+			os.Exit(signalExitCode(sig))
+		case <-done:
+		}
+	}()
+
+	return cmd.ParseRunContext(ctx, args)
+}
+
+// signalExitCode returns the exit code for the program being killed by sig.
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
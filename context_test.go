@@ -0,0 +1,125 @@
+package cmds
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestContext(t *testing.T) {
+	type key struct{}
+	var got context.Context
+	cmd := &Command{
+		Name: "test",
+		Commands: []*Command{
+			{Name: "sub", Runner: func(cmd *Command, args []string) error {
+				got = cmd.Context()
+				return nil
+			}},
+		},
+	}
+
+	expectEq(t, cmd.Commands[0].Context(), context.Background())
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	expectErrorNone(t, cmd.ParseRunContext(ctx, []string{"sub"}))
+	expectEq(t, got.Value(key{}), "value")
+}
+
+func TestCleanup(t *testing.T) {
+	var calls []string
+	cmd := &Command{
+		Name: "test",
+		Commands: []*Command{
+			{Name: "sub", Runner: func(cmd *Command, args []string) error {
+				cmd.Cleanup(func() { calls = append(calls, "first") })
+				cmd.Cleanup(func() { calls = append(calls, "second") })
+				return errors.New("run error")
+			}},
+		},
+	}
+
+	expectError(t, cmd.ParseRun([]string{"sub"}))
+	expectEq(t, calls, []string{"second", "first"})
+	calls = nil
+	expectErrorIs(t, cmd.ParseRun([]string{"invalid"}), ErrUnknownCommand)
+	expectEq(t, calls, []string(nil))
+}
+
+func TestOnExit(t *testing.T) {
+	var calls []string
+	cmd := &Command{
+		Name: "test",
+		Runner: func(cmd *Command, args []string) error {
+			cmd.OnExit(func() { calls = append(calls, "exit first") })
+			cmd.OnExit(func() { calls = append(calls, "exit second") })
+			cmd.Cleanup(func() { calls = append(calls, "cleanup") })
+			return nil
+		},
+	}
+
+	expectErrorNone(t, cmd.ParseRun(nil))
+	expectEq(t, calls, []string{"cleanup", "exit second", "exit first"})
+
+	// The functions are only called once.
+	calls = nil
+	cmd.runExitHooks()
+	expectEq(t, calls, []string(nil))
+}
+
+func TestParseRunWithSignals(t *testing.T) {
+	var cleaned bool
+	cmd := &Command{
+		Name: "test",
+		Runner: func(cmd *Command, args []string) error {
+			cmd.Cleanup(func() { cleaned = true })
+			proc, err := os.FindProcess(os.Getpid())
+			if err != nil {
+				return err
+			}
+			if err := proc.Signal(os.Interrupt); err != nil {
+				t.Skipf("can't send interrupt: %v", err)
+			}
+
+			select {
+			case <-cmd.Context().Done():
+				return cmd.Context().Err()
+			case <-time.After(5 * time.Second):
+				return errors.New("context wasn't cancelled")
+			}
+		},
+	}
+
+	expectErrorIs(t, cmd.ParseRunWithSignals(nil), context.Canceled)
+	expectTrue(t, cleaned)
+}
+
+func TestTimeout(t *testing.T) {
+	wait := func(cmd *Command, args []string) error {
+		select {
+		case <-cmd.Context().Done():
+			return cmd.Context().Err()
+		case <-time.After(5 * time.Second):
+			return errors.New("context wasn't cancelled")
+		}
+	}
+	cmd := &Command{
+		Name:  "test",
+		Flags: flag.NewFlagSet("test", flag.ContinueOnError),
+		Commands: []*Command{
+			{Name: "wait", Runner: wait},
+			{Name: "quick", Runner: nopRunner},
+		},
+	}
+	cmd.Flags.DurationVar(&cmd.Timeout, "timeout", 0, "")
+
+	err := cmd.ParseRun([]string{"-timeout", "10ms", "wait"})
+	expectErrorIs(t, err, ErrTimeout)
+	expectErrorIs(t, err, context.DeadlineExceeded)
+	expectEq(t, err.Error(), "timed out after 10ms: context deadline exceeded")
+	expectEq(t, cmd.ExitCode(err), 124)
+
+	expectErrorNone(t, cmd.ParseRun([]string{"quick"}))
+}
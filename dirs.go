@@ -0,0 +1,102 @@
+package cmds
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// ConfigDir returns the directory for the config files of the program name,
+// which is in $XDG_CONFIG_HOME or ~/.config on Linux and other Unix systems,
+// %AppData% on Windows and ~/Library/Application Support on macOS.
+// The directory isn't created.
+func ConfigDir(name string) (string, error) {
+	return userDir(runtime.GOOS, os.Getenv, name, dirConfig)
+}
+
+// CacheDir returns the directory for the cache files of the program name,
+// which is in $XDG_CACHE_HOME or ~/.cache on Linux and other Unix systems,
+// %LocalAppData% on Windows and ~/Library/Caches on macOS.
+// The directory isn't created.
+func CacheDir(name string) (string, error) {
+	return userDir(runtime.GOOS, os.Getenv, name, dirCache)
+}
+
+// DataDir returns the directory for the data files of the program name,
+// which is in $XDG_DATA_HOME or ~/.local/share on Linux and other Unix
+// systems, %LocalAppData% on Windows and ~/Library/Application Support on
+// macOS.
+// The directory isn't created.
+func DataDir(name string) (string, error) {
+	return userDir(runtime.GOOS, os.Getenv, name, dirData)
+}
+
+// ConfigDir returns the [ConfigDir] for the name of the root command of cmd.
+func (cmd *Command) ConfigDir() (string, error) {
+	return ConfigDir(cmd.root().Name)
+}
+
+// CacheDir returns the [CacheDir] for the name of the root command of cmd.
+func (cmd *Command) CacheDir() (string, error) {
+	return CacheDir(cmd.root().Name)
+}
+
+// DataDir returns the [DataDir] for the name of the root command of cmd.
+func (cmd *Command) DataDir() (string, error) {
+	return DataDir(cmd.root().Name)
+}
+
+type dirKind int
+
+const (
+	dirConfig dirKind = iota
+	dirCache
+	dirData
+)
+
+// userDir returns the directory of kind for name on goos, with the
+// environment from getenv, so that every OS can be tested.
+func userDir(goos string, getenv func(string) string, name string, kind dirKind) (string, error) {
+	if name == "" {
+		return "", errors.New("empty program name")
+	}
+
+	home := func() (string, error) {
+		env := "HOME"
+		if goos == "windows" {
+			env = "USERPROFILE"
+		}
+		if dir := getenv(env); dir != "" {
+			return dir, nil
+		}
+		return "", errors.New("$" + env + " is not defined")
+	}
+
+	var base string
+	switch goos {
+	case "windows":
+		env := [...]string{"AppData", "LocalAppData", "LocalAppData"}[kind]
+		if base = getenv(env); base == "" {
+			return "", errors.New("%" + env + "% is not defined")
+		}
+	case "darwin", "ios":
+		dir, err := home()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(dir, "Library", [...]string{"Application Support", "Caches", "Application Support"}[kind])
+	default:
+		env := [...]string{"XDG_CONFIG_HOME", "XDG_CACHE_HOME", "XDG_DATA_HOME"}[kind]
+		// Relative paths are invalid according to the XDG spec.
+		if base = getenv(env); base == "" || !filepath.IsAbs(base) {
+			dir, err := home()
+			if err != nil {
+				return "", err
+			}
+			base = filepath.Join(dir, [...]string{".config", ".cache", filepath.Join(".local", "share")}[kind])
+		}
+	}
+
+	return filepath.Join(base, name), nil
+}
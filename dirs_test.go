@@ -0,0 +1,52 @@
+package cmds
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestUserDir(t *testing.T) {
+	env := map[string]string{
+		"HOME":            "/home/u",
+		"USERPROFILE":     `C:\Users\u`,
+		"AppData":         `C:\Users\u\AppData\Roaming`,
+		"LocalAppData":    `C:\Users\u\AppData\Local`,
+		"XDG_CACHE_HOME":  "/tmp/cache",
+		"XDG_CONFIG_HOME": "relative",
+	}
+	getenv := func(name string) string {
+		return env[name]
+	}
+
+	for _, test := range []struct {
+		goos string
+		kind dirKind
+		want string
+	}{
+		{"linux", dirConfig, filepath.Join("/home/u", ".config", "tool")},
+		{"linux", dirCache, filepath.Join("/tmp/cache", "tool")},
+		{"freebsd", dirData, filepath.Join("/home/u", ".local", "share", "tool")},
+		{"darwin", dirConfig, filepath.Join("/home/u", "Library", "Application Support", "tool")},
+		{"darwin", dirCache, filepath.Join("/home/u", "Library", "Caches", "tool")},
+		{"windows", dirConfig, filepath.Join(`C:\Users\u\AppData\Roaming`, "tool")},
+		{"windows", dirData, filepath.Join(`C:\Users\u\AppData\Local`, "tool")},
+	} {
+		got, err := userDir(test.goos, getenv, "tool", test.kind)
+		expectErrorNone(t, err)
+		expectEq(t, got, test.want)
+	}
+
+	delete(env, "HOME")
+	_, err := userDir("linux", getenv, "tool", dirConfig)
+	expectError(t, err)
+	_, err = userDir("linux", getenv, "", dirCache)
+	expectError(t, err)
+
+	if runtime.GOOS == "linux" {
+		t.Setenv("XDG_CONFIG_HOME", "/config")
+		dir, err := (&Command{Name: "tool"}).ConfigDir()
+		expectErrorNone(t, err)
+		expectEq(t, dir, "/config/tool")
+	}
+}
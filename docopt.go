@@ -0,0 +1,324 @@
+package cmds
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// FromDocopt returns a command tree made from doc, a usage message in the
+// style of docopt (http://docopt.org), with run as the Runner of the commands
+// in the usage patterns, for prototypes and one-off scripts.
+// It's experimental and only supports a subset of docopt:
+//
+//	Naval Fate.
+//
+//	Usage:
+//	  naval-fate ship new <name>...
+//	  naval-fate ship move <x> <y> [--speed=<kn>]
+//	  naval-fate mine (set|remove) <x> <y> [--moored | --drifting]
+//	  naval-fate -h | --help
+//
+//	Options:
+//	  -h --help     Show this screen.
+//	  --speed=<kn>  Speed in knots [default: 10].
+//	  --moored      Moored (anchored) mine.
+//	  --drifting    Drifting mine.
+//
+// The words after the program name in a pattern are the path of the command,
+// alternatives like "(set|remove)" make a command for each of them, and the
+// positional arguments after them, like "<x>" or "X", optionally in brackets
+// and repeated with "...", are what it's Args accepts.
+// The options that a pattern mentions, or all of them for "[options]", are
+// flags of it's command named after the first long name of the option, with
+// the other names defined for the same value, the ones that no pattern
+// mentions are flags of the root command.
+// An option with an argument like "<kn>" is a string flag with it's
+// "[default: ...]" as the default, the other ones are bool flags.
+// The -h and --help options are ignored, since help is built in.
+// The text before "Usage:" is the LongDesc of the root command.
+func FromDocopt(doc string, run RunnerFunc) (*Command, error) {
+	patterns, desc, err := docoptUsage(doc)
+	if err != nil {
+		return nil, err
+	}
+	options := docoptOptions(doc)
+
+	root := &Command{LongDesc: desc}
+	used := make(map[*docoptOption]bool)
+	for _, pattern := range patterns {
+		for _, tokens := range docoptExpand(docoptTokens(pattern)) {
+			if root.Name == "" {
+				root.Name = tokens[0]
+				root.Flags = flag.NewFlagSet(root.Name, flag.ContinueOnError)
+				root.Flags.Usage = root.DefaultUsage()
+			}
+			if err := root.docoptPattern(tokens[1:], options, used, run); err != nil {
+				return nil, fmt.Errorf("docopt: %s: %w", strings.TrimSpace(pattern), err)
+			}
+		}
+	}
+
+	for _, opt := range options {
+		if !used[opt] {
+			opt.define(root.Flags)
+		}
+	}
+
+	return root, nil
+}
+
+// docoptOption is an option in the options section of a docopt usage message.
+type docoptOption struct {
+	names []string
+	value bool
+	def   string
+	usage string
+}
+
+// define defines the flags of opt in fset, if they aren't defined already.
+// The names after the first one are defined with the same Value, so that they
+// stay aliases in the copies of [Command.ParseArgs].
+func (opt *docoptOption) define(fset *flag.FlagSet) {
+	if fset.Lookup(opt.names[0]) != nil {
+		return
+	}
+	if opt.value {
+		fset.String(opt.names[0], opt.def, opt.usage)
+	} else {
+		fset.Bool(opt.names[0], false, opt.usage)
+	}
+	value := fset.Lookup(opt.names[0]).Value
+	for _, name := range opt.names[1:] {
+		fset.Var(value, name, opt.usage)
+	}
+}
+
+// docoptUsage returns the patterns in the "Usage:" section of doc and the text
+// before it.
+func docoptUsage(doc string) (patterns []string, desc string, err error) {
+	lines := strings.Split(doc, "\n")
+	start := -1
+	for i, line := range lines {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(line)), "usage:") {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return nil, "", errors.New(`docopt: no "Usage:" section`)
+	}
+
+	desc = strings.TrimSpace(strings.Join(lines[:start], "\n"))
+	first := strings.TrimSpace(lines[start])[len("usage:"):]
+	for _, line := range append([]string{first}, lines[start+1:]...) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if len(patterns) > 0 {
+				break
+			}
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	if len(patterns) == 0 {
+		return nil, "", errors.New("docopt: no usage patterns")
+	}
+	return patterns, desc, nil
+}
+
+var docoptDefault = regexp.MustCompile(`(?i)\s*\[default:\s*([^\]]*)\]`)
+
+// docoptOptions returns the options described in doc, on the lines that
+// start with "-".
+func docoptOptions(doc string) []*docoptOption {
+	var options []*docoptOption
+	for _, line := range strings.Split(doc, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "-") {
+			continue
+		}
+
+		spec, usage, _ := strings.Cut(line, "  ")
+		opt := &docoptOption{usage: strings.TrimSpace(usage)}
+		if m := docoptDefault.FindStringSubmatch(opt.usage); m != nil {
+			opt.def = strings.TrimSpace(m[1])
+			opt.usage = strings.TrimSpace(docoptDefault.ReplaceAllString(opt.usage, ""))
+		}
+
+		var long []string
+		for _, token := range strings.FieldsFunc(spec, func(r rune) bool { return r == ' ' || r == ',' }) {
+			if !strings.HasPrefix(token, "-") {
+				opt.value = true
+				continue
+			}
+			name, _, value := strings.Cut(strings.TrimLeft(token, "-"), "=")
+			opt.value = opt.value || value
+			if strings.HasPrefix(token, "--") {
+				long = append(long, name)
+			} else {
+				opt.names = append(opt.names, name)
+			}
+		}
+		opt.names = append(long, opt.names...)
+		if len(opt.names) == 0 || isHelpOption(opt.names[0]) {
+			continue
+		}
+		options = append(options, opt)
+	}
+	return options
+}
+
+func isHelpOption(name string) bool {
+	return name == "h" || name == "help"
+}
+
+// docoptTokens splits pattern into it's words, the characters ()[]| and
+// "...".
+func docoptTokens(pattern string) []string {
+	for _, c := range []string{"(", ")", "[", "]", "|", "..."} {
+		pattern = strings.ReplaceAll(pattern, c, " "+c+" ")
+	}
+	return strings.Fields(pattern)
+}
+
+// docoptExpand returns the alternatives of tokens for the first group of
+// alternatives in parentheses, like "(set|remove)", recursively.
+func docoptExpand(tokens []string) [][]string {
+	start := -1
+	for i, token := range tokens {
+		if token == "(" {
+			start = i
+		}
+		if token == ")" && start >= 0 {
+			var alts [][]string
+			alt := []string{}
+			for _, t := range tokens[start+1 : i] {
+				if t == "|" {
+					alts = append(alts, alt)
+					alt = []string{}
+					continue
+				}
+				alt = append(alt, t)
+			}
+			alts = append(alts, alt)
+
+			var expanded [][]string
+			for _, alt := range alts {
+				t := append(append(append([]string(nil), tokens[:start]...), alt...), tokens[i+1:]...)
+				expanded = append(expanded, docoptExpand(t)...)
+			}
+			return expanded
+		}
+	}
+	return [][]string{tokens}
+}
+
+func docoptPositional(token string) bool {
+	return strings.HasPrefix(token, "<") || (token == strings.ToUpper(token) && strings.ToLower(token) != token)
+}
+
+// docoptPattern adds the command of the pattern tokens, without the program
+// name, to cmd.
+func (cmd *Command) docoptPattern(tokens []string, options []*docoptOption, used map[*docoptOption]bool, run RunnerFunc) error {
+	// Like "-h | --help".
+	onlyHelp := true
+	for _, token := range tokens {
+		if name := strings.TrimLeft(token, "-"); token != "|" && (name == token || !isHelpOption(name)) {
+			onlyHelp = false
+		}
+	}
+	if onlyHelp {
+		return nil
+	}
+
+	leaf := cmd
+	i := 0
+	for ; i < len(tokens); i++ {
+		token := tokens[i]
+		if strings.HasPrefix(token, "-") || strings.ContainsAny(token, "[]|") || token == "..." || docoptPositional(token) {
+			break
+		}
+		sub := leaf.Find(token)
+		if sub == nil {
+			sub = &Command{Name: token, Flags: flag.NewFlagSet(token, flag.ContinueOnError)}
+			sub.Flags.Usage = sub.DefaultUsage()
+			leaf.Commands = append(leaf.Commands, sub)
+		}
+		leaf = sub
+	}
+
+	var args []string
+	var lastArg string
+	min, max, depth := 0, 0, 0
+	for ; i < len(tokens); i++ {
+		token := tokens[i]
+		switch {
+		case token == "[":
+			depth++
+		case token == "]":
+			depth--
+		case token == "|":
+		case token == "...":
+			max = -1
+			lastArg += "..."
+		case token == "options" && depth > 0:
+			for _, opt := range options {
+				used[opt] = true
+				opt.define(leaf.Flags)
+			}
+		case strings.HasPrefix(token, "-"):
+			name, _, value := strings.Cut(strings.TrimLeft(token, "-"), "=")
+			if isHelpOption(name) {
+				continue
+			}
+			opt := findDocoptOption(options, name)
+			if opt == nil {
+				opt = &docoptOption{names: []string{name}, value: value}
+			}
+			used[opt] = true
+			opt.define(leaf.Flags)
+		case docoptPositional(token):
+			if lastArg != "" {
+				args = append(args, lastArg)
+			}
+			lastArg = token
+			if depth > 0 {
+				lastArg = "[" + token + "]"
+			} else {
+				min++
+			}
+			if max >= 0 {
+				max++
+			}
+		default:
+			return fmt.Errorf("unsupported word \"%s\" after positional arguments or options", token)
+		}
+	}
+	if lastArg != "" {
+		args = append(args, lastArg)
+	}
+
+	// Optional repeated arguments are written inside the brackets.
+	argsUsage := strings.ReplaceAll(strings.Join(args, " "), "]...", "...]")
+	if leaf.Runner != nil && leaf.ArgsUsage != argsUsage {
+		return fmt.Errorf("command \"%s\" has more than one pattern with different arguments", leaf.Name)
+	}
+	leaf.Runner = run
+	leaf.ArgsUsage = argsUsage
+	leaf.Args = RangeArgs(min, max)
+	return nil
+}
+
+func findDocoptOption(options []*docoptOption, name string) *docoptOption {
+	for _, opt := range options {
+		for _, n := range opt.names {
+			if n == name {
+				return opt
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package cmds
+
+import (
+	"strings"
+	"testing"
+)
+
+const testNavalFate = `Naval Fate.
+
+Usage:
+  naval-fate ship new <name>...
+  naval-fate ship move <x> <y> [--speed=<kn>]
+  naval-fate mine (set|remove) <x> <y> [--moored | --drifting]
+  naval-fate -h | --help
+
+Options:
+  -h --help     Show this screen.
+  --speed=<kn>  Speed in knots [default: 10].
+  --moored      Moored (anchored) mine.
+  --drifting    Drifting mine.
+  -v, --verbose  Verbose output.
+`
+
+func TestFromDocopt(t *testing.T) {
+	var got []string
+	cmd, err := FromDocopt(testNavalFate, func(cmd *Command, args []string) error {
+		got = append(cmd.Path(), args...)
+		return nil
+	})
+	expectErrorNone(t, err)
+	expectEq(t, cmd.Name, "naval-fate")
+	expectEq(t, cmd.Short(), "Naval Fate")
+	expectTrue(t, cmd.Runner == nil)
+
+	expectErrorNone(t, cmd.ParseRun([]string{"ship", "new", "a", "b"}))
+	expectEq(t, got, []string{"naval-fate", "ship", "new", "a", "b"})
+	expectErrorIs(t, cmd.ParseRun([]string{"ship", "new"}), ErrArgs)
+
+	move := cmd.Find("ship").Find("move")
+	expectErrorNone(t, cmd.ParseRun([]string{"ship", "move", "--speed=20", "1", "2"}))
+	expectEq(t, move.Synopsis(), "naval-fate ship move [-speed string] <x> <y>")
+	expectEq(t, move.Flags.Lookup("speed").Value.String(), "20")
+	expectEq(t, move.Flags.Lookup("speed").DefValue, "10")
+	expectEq(t, move.Flags.Lookup("speed").Usage, "Speed in knots.")
+
+	expectErrorNone(t, cmd.ParseRun([]string{"mine", "remove", "-moored", "1", "2"}))
+	expectEq(t, got, []string{"naval-fate", "mine", "remove", "1", "2"})
+	expectTrue(t, cmd.Find("mine").Find("set") != nil)
+
+	// The options that no pattern mentions are flags of the root command,
+	// with every name.
+	expectErrorNone(t, cmd.ParseRun([]string{"-v", "mine", "set", "1", "2"}))
+	expectEq(t, cmd.Flags.Lookup("verbose").Value.String(), "true")
+	expectTrue(t, cmd.Flags.Lookup("h") == nil)
+
+	// The names stay aliases in the copies of ParseArgs.
+	cmd.Reset()
+	r, err := cmd.ParseArgs([]string{"-v", "mine", "set", "1", "2"})
+	expectErrorNone(t, err)
+	root := r.Command.Parent().Parent()
+	expectEq(t, root.Flags.Lookup("v").Value.String(), "true")
+	expectEq(t, root.Flags.Lookup("verbose").Value.String(), "true")
+	expectEq(t, cmd.Flags.Lookup("verbose").Value.String(), "false")
+
+	for _, doc := range []string{
+		"no usage",
+		"Usage:\n",
+		"Usage: tool <x> extra",
+	} {
+		_, err := FromDocopt(doc, nopRunner)
+		if err == nil || !strings.HasPrefix(err.Error(), "docopt: ") {
+			t.Errorf("expected a docopt error for %q, got %v", doc, err)
+		}
+	}
+
+	cmd, err = FromDocopt("Usage: cp [options] <src> [<dst>]...\n\n  -r  Recursive.", nopRunner)
+	expectErrorNone(t, err)
+	expectEq(t, cmd.ArgsUsage, "<src> [<dst>...]")
+	expectErrorNone(t, cmd.ParseRun([]string{"-r", "a", "b", "c"}))
+	expectEq(t, cmd.Flags.Lookup("r").Value.String(), "true")
+}
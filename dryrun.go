@@ -0,0 +1,37 @@
+package cmds
+
+import (
+	"flag"
+	"fmt"
+)
+
+// AddDryRunFlag adds the -dry-run flag that sets DryRun to the flags of cmd,
+// creating them if there are none.
+func (cmd *Command) AddDryRunFlag() {
+	if cmd.Flags == nil {
+		cmd.Flags = flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+		cmd.Flags.Usage = cmd.DefaultUsage()
+	}
+	cmd.Flags.BoolVar(&cmd.DryRun, "dry-run", cmd.DryRun, cmd.messages().DryRunUsage)
+}
+
+// IsDryRun reports whether cmd or any of it's parents has DryRun set.
+func (cmd *Command) IsDryRun() bool {
+	for c := cmd; c != nil; c = c.parent {
+		if c.DryRun {
+			return true
+		}
+	}
+	return false
+}
+
+// Do calls fn and returns it's error unless [Command.IsDryRun], in which case
+// it outputs the description of what fn would do to [Command.Output] instead,
+// desc should be like "remove file.txt".
+func (cmd *Command) Do(desc string, fn func() error) error {
+	if cmd.IsDryRun() {
+		fmt.Fprintf(cmd.Output(), cmd.messages().DryRun+"\n", desc)
+		return nil
+	}
+	return fn()
+}
@@ -0,0 +1,42 @@
+package cmds
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	var b strings.Builder
+	var done []string
+	cmd := &Command{
+		Name:   "test",
+		Stdout: &b,
+		Commands: []*Command{
+			{Name: "rm", Runner: func(cmd *Command, args []string) error {
+				for _, arg := range args {
+					err := cmd.Do("remove "+arg, func() error {
+						done = append(done, arg)
+						return nil
+					})
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			}},
+		},
+	}
+	cmd.AddDryRunFlag()
+	expectEq(t, cmd.Flags.Lookup("dry-run").Usage, "output what would be done without doing it")
+
+	expectErrorNone(t, cmd.ParseRun([]string{"--dry-run", "rm", "a", "b"}))
+	expectEq(t, b.String(), "dry run: remove a\ndry run: remove b\n")
+	expectEq(t, done, []string(nil))
+	expectTrue(t, cmd.Commands[0].IsDryRun())
+
+	b.Reset()
+	cmd.DryRun = false
+	expectErrorNone(t, cmd.ParseRun([]string{"rm", "a"}))
+	expectEq(t, b.String(), "")
+	expectEq(t, done, []string{"a"})
+}
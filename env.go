@@ -0,0 +1,160 @@
+package cmds
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// LoadEnv loads the variables in the dotenv files at paths into the
+// environment of the process, without changing the variables that are already
+// set, so the real environment takes precedence over the files and earlier
+// files over later ones.
+// Files that don't exist are skipped.
+//
+// The files have a variable per line, like "NAME=value" or
+// "export NAME=value", lines starting with # are comments.
+// Values can be quoted with single quotes, which are taken literally, or
+// double quotes, in which \n, \", \\ and other escapes are interpreted, the
+// others are trimmed and end at a # preceded by a space.
+func LoadEnv(paths ...string) error {
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		vars, err := parseEnv(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s:%w", path, err)
+		}
+
+		for _, v := range vars {
+			if _, ok := os.LookupEnv(v[0]); !ok {
+				os.Setenv(v[0], v[1])
+			}
+		}
+	}
+	return nil
+}
+
+// parseEnv returns the names and values of the variables in the dotenv file
+// r in the order they're in, errors start with the line number.
+func parseEnv(r io.Reader) ([][2]string, error) {
+	var vars [][2]string
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("%d: invalid line \"%s\"", n, line)
+		}
+
+		value, err := parseEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%d: %w", n, err)
+		}
+		vars = append(vars, [2]string{name, value})
+	}
+	return vars, sc.Err()
+}
+
+func parseEnvValue(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+
+	switch s[0] {
+	case '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", errors.New("unterminated single quote")
+		}
+		return s[1 : end+1], nil
+
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(s); i++ {
+			switch c := s[i]; c {
+			case '"':
+				return b.String(), nil
+			case '\\':
+				i++
+				if i == len(s) {
+					break
+				}
+				switch s[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				case 'r':
+					b.WriteByte('\r')
+				default:
+					b.WriteByte(s[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", errors.New("unterminated double quote")
+	}
+
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s), nil
+}
+
+// expandEnvArgs returns args with the references to environment variables in
+// them expanded as described in ExpandEnv.
+func expandEnvArgs(args []string) []string {
+	expanded := make([]string, len(args))
+	for i, arg := range args {
+		expanded[i] = expandEnvArg(arg)
+	}
+	return expanded
+}
+
+func expandEnvArg(s string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			break
+		}
+		if i > 0 && s[i-1] == '$' {
+			b.WriteString(s[:i])
+			b.WriteString("{")
+			s = s[i+2:]
+			continue
+		}
+		end := strings.IndexByte(s[i+2:], '}')
+		if end < 0 {
+			break
+		}
+		b.WriteString(s[:i])
+		b.WriteString(os.Getenv(s[i+2 : i+2+end]))
+		s = s[i+2+end+1:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
@@ -0,0 +1,89 @@
+package cmds
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadEnv(t *testing.T) {
+	dir := t.TempDir()
+	env := filepath.Join(dir, ".env")
+	local := filepath.Join(dir, ".env.local")
+	expectErrorNone(t, os.WriteFile(env, []byte(`# comment
+CMDS_TEST_A=a
+export CMDS_TEST_B = "b\n\"c\"" 
+CMDS_TEST_C='single # "quoted"'
+CMDS_TEST_D=value # comment
+CMDS_TEST_E=
+CMDS_TEST_REAL=file
+`), 0o644))
+	expectErrorNone(t, os.WriteFile(local, []byte("CMDS_TEST_A=local\nCMDS_TEST_F=f\n"), 0o644))
+
+	t.Setenv("CMDS_TEST_REAL", "real")
+	for _, name := range []string{"A", "B", "C", "D", "E", "F"} {
+		t.Setenv("CMDS_TEST_"+name, "")
+		os.Unsetenv("CMDS_TEST_" + name)
+	}
+
+	var got []string
+	cmd := &Command{
+		Name:     "test",
+		EnvFiles: []string{env, local, filepath.Join(dir, "missing")},
+		Runner: func(cmd *Command, args []string) error {
+			for _, name := range []string{"A", "B", "C", "D", "E", "F", "REAL"} {
+				got = append(got, os.Getenv("CMDS_TEST_"+name))
+			}
+			return nil
+		},
+	}
+	expectErrorNone(t, cmd.ParseRun(nil))
+	expectEq(t, got, []string{"a", "b\n\"c\"", "single # \"quoted\"", "value", "", "f", "real"})
+
+	for _, content := range []string{"invalid", "A='unterminated", "A=\"unterminated\\\"", "A B=c"} {
+		expectErrorNone(t, os.WriteFile(env, []byte(content), 0o644))
+		err := cmd.ParseRun(nil)
+		expectErrorIs(t, err, Err)
+		expectTrue(t, strings.HasPrefix(err.Error(), "command error: "+env+":1: "))
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("CMDS_TEST_HOST", "example.com")
+	t.Setenv("CMDS_TEST_EMPTY", "")
+
+	expectEq(t, expandEnvArgs([]string{
+		"-host=${CMDS_TEST_HOST}",
+		"https://${CMDS_TEST_HOST}/${CMDS_TEST_EMPTY}${CMDS_TEST_UNSET}path",
+		"$${CMDS_TEST_HOST}",
+		"$CMDS_TEST_HOST",
+		"${CMDS_TEST_HOST",
+	}), []string{
+		"-host=example.com",
+		"https://example.com/path",
+		"${CMDS_TEST_HOST}",
+		"$CMDS_TEST_HOST",
+		"${CMDS_TEST_HOST",
+	})
+
+	var host string
+	var got []string
+	cmd := &Command{
+		Name:      "test",
+		ExpandEnv: true,
+		Flags: func() *flag.FlagSet {
+			fset := flag.NewFlagSet("test", flag.ContinueOnError)
+			fset.StringVar(&host, "host", "", "")
+			return fset
+		}(),
+		Runner: func(cmd *Command, args []string) error {
+			got = args
+			return nil
+		},
+	}
+	expectErrorNone(t, cmd.ParseRun([]string{"-host", "${CMDS_TEST_HOST}", "${CMDS_TEST_HOST}"}))
+	expectEq(t, host, "example.com")
+	expectEq(t, got, []string{"example.com"})
+}
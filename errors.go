@@ -0,0 +1,467 @@
+package cmds
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rgzlv/cmds/term"
+)
+
+// Err is the most generic error and is used to wrap all the errors returned
+// by this package, like [ErrCmd] and [ErrFlag] but not the ones returned by a
+// call to [RunnerFunc].
+var Err = errors.New("command error")
+
+// ErrCmd indicates an error while parsing commands.
+var ErrCmd = errors.New("command parse error")
+
+// ErrFlag indicates an error while parsing flags using [flag].
+var ErrFlag = errors.New("flag parse error")
+
+// ErrHelp is returned by [Command.Parse] when the -h or -help flag is given
+// but not defined, after the usage message was output.
+// It's not an error so it isn't wrapped by [Err] or passed to HandleError and
+// [ExitOnError] exits with code 0 for it.
+// It wraps [flag.ErrHelp].
+var ErrHelp = fmt.Errorf("%w", flag.ErrHelp)
+
+// ErrTimeout wraps the error returned by a Runner that ran for longer than
+// it's Timeout, [ExitOnError] exits with code 124 for it, like timeout(1).
+var ErrTimeout = errors.New("timed out")
+
+// ErrMissingCommand, ErrUnknownCommand, ErrHelpTopic, ErrNilRunner and ErrArgs
+// are the reasons for a [CommandError].
+var (
+	ErrMissingCommand = errors.New("missing command")
+	ErrUnknownCommand = errors.New("no such command")
+	ErrHelpTopic      = errors.New("is a help topic")
+	ErrNilRunner      = errors.New("nil runner")
+	ErrArgs           = errors.New("invalid arguments")
+)
+
+// ErrFlagRequired and ErrFlagChoice are the reasons for a [FlagError] that's
+// about a flag that parsed fine but is invalid according to it's [FlagMeta].
+var (
+	ErrFlagRequired = errors.New("missing required flag")
+	ErrFlagChoice   = errors.New("invalid choice")
+)
+
+// CommandError is the error returned when parsing commands fails, it's always
+// wrapped by [ErrCmd].
+type CommandError struct {
+	// Path is the names of the commands from the root to the one that the
+	// error is about, for an unknown command that's it's parent.
+	Path []string
+
+	// Arg is the argument that caused the error, like the name of the unknown
+	// command, it's empty if there's none.
+	Arg string
+
+	// Err is the reason for the error, like [ErrUnknownCommand].
+	Err error
+
+	// Suggestions are the names of the sub-commands that are similar to an
+	// unknown command, closest first, they're in the message too.
+	Suggestions []string
+
+	msg string
+}
+
+func newCommandError(cmd *Command, arg string, err error, msg string) error {
+	return fmt.Errorf("%w: %w", ErrCmd, &CommandError{
+		Path: cmd.Path(),
+		Arg:  arg,
+		Err:  err,
+		msg:  msg,
+	})
+}
+
+func (err *CommandError) Error() string {
+	if err.msg != "" {
+		return err.msg
+	}
+	if err.Arg != "" {
+		return fmt.Sprintf("%v \"%s\"", err.Err, err.Arg)
+	}
+	return err.Err.Error()
+}
+
+func (err *CommandError) Unwrap() error {
+	return err.Err
+}
+
+// FlagError is the error returned when parsing flags fails, it's always
+// wrapped by [ErrFlag].
+type FlagError struct {
+	// Path is the names of the commands from the root to the one whose flags
+	// failed to parse.
+	Path []string
+
+	// Flag is the name of the flag that couldn't be parsed or was undefined,
+	// if it could be determined from Err.
+	Flag string
+
+	// Err is the error returned by [flag.FlagSet.Parse], or the reason the
+	// flag is invalid, like [ErrFlagRequired].
+	Err error
+
+	msg string
+}
+
+func newFlagError(cmd *Command, err error) error {
+	return fmt.Errorf("%w: %w", ErrFlag, &FlagError{
+		Path: cmd.Path(),
+		Flag: flagErrorName(err),
+		Err:  err,
+	})
+}
+
+func newFlagErrorReason(cmd *Command, name string, reason error, msg string) error {
+	return fmt.Errorf("%w: %w", ErrFlag, &FlagError{
+		Path: cmd.Path(),
+		Flag: name,
+		Err:  reason,
+		msg:  msg,
+	})
+}
+
+func (err *FlagError) Error() string {
+	if err.msg != "" {
+		return err.msg
+	}
+	return err.Err.Error()
+}
+
+func (err *FlagError) Unwrap() error {
+	return err.Err
+}
+
+// PanicError is the error that a panic in a Runner is turned into when
+// RecoverPanics is set.
+type PanicError struct {
+	// Value is the value that was passed to panic.
+	Value any
+
+	// Stack is the stack trace of the goroutine at the time of the panic, as
+	// returned by [runtime/debug.Stack].
+	Stack []byte
+}
+
+func (err *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", err.Value)
+}
+
+// Unwrap returns Value if it's an error.
+func (err *PanicError) Unwrap() error {
+	if e, ok := err.Value.(error); ok {
+		return e
+	}
+	return nil
+}
+
+// flagErrorName returns the name of the flag that an error returned by
+// [flag.FlagSet.Parse] is about, the flag package doesn't expose it any other
+// way than in the error message.
+func flagErrorName(err error) string {
+	msg := err.Error()
+	for _, prefix := range []string{
+		"flag provided but not defined: -",
+		"flag needs an argument: -",
+	} {
+		if strings.HasPrefix(msg, prefix) {
+			return strings.TrimLeft(strings.TrimPrefix(msg, prefix), "-")
+		}
+	}
+
+	// Like "invalid value "x" for flag -name: ...".
+	if i := strings.Index(msg, " flag -"); i >= 0 {
+		name := msg[i+len(" flag -"):]
+		if j := strings.IndexByte(name, ':'); j >= 0 {
+			return name[:j]
+		}
+	}
+
+	return ""
+}
+
+// ErrorHandling defines how [Command.Parse] behaves if parsing fails.
+// This only affects errors detected in [Command.Parse] itself and whatever
+// non-exported functions that it might call in this package so flag parsing
+// errors that occur as a result of calling [flag.FlagSet.Parse] still use the
+// error handling associated with that [flag.FlagSet].
+//
+//go:generate stringer -type ErrorHandling
+type ErrorHandling int
+
+const (
+	ReturnOnError ErrorHandling = iota
+
+	// When the error is wrapped by ErrCmd, call os.Exit(3), if it's wrapped by
+	// ErrFlag, call os.Exit(2), same as the flag package, if it's wrapped by
+	// ErrTimeout, call os.Exit(124), otherwise, if the Runner returned the
+	// error, call os.Exit(1).
+	// These can be changed with ExitCoder errors and Command.SetExitCode.
+	ExitOnError
+
+	PanicOnError
+)
+
+// ExitCodeStyle defines the default exit codes that [ExitOnError] exits with.
+type ExitCodeStyle int
+
+const (
+	// ExitCodesDefault exits with the codes described in ExitOnError.
+	ExitCodesDefault ExitCodeStyle = iota
+
+	// ExitCodesSysexits exits with the codes of the BSD sysexits.h, which
+	// some packaging guidelines expect: EX_USAGE (64) for errors wrapped by
+	// ErrCmd or ErrFlag, EX_SOFTWARE (70) for a PanicError and 1 for other
+	// errors returned by the Runner, EX_TEMPFAIL (75) for ErrTimeout.
+	ExitCodesSysexits
+)
+
+// The sysexits.h exit codes used by ExitCodesSysexits.
+const (
+	exUsage    = 64
+	exSoftware = 70
+	exTempFail = 75
+)
+
+// ExitCoder is implemented by errors that know what exit code the program
+// should exit with under [ExitOnError].
+type ExitCoder interface {
+	error
+	ExitCode() int
+}
+
+type exitCode struct {
+	err  error
+	code int
+}
+
+// SetExitCode makes [ExitOnError] exit with code for errors that match err
+// according to [errors.Is], instead of the default exit codes.
+// The codes are checked in the order they were set in, setting the code for
+// the same err again replaces it.
+func (cmd *Command) SetExitCode(err error, code int) {
+	for i := range cmd.exitCodes {
+		if cmd.exitCodes[i].err == err {
+			cmd.exitCodes[i].code = code
+			return
+		}
+	}
+	cmd.exitCodes = append(cmd.exitCodes, exitCode{err: err, code: code})
+}
+
+// ExitCode returns the exit code that [ExitOnError] exits with for err.
+// If err or an error it wraps is an [ExitCoder], that error's code is used,
+// otherwise the code set with [Command.SetExitCode] for the first error that
+// err matches, or finally the default exit codes of the command's
+// ExitCodeStyle and 0 for [ErrHelp].
+func (cmd *Command) ExitCode(err error) int {
+	var coder ExitCoder
+	if errors.As(err, &coder) {
+		return coder.ExitCode()
+	}
+
+	for _, ec := range cmd.exitCodes {
+		if errors.Is(err, ec.err) {
+			return ec.code
+		}
+	}
+
+	if errors.Is(err, ErrHelp) {
+		return 0
+	}
+	if cmd.ExitCodeStyle == ExitCodesSysexits {
+		var panicErr *PanicError
+		switch {
+		case errors.Is(err, ErrCmd) || errors.Is(err, ErrFlag):
+			return exUsage
+		case errors.As(err, &panicErr):
+			return exSoftware
+		case errors.Is(err, ErrTimeout):
+			return exTempFail
+		}
+		return 1
+	}
+	if errors.Is(err, ErrCmd) {
+		return 3
+	}
+	if errors.Is(err, ErrFlag) {
+		return 2
+	}
+	if errors.Is(err, ErrTimeout) {
+		return 124
+	}
+	return 1
+}
+
+// SetErrOutput sets the writer that the error messages under [ExitOnError]
+// and the usage messages for UsageOnError are output to, instead of
+// [os.Stderr] and the output of the command's FlagSet.
+// It's used for cmd and it's sub-commands unless they set their own.
+func (cmd *Command) SetErrOutput(w io.Writer) {
+	cmd.errOutput = w
+}
+
+// ErrOutput returns the writer set with [Command.SetErrOutput] or the Stderr
+// of cmd or the closest of it's parents that has either, or [os.Stderr] if
+// none has.
+func (cmd *Command) ErrOutput() io.Writer {
+	if w := cmd.errOutputSet(); w != nil {
+		return w
+	}
+	return os.Stderr
+}
+
+func (cmd *Command) errOutputSet() io.Writer {
+	for c := cmd; c != nil; c = c.parent {
+		if c.errOutput != nil {
+			return c.errOutput
+		}
+		if c.Stderr != nil {
+			return c.Stderr
+		}
+	}
+	return nil
+}
+
+// DefaultFormatError returns err formatted like "tool: error: message" with
+// the name of the root command of cmd, the way it's output under
+// [ExitOnError] unless the command has FormatError set.
+// The message is the message of err without the generic prefixes of [Err],
+// [ErrCmd] and [ErrFlag], "error:" is red if the [Command.ErrOutput] of cmd
+// supports colors according to [term.Color].
+// Errors joined with [errors.Join], like the ones of multiple invalid flags,
+// are formatted on a line each.
+func DefaultFormatError(cmd *Command, err error) string {
+	label := "error:"
+	if term.Color(cmd.ErrOutput()) {
+		label = "\x1b[31m" + label + "\x1b[0m"
+	}
+	if name := cmd.root().Name; name != "" {
+		label = name + ": " + label
+	}
+
+	errs := splitErrors(err)
+	lines := make([]string, len(errs))
+	for i, err := range errs {
+		msg := err.Error()
+		for _, prefix := range []error{Err, ErrCmd, ErrFlag} {
+			msg = strings.TrimPrefix(msg, prefix.Error()+": ")
+		}
+		lines[i] = label + " " + msg
+	}
+	return strings.Join(lines, "\n")
+}
+
+// splitErrors returns the errors that err joins, with [errors.Join] and
+// wrapped in the generic prefixes of [Err], [ErrCmd] and [ErrFlag], or err
+// itself if it doesn't join any.
+func splitErrors(err error) []error {
+	u, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+	errs := u.Unwrap()
+
+	// Wrapped like fmt.Errorf("%w: %w", Err, err).
+	if len(errs) == 2 && (errs[0] == Err || errs[0] == ErrCmd || errs[0] == ErrFlag) {
+		if split := splitErrors(errs[1]); len(split) > 1 {
+			return split
+		}
+		return []error{err}
+	}
+
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	if err.Error() != strings.Join(msgs, "\n") {
+		return []error{err}
+	}
+	var split []error
+	for _, e := range errs {
+		split = append(split, splitErrors(e)...)
+	}
+	return split
+}
+
+func (cmd *Command) handleError(err error) error {
+	return cmd.handleErrorAt(nil, err)
+}
+
+// handleErrorAt handles err which is about the command at, which can be nil.
+func (cmd *Command) handleErrorAt(at *Command, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, ErrHelp) {
+		if cmd.ErrorHandling == ExitOnError {
+			os.Exit(cmd.ExitCode(err))
+		}
+		return err
+	}
+
+	if errors.Is(err, ErrCmd) || errors.Is(err, ErrFlag) {
+		err = fmt.Errorf("%w: %w", Err, err)
+	}
+
+	if cmd.OnError != nil {
+		if at != nil {
+			cmd.OnError(at, err)
+		} else {
+			cmd.OnError(cmd, err)
+		}
+	}
+
+	if cmd.HandleError != nil {
+		if err = cmd.HandleError(err); err == nil {
+			return nil
+		}
+	}
+
+	errOutput := cmd.errOutputSet()
+	usage := func() {
+		if !cmd.UsageOnError || at == nil || at.Flags == nil || at.Flags.Usage == nil || !errors.Is(err, ErrCmd) {
+			return
+		}
+		if errOutput != nil {
+			out := at.Flags.Output()
+			at.Flags.SetOutput(errOutput)
+			defer at.Flags.SetOutput(out)
+		}
+		at.Flags.Usage()
+	}
+
+	switch cmd.ErrorHandling {
+	case ExitOnError:
+		var stack []byte
+		var panicErr *PanicError
+		if errors.As(err, &panicErr) {
+			stack = panicErr.Stack
+		}
+		format := cmd.FormatError
+		if format == nil {
+			format = DefaultFormatError
+		}
+		w := cmd.ErrOutput()
+		fmt.Fprintln(w, format(cmd, err))
+		w.Write(stack)
+		usage()
+		cmd.runExitHooks()
+		os.Exit(cmd.ExitCode(err))
+	case PanicOnError:
+		usage()
+		panic(err)
+	}
+
+	usage()
+	return err
+}
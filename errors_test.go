@@ -0,0 +1,367 @@
+package cmds
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCommandError(t *testing.T) {
+	cmd := &Command{
+		Name: "test",
+		Commands: []*Command{
+			{
+				Name: "sub",
+				Commands: []*Command{
+					{Name: "leaf"},
+				},
+			},
+			{Name: "topic", LongDesc: "About."},
+		},
+	}
+
+	expectCommandError := func(err error, cmdErr *CommandError, msg string) {
+		t.Helper()
+		expectErrorIs(t, err, ErrCmd)
+		var target *CommandError
+		if !errors.As(err, &target) {
+			t.Fatalf("expected *CommandError, got %#v", err)
+		}
+		expectEq(t, target.Path, cmdErr.Path)
+		expectEq(t, target.Arg, cmdErr.Arg)
+		expectErrorIs(t, err, cmdErr.Err)
+		expectEq(t, err.Error(), msg)
+	}
+
+	expectCommandError(cmd.ParseRun(nil), &CommandError{
+		Path: []string{"test"},
+		Err:  ErrMissingCommand,
+	}, "command error: command parse error: missing command")
+	expectCommandError(cmd.ParseRun([]string{"sub"}), &CommandError{
+		Path: []string{"test", "sub"},
+		Err:  ErrMissingCommand,
+	}, "command error: command parse error: missing command for \"sub\"")
+	expectCommandError(cmd.ParseRun([]string{"sub", "x"}), &CommandError{
+		Path: []string{"test", "sub"},
+		Arg:  "x",
+		Err:  ErrUnknownCommand,
+	}, "command error: command parse error: no such command \"x\"")
+	expectCommandError(cmd.ParseRun([]string{"topic"}), &CommandError{
+		Path: []string{"test", "topic"},
+		Arg:  "topic",
+		Err:  ErrHelpTopic,
+	}, "command error: command parse error: \"topic\" is a help topic")
+	expectCommandError(cmd.ParseRun([]string{"sub", "leaf"}), &CommandError{
+		Path: []string{"test", "sub", "leaf"},
+		Err:  ErrNilRunner,
+	}, "command error: command parse error: nil runner")
+}
+
+func TestFlagError(t *testing.T) {
+	cmd := &Command{
+		Name: "test",
+		Commands: []*Command{
+			{
+				Name:   "sub",
+				Runner: nopRunner,
+				Flags: func() *flag.FlagSet {
+					fset := flag.NewFlagSet("sub", flag.ContinueOnError)
+					fset.SetOutput(io.Discard)
+					fset.Int("n", 0, "")
+					return fset
+				}(),
+			},
+		},
+	}
+
+	for args, name := range map[string]string{
+		"sub -x":     "x",
+		"sub --x=1":  "x",
+		"sub -n":     "n",
+		"sub -n a":   "n",
+		"sub --n=a":  "n",
+		"sub ---n=a": "",
+	} {
+		err := cmd.ParseRun(strings.Fields(args))
+		expectErrorIs(t, err, ErrFlag)
+		var target *FlagError
+		if !errors.As(err, &target) {
+			t.Fatalf("expected *FlagError, got %#v", err)
+		}
+		expectEq(t, target.Path, []string{"test", "sub"})
+		expectEq(t, target.Flag, name)
+	}
+}
+
+func TestErrHelp(t *testing.T) {
+	var handled bool
+	cmd := &Command{
+		Flags: func() *flag.FlagSet {
+			fset := flag.NewFlagSet("test", flag.ContinueOnError)
+			fset.SetOutput(io.Discard)
+			return fset
+		}(),
+		ErrorHandling: PanicOnError,
+		HandleError: func(err error) error {
+			handled = true
+			return err
+		},
+		Runner: nopRunner,
+	}
+
+	err := cmd.ParseRun([]string{"-h"})
+	expectErrorIs(t, err, ErrHelp)
+	expectErrorIs(t, err, flag.ErrHelp)
+	expectErrorNot(t, err, Err)
+	expectErrorNot(t, err, ErrFlag)
+	expectFalse(t, handled)
+	expectEq(t, cmd.ExitCode(err), 0)
+}
+
+func TestUsageOnError(t *testing.T) {
+	var b strings.Builder
+	cmd := &Command{
+		Name: "test",
+		Flags: func() *flag.FlagSet {
+			fset := flag.NewFlagSet("test", flag.ContinueOnError)
+			fset.SetOutput(&b)
+			return fset
+		}(),
+		Commands: []*Command{
+			{
+				Name: "sub",
+				Flags: func() *flag.FlagSet {
+					fset := flag.NewFlagSet("sub", flag.ContinueOnError)
+					fset.SetOutput(&b)
+					return fset
+				}(),
+				Commands: []*Command{
+					{Name: "leaf", Runner: nopRunner},
+				},
+			},
+		},
+	}
+	cmd.Flags.Usage = func() { fmt.Fprint(&b, "test usage") }
+	cmd.Commands[0].Flags.Usage = func() { fmt.Fprint(&b, "sub usage") }
+
+	expectErrorIs(t, cmd.ParseRun([]string{"sub"}), ErrCmd)
+	expectEq(t, b.String(), "")
+
+	cmd.UsageOnError = true
+	expectErrorIs(t, cmd.ParseRun([]string{"sub"}), ErrCmd)
+	expectEq(t, b.String(), "sub usage")
+	b.Reset()
+
+	expectErrorIs(t, cmd.ParseRun([]string{"invalid"}), ErrCmd)
+	expectEq(t, b.String(), "test usage")
+	b.Reset()
+
+	expectErrorNone(t, cmd.ParseRun([]string{"sub", "leaf"}))
+	expectEq(t, b.String(), "")
+}
+
+func TestErrOutput(t *testing.T) {
+	var b, e strings.Builder
+	cmd := &Command{
+		Name:         "test",
+		UsageOnError: true,
+		Flags:        flag.NewFlagSet("test", flag.ContinueOnError),
+		Commands: []*Command{
+			{Name: "leaf", Runner: nopRunner},
+		},
+	}
+	cmd.Flags.SetOutput(&b)
+	cmd.Flags.Usage = func() { fmt.Fprint(cmd.Flags.Output(), "test usage") }
+
+	expectEq(t, cmd.ErrOutput(), io.Writer(os.Stderr))
+	cmd.SetErrOutput(&e)
+	expectErrorIs(t, cmd.ParseRun([]string{"invalid"}), ErrCmd)
+	expectEq(t, e.String(), "test usage")
+	expectEq(t, b.String(), "")
+	expectEq(t, cmd.Flags.Output(), io.Writer(&b))
+
+	expectErrorNone(t, cmd.ParseRun([]string{"leaf"}))
+	expectEq(t, cmd.Commands[0].ErrOutput(), io.Writer(&e))
+}
+
+func TestHandleError(t *testing.T) {
+	errTranslated := errors.New("translated")
+	var handled error
+	cmd := &Command{
+		ErrorHandling: PanicOnError,
+		HandleError: func(err error) error {
+			handled = err
+			if errors.Is(err, ErrCmd) {
+				return nil
+			}
+			return errTranslated
+		},
+		Commands: []*Command{
+			{Name: "sub", Runner: nopRunner},
+		},
+	}
+
+	expectErrorNone(t, cmd.ParseRun(nil))
+	expectErrorIs(t, handled, Err)
+	expectErrorIs(t, handled, ErrCmd)
+
+	cmd.ErrorHandling = ReturnOnError
+	cmd.Flags = flag.NewFlagSet("test", flag.ContinueOnError)
+	cmd.Flags.SetOutput(io.Discard)
+	err := cmd.ParseRun([]string{"-x"})
+	expectErrorIs(t, handled, ErrFlag)
+	expectErrorIs(t, err, errTranslated)
+}
+
+func TestOnError(t *testing.T) {
+	var path []string
+	var values map[string]string
+	var handled error
+	cmd := &Command{
+		Name:  "test",
+		Flags: flag.NewFlagSet("test", flag.ContinueOnError),
+		OnError: func(cmd *Command, err error) {
+			path = cmd.Path()
+			values = cmd.FlagValues()
+			for c := cmd.Parent(); c != nil; c = c.Parent() {
+				for name, value := range c.FlagValues() {
+					values[name] = value
+				}
+			}
+			handled = err
+		},
+		Commands: []*Command{
+			{
+				Name:     "sub",
+				Flags:    flag.NewFlagSet("sub", flag.ContinueOnError),
+				Commands: []*Command{{Name: "leaf"}},
+			},
+		},
+	}
+	cmd.Flags.String("token", "", "")
+	cmd.Flags.Bool("v", false, "")
+	cmd.Meta("token").Secret = true
+	cmd.Commands[0].Flags.String("n", "", "")
+
+	expectErrorIs(t, cmd.ParseRun([]string{"-token", "x", "-v", "sub", "-n", "1", "invalid"}), ErrUnknownCommand)
+	expectEq(t, path, []string{"test", "sub"})
+	expectEq(t, values, map[string]string{"token": "REDACTED", "v": "true", "n": "1"})
+	expectErrorIs(t, handled, Err)
+
+	expectErrorIs(t, cmd.ParseRun([]string{"sub", "leaf"}), ErrNilRunner)
+	expectEq(t, path, []string{"test", "sub", "leaf"})
+
+	handled = nil
+	cmd.Flags.SetOutput(io.Discard)
+	expectErrorIs(t, cmd.ParseRun([]string{"-h"}), ErrHelp)
+	expectErrorNone(t, handled)
+}
+
+func TestRecoverPanics(t *testing.T) {
+	errPanic := errors.New("panic value")
+	var handled error
+	cmd := &Command{
+		Name: "test",
+		HandleError: func(err error) error {
+			handled = err
+			return err
+		},
+		Commands: []*Command{
+			{Name: "sub", Runner: func(cmd *Command, args []string) error {
+				panic(errPanic)
+			}},
+			{Name: "str", Runner: func(cmd *Command, args []string) error {
+				panic("oops")
+			}},
+		},
+	}
+
+	func() {
+		defer func() {
+			expectEq(t, recover(), errPanic)
+		}()
+		cmd.ParseRun([]string{"sub"})
+	}()
+
+	cmd.RecoverPanics = true
+	err := cmd.ParseRun([]string{"sub"})
+	expectErrorIs(t, err, errPanic)
+	expectErrorIs(t, handled, errPanic)
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("expected *PanicError, got %#v", err)
+	}
+	expectTrue(t, strings.Contains(string(panicErr.Stack), "TestRecoverPanics"))
+
+	err = cmd.ParseRun([]string{"str"})
+	expectEq(t, err.Error(), "panic: oops")
+	expectEq(t, cmd.ExitCode(err), 1)
+}
+
+type exitCodeError int
+
+func (err exitCodeError) Error() string {
+	return fmt.Sprintf("exit code %d", int(err))
+}
+
+func (err exitCodeError) ExitCode() int {
+	return int(err)
+}
+
+func TestExitCode(t *testing.T) {
+	errUnavailable := errors.New("service unavailable")
+	cmd := &Command{}
+
+	expectEq(t, cmd.ExitCode(errors.New("run error")), 1)
+	expectEq(t, cmd.ExitCode(fmt.Errorf("%w: %w", Err, ErrFlag)), 2)
+	expectEq(t, cmd.ExitCode(fmt.Errorf("%w: %w", Err, ErrCmd)), 3)
+
+	cmd.SetExitCode(ErrCmd, 64)
+	cmd.SetExitCode(errUnavailable, 69)
+	cmd.SetExitCode(Err, 70)
+	expectEq(t, cmd.ExitCode(fmt.Errorf("%w: %w", Err, ErrCmd)), 64)
+	expectEq(t, cmd.ExitCode(fmt.Errorf("%w: %w", Err, ErrFlag)), 70)
+	expectEq(t, cmd.ExitCode(fmt.Errorf("wrapped: %w", errUnavailable)), 69)
+	cmd.SetExitCode(ErrCmd, 65)
+	expectEq(t, cmd.ExitCode(fmt.Errorf("%w: %w", Err, ErrCmd)), 65)
+
+	expectEq(t, cmd.ExitCode(fmt.Errorf("wrapped: %w", exitCodeError(42))), 42)
+
+	cmd = &Command{ExitCodeStyle: ExitCodesSysexits}
+	expectEq(t, cmd.ExitCode(errors.New("run error")), 1)
+	expectEq(t, cmd.ExitCode(fmt.Errorf("%w: %w", Err, ErrFlag)), 64)
+	expectEq(t, cmd.ExitCode(fmt.Errorf("%w: %w", Err, ErrCmd)), 64)
+	expectEq(t, cmd.ExitCode(&PanicError{Value: "oops"}), 70)
+	expectEq(t, cmd.ExitCode(fmt.Errorf("%w: %w", ErrTimeout, context.DeadlineExceeded)), 75)
+	expectEq(t, cmd.ExitCode(ErrHelp), 0)
+	cmd.SetExitCode(errUnavailable, 69)
+	expectEq(t, cmd.ExitCode(errUnavailable), 69)
+}
+
+func TestDefaultFormatError(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("CLICOLOR_FORCE", "")
+	cmd := &Command{
+		Name:     "tool",
+		Stderr:   io.Discard,
+		Commands: []*Command{{Name: "leaf", Runner: nopRunner}},
+	}
+	err := cmd.ParseRun([]string{"lef"})
+	expectErrorIs(t, err, ErrCmd)
+	expectEq(t, DefaultFormatError(cmd, err), "tool: error: no such command \"lef\", did you mean \"leaf\"?")
+	expectEq(t, DefaultFormatError(&Command{}, errors.New("failed")), "error: failed")
+
+	err = fmt.Errorf("%w: %w", Err, errors.Join(
+		fmt.Errorf("%w: %w", ErrFlag, errors.New("invalid value for -n")),
+		errors.New("missing -name"),
+	))
+	expectEq(t, DefaultFormatError(cmd, err), "tool: error: invalid value for -n\ntool: error: missing -name")
+
+	t.Setenv("CLICOLOR_FORCE", "1")
+	expectEq(t, DefaultFormatError(cmd, errors.New("failed")), "tool: \x1b[31merror:\x1b[0m failed")
+}
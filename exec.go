@@ -0,0 +1,161 @@
+package cmds
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"text/template"
+)
+
+// ExecFlag is a flag of a command made with [ExecCommand] and how it's passed
+// to the program.
+type ExecFlag struct {
+	Name    string
+	Usage   string
+	Default string
+
+	// Bool makes it a bool flag, Default is then "true" or "false".
+	Bool bool
+
+	// Args are added to the arguments of the program when the flag has a
+	// value other than it's default, each of them is a [text/template]
+	// executed with the value of the flag as ".", like "--color={{.}}".
+	Args []string
+}
+
+// execFlagArgs is the element of the arguments of [ExecCommand] that is
+// replaced by the arguments of the flags.
+const execFlagArgs = "{{.FlagArgs}}"
+
+// ExecCommand returns a command named name that runs the program path, so
+// that an existing tool can be wrapped with a curated set of flags.
+// The arguments of the program are args, templates like [Manifest.Exec]
+// without the program, where an element that is exactly "{{.FlagArgs}}" is
+// replaced by the Args of the flags, in the order of flags, and an element
+// that is exactly "{{.Args}}" by the arguments of the command.
+// If args is empty the flag arguments are followed by the arguments of the
+// command.
+//
+// The program is run with the streams of the command, when the context of the
+// command is done it's interrupted and killed if it doesn't exit soon after,
+// on Windows it's killed.
+// If it exits with a non-zero code, the error returned by the Runner is an
+// [ExitCoder] with that code, so that [ExitOnError] exits with it too.
+func ExecCommand(name, path string, args []string, flags ...ExecFlag) (*Command, error) {
+	if path == "" {
+		return nil, errors.New("empty program name")
+	}
+	if len(args) == 0 {
+		args = []string{execFlagArgs, manifestArgs}
+	}
+
+	cmd := &Command{
+		Name:  name,
+		Flags: flag.NewFlagSet(name, flag.ContinueOnError),
+	}
+	cmd.Flags.Usage = cmd.DefaultUsage()
+
+	mapped := make([][]*template.Template, len(flags))
+	for i, f := range flags {
+		if f.Name == "" {
+			return nil, fmt.Errorf("flag without a name in command \"%s\"", name)
+		}
+		cmd.DeclareFlags(f.Name)
+		if f.Bool {
+			def := f.Default == "true"
+			if f.Default != "" && !def && f.Default != "false" {
+				return nil, fmt.Errorf("invalid default \"%s\" for bool flag -%s", f.Default, f.Name)
+			}
+			cmd.Flags.Bool(f.Name, def, f.Usage)
+		} else {
+			cmd.Flags.String(f.Name, f.Default, f.Usage)
+		}
+		for _, arg := range f.Args {
+			tmpl, err := template.New("").Option("missingkey=error").Parse(arg)
+			if err != nil {
+				return nil, fmt.Errorf("flag -%s: %w", f.Name, err)
+			}
+			mapped[i] = append(mapped[i], tmpl)
+		}
+	}
+
+	runner, err := execRunner(append([]string{path}, args...), func(cmd *Command) ([]string, error) {
+		var flagArgs []string
+		for i, f := range flags {
+			fl := cmd.Flags.Lookup(f.Name)
+			value := fl.Value.String()
+			if value == fl.DefValue {
+				continue
+			}
+			for _, tmpl := range mapped[i] {
+				var b bytes.Buffer
+				if err := tmpl.Execute(&b, value); err != nil {
+					return nil, err
+				}
+				flagArgs = append(flagArgs, b.String())
+			}
+		}
+		return flagArgs, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("command \"%s\": %w", name, err)
+	}
+	cmd.Runner = runner
+
+	return cmd, nil
+}
+
+type execTemplateData struct {
+	Flags map[string]string
+	Args  []string
+}
+
+// execRunner returns a Runner that runs the program argv expands to, as
+// described for [Manifest.Exec], with the element execFlagArgs replaced by
+// what flagArgs returns.
+func execRunner(argv []string, flagArgs func(*Command) ([]string, error)) (RunnerFunc, error) {
+	tmpls := make([]*template.Template, len(argv))
+	for i, arg := range argv {
+		if arg == manifestArgs || (arg == execFlagArgs && flagArgs != nil) {
+			continue
+		}
+		tmpl, err := template.New("").Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return nil, err
+		}
+		tmpls[i] = tmpl
+	}
+
+	return func(cmd *Command, args []string) error {
+		data := execTemplateData{Flags: map[string]string{}, Args: args}
+		cmd.Flags.VisitAll(func(f *flag.Flag) {
+			data.Flags[f.Name] = f.Value.String()
+		})
+
+		var expanded []string
+		for i, tmpl := range tmpls {
+			switch {
+			case tmpl != nil:
+				var b bytes.Buffer
+				if err := tmpl.Execute(&b, data); err != nil {
+					return err
+				}
+				expanded = append(expanded, b.String())
+			case argv[i] == manifestArgs:
+				expanded = append(expanded, args...)
+			default:
+				fargs, err := flagArgs(cmd)
+				if err != nil {
+					return err
+				}
+				expanded = append(expanded, fargs...)
+			}
+		}
+
+		if len(expanded) == 0 || expanded[0] == "" {
+			return errors.New("empty program name")
+		}
+		return cmd.runProgram(expanded[0], expanded[1:], nil)
+	}, nil
+}
@@ -0,0 +1,65 @@
+package cmds
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestExecCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("exec commands need a POSIX shell")
+	}
+
+	testCmd := func(out *bytes.Buffer) *Command {
+		cmd, err := ExecCommand("test", "sh", []string{"-c", `echo "$@"; exit $1`, "sh", "{{.Args}}", "{{.FlagArgs}}"},
+			ExecFlag{Name: "color", Default: "auto", Args: []string{"--color={{.}}"}},
+			ExecFlag{Name: "q", Bool: true, Args: []string{"--quiet", "-s"}},
+			ExecFlag{Name: "pager", Bool: true, Default: "true", Args: []string{"--no-pager"}},
+		)
+		expectErrorNone(t, err)
+		cmd.Stdout = out
+		return cmd
+	}
+
+	var out bytes.Buffer
+	expectErrorNone(t, testCmd(&out).ParseRun([]string{"0", "a"}))
+	expectEq(t, out.String(), "0 a\n")
+
+	out.Reset()
+	expectErrorNone(t, testCmd(&out).ParseRun([]string{"-color", "never", "-q", "0"}))
+	expectEq(t, out.String(), "0 --color=never --quiet -s\n")
+
+	out.Reset()
+	expectErrorNone(t, testCmd(&out).ParseRun([]string{"-pager=false", "0"}))
+	expectEq(t, out.String(), "0 --no-pager\n")
+
+	cmd := testCmd(&out)
+	err := cmd.ParseRun([]string{"4"})
+	expectError(t, err)
+	expectEq(t, cmd.ExitCode(err), 4)
+
+	_, err = ExecCommand("test", "", nil)
+	expectError(t, err)
+	_, err = ExecCommand("test", "sh", nil, ExecFlag{Name: "x", Args: []string{"{{"}})
+	expectError(t, err)
+}
+
+func TestExecCommandInterrupt(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("exec commands need a POSIX shell")
+	}
+
+	cmd, err := ExecCommand("test", "sh", []string{"-c", `trap 'echo interrupted; exit 5' INT; sleep 10 </dev/null >/dev/null 2>&1 & wait`})
+	expectErrorNone(t, err)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	err = cmd.ParseRunContext(ctx, nil)
+	expectEq(t, cmd.ExitCode(err), 5)
+	expectEq(t, out.String(), "interrupted\n")
+}
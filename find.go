@@ -0,0 +1,96 @@
+package cmds
+
+// findIndexMin is the number of sub-commands from which [Command.Find] looks
+// them up in an index instead of going through all of them.
+const findIndexMin = 16
+
+// findIndex maps the names and aliases of the commands it was made for to
+// their positions.
+type findIndex struct {
+	commands []*Command
+	names    map[string]int
+}
+
+func newFindIndex(commands []*Command) *findIndex {
+	idx := &findIndex{commands: commands, names: make(map[string]int, len(commands))}
+	for i, sub := range commands {
+		if _, ok := idx.names[sub.Name]; !ok {
+			idx.names[sub.Name] = i
+		}
+	}
+	for i, sub := range commands {
+		for _, alias := range sub.Aliases {
+			if _, ok := idx.names[alias]; !ok {
+				idx.names[alias] = i
+			}
+		}
+	}
+	return idx
+}
+
+// valid reports whether idx was made for commands, as far as that can be
+// told without going through them.
+func (idx *findIndex) valid(commands []*Command) bool {
+	return idx != nil && len(idx.commands) == len(commands) && &idx.commands[0] == &commands[0]
+}
+
+// Find finds the sub-command with the given name or alias, names take
+// precedence over aliases.
+// For commands with many sub-commands it uses an index that is made when it's
+// first needed and again when Commands is changed by appending to it or
+// assigning it, changing the names of the sub-commands in place afterwards
+// isn't noticed.
+// It doesn't name the sub-commands without a Name, see
+// [Command.NameCommands].
+func (cmd *Command) Find(name string) *Command {
+	if len(cmd.Commands) < findIndexMin {
+		return findCommand(cmd.Commands, name)
+	}
+
+	idx, _ := cmd.findIndex.Load().(*findIndex)
+	if !idx.valid(cmd.Commands) {
+		idx = newFindIndex(cmd.Commands)
+		cmd.findIndex.Store(idx)
+	}
+	i, ok := idx.names[name]
+	if !ok {
+		return nil
+	}
+	if sub := cmd.Commands[i]; hasName(sub, name) {
+		return sub
+	}
+
+	// A sub-command was replaced in place.
+	cmd.findIndex.Store(newFindIndex(cmd.Commands))
+	return findCommand(cmd.Commands, name)
+}
+
+func findCommand(commands []*Command, name string) *Command {
+	for _, sub := range commands {
+		if sub.Name == name {
+			return sub
+		}
+	}
+
+	for _, sub := range commands {
+		for _, alias := range sub.Aliases {
+			if alias == name {
+				return sub
+			}
+		}
+	}
+
+	return nil
+}
+
+func hasName(cmd *Command, name string) bool {
+	if cmd.Name == name {
+		return true
+	}
+	for _, alias := range cmd.Aliases {
+		if alias == name {
+			return true
+		}
+	}
+	return false
+}
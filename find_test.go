@@ -0,0 +1,31 @@
+package cmds
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	for _, n := range []int{2, findIndexMin * 2} {
+		cmd := &Command{Name: "test"}
+		for i := 0; i < n; i++ {
+			cmd.Commands = append(cmd.Commands, &Command{Name: fmt.Sprint("sub", i), Aliases: []string{fmt.Sprint("s", i)}})
+		}
+		// Names take precedence over aliases.
+		cmd.Commands[0].Aliases = append(cmd.Commands[0].Aliases, "sub1")
+
+		expectTrue(t, cmd.Find("sub0") == cmd.Commands[0])
+		expectTrue(t, cmd.Find("s1") == cmd.Commands[1])
+		expectTrue(t, cmd.Find("sub1") == cmd.Commands[1])
+		expectTrue(t, cmd.Find("missing") == nil)
+
+		added := &Command{Name: "added"}
+		cmd.Commands = append(cmd.Commands, added)
+		expectTrue(t, cmd.Find("added") == added)
+
+		replaced := &Command{Name: "sub1"}
+		cmd.Commands[1] = replaced
+		expectTrue(t, cmd.Find("sub1") == replaced)
+		expectTrue(t, cmd.Find("s1") == nil)
+	}
+}
@@ -0,0 +1,126 @@
+package cmds
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// FlagsFromMap returns a FlagSet with ContinueOnError and the flags in specs,
+// named after their key, so that flags can be declared as data like in
+// manifests, the Name of the specs isn't used.
+// The Type of a spec is one of "string", which is the default unless Bool is
+// set, "bool", "int", "int64", "uint", "uint64", "float64" and "duration",
+// the Default is parsed according to it.
+// The Env, Required, Choices and Deprecated of the specs are for the FlagMeta
+// of the command, see [Command.MetaFromMap].
+// It panics if a Type or Default is invalid, like the flag package panics
+// when a flag is defined twice.
+func FlagsFromMap(specs map[string]FlagSpec) *flag.FlagSet {
+	fset := flag.NewFlagSet("", flag.ContinueOnError)
+	for name, spec := range specs {
+		if err := defineFlag(fset, name, spec); err != nil {
+			panic(fmt.Sprintf("flag -%s: %v", name, err))
+		}
+	}
+	return fset
+}
+
+func defineFlag(fset *flag.FlagSet, name string, spec FlagSpec) error {
+	typ := spec.Type
+	if typ == "" {
+		typ = "string"
+		if spec.Bool {
+			typ = "bool"
+		}
+	}
+
+	var err error
+	parse := func(def string, parse func(string) error) {
+		if def != "" {
+			err = parse(def)
+		}
+	}
+	switch typ {
+	case "string":
+		fset.String(name, spec.Default, spec.Usage)
+	case "bool":
+		var v bool
+		parse(spec.Default, func(s string) (err error) { v, err = strconv.ParseBool(s); return })
+		fset.Bool(name, v, spec.Usage)
+	case "int":
+		var v int
+		parse(spec.Default, func(s string) (err error) { v, err = strconv.Atoi(s); return })
+		fset.Int(name, v, spec.Usage)
+	case "int64":
+		var v int64
+		parse(spec.Default, func(s string) (err error) { v, err = strconv.ParseInt(s, 0, 64); return })
+		fset.Int64(name, v, spec.Usage)
+	case "uint":
+		var v uint64
+		parse(spec.Default, func(s string) (err error) { v, err = strconv.ParseUint(s, 0, strconv.IntSize); return })
+		fset.Uint(name, uint(v), spec.Usage)
+	case "uint64":
+		var v uint64
+		parse(spec.Default, func(s string) (err error) { v, err = strconv.ParseUint(s, 0, 64); return })
+		fset.Uint64(name, v, spec.Usage)
+	case "float64":
+		var v float64
+		parse(spec.Default, func(s string) (err error) { v, err = strconv.ParseFloat(s, 64); return })
+		fset.Float64(name, v, spec.Usage)
+	case "duration":
+		var v time.Duration
+		parse(spec.Default, func(s string) (err error) { v, err = time.ParseDuration(s); return })
+		fset.Duration(name, v, spec.Usage)
+	default:
+		return fmt.Errorf("invalid type \"%s\"", spec.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid default \"%s\": %w", spec.Default, err)
+	}
+	return nil
+}
+
+// MetaFromMap sets the Env, Required, Choices and Deprecated of the
+// [FlagMeta] of the flags of cmd in specs, for the FlagSets made with
+// [FlagsFromMap].
+func (cmd *Command) MetaFromMap(specs map[string]FlagSpec) {
+	for name, spec := range specs {
+		if spec.Env == "" && !spec.Required && spec.Choices == nil && spec.Deprecated == "" {
+			continue
+		}
+		meta := cmd.Meta(name)
+		meta.Env = spec.Env
+		meta.Required = spec.Required
+		meta.Choices = spec.Choices
+		meta.Deprecated = spec.Deprecated
+	}
+}
+
+// flagType returns the Type of f for a [FlagSpec].
+func flagType(f *flag.Flag) string {
+	getter, ok := f.Value.(flag.Getter)
+	if !ok {
+		return ""
+	}
+	switch getter.Get().(type) {
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case int:
+		return "int"
+	case int64:
+		return "int64"
+	case uint:
+		return "uint"
+	case uint64:
+		return "uint64"
+	case float64:
+		return "float64"
+	case time.Duration:
+		return "duration"
+	}
+	return ""
+}
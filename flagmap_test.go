@@ -0,0 +1,45 @@
+package cmds
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFlagsFromMap(t *testing.T) {
+	specs := map[string]FlagSpec{
+		"name":    {Usage: "the name", Default: "x", Env: "TEST_NAME"},
+		"v":       {Bool: true, Usage: "verbose output"},
+		"n":       {Type: "int", Default: "3", Required: true},
+		"timeout": {Type: "duration", Default: "1s"},
+		"level":   {Choices: []string{"debug", "info"}, Default: "info"},
+	}
+	cmd := &Command{Name: "test", Runner: nopRunner, Flags: FlagsFromMap(specs)}
+	cmd.MetaFromMap(specs)
+	expectEq(t, cmd.Flags.Lookup("name").Usage, "the name")
+	expectEq(t, cmd.Flags.Lookup("timeout").DefValue, "1s")
+	expectTrue(t, isBoolFlag(cmd.Flags.Lookup("v")))
+	expectEq(t, cmd.Meta("name").Env, "TEST_NAME")
+	expectTrue(t, cmd.Meta("n").Required)
+
+	expectErrorNone(t, cmd.ParseRun([]string{"-v", "-n", "5", "-timeout", "2m"}))
+	expectEq(t, cmd.Flags.Lookup("n").Value.String(), "5")
+	expectEq(t, cmd.Flags.Lookup("timeout").Value.(interface{ Get() any }).Get(), any(2*time.Minute))
+
+	cmd.Reset()
+	expectError(t, cmd.ParseRun([]string{"-n", "1", "-level", "trace"}))
+
+	// The spec of the flags has their types.
+	types := map[string]string{"name": "string", "v": "bool", "n": "int", "timeout": "duration", "level": "string"}
+	for _, f := range cmd.Spec().Flags {
+		expectEq(t, f.Type, types[f.Name])
+	}
+
+	for _, spec := range []FlagSpec{{Type: "complex128"}, {Type: "int", Default: "x"}} {
+		func() {
+			defer func() {
+				expectTrue(t, recover() != nil)
+			}()
+			FlagsFromMap(map[string]FlagSpec{"bad": spec})
+		}()
+	}
+}
@@ -0,0 +1,47 @@
+package cmds
+
+import "flag"
+
+// FlagParser parses the flags of a command instead of it's [flag.FlagSet],
+// like [Getopt] or a *pflag.FlagSet of github.com/spf13/pflag, see package
+// [github.com/rgzlv/cmds/compat/pflag].
+//
+// The flags still need to be defined in the FlagSet of the command with the
+// same Values, since the usage message, completion, validation and [Source]
+// use it.
+type FlagParser interface {
+	// Parse parses the flags in args, it returns an error that wraps
+	// [flag.ErrHelp] for the help flags.
+	Parse(args []string) error
+
+	// Args returns the arguments after the flags.
+	Args() []string
+
+	// Changed reports whether the flag name was given to Parse.
+	Changed(name string) bool
+}
+
+// flagParserResetter is implemented by the FlagParsers that keep state
+// between parses, [Command.Reset] calls their Reset method.
+type flagParserResetter interface {
+	Reset()
+}
+
+// visitSet calls fn for the flags of cmd that were set, on the command line or
+// with [flag.FlagSet.Set].
+func (cmd *Command) visitSet(fn func(*flag.Flag)) {
+	if cmd.FlagParser == nil {
+		cmd.Flags.Visit(fn)
+		return
+	}
+
+	set := make(map[string]bool)
+	cmd.Flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	cmd.Flags.VisitAll(func(f *flag.Flag) {
+		if set[f.Name] || cmd.FlagParser.Changed(f.Name) {
+			fn(f)
+		}
+	})
+}
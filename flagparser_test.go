@@ -0,0 +1,56 @@
+package cmds
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+// testParser parses "+name=value" flags.
+type testParser struct {
+	fset    *flag.FlagSet
+	args    []string
+	changed map[string]bool
+}
+
+func (p *testParser) Parse(args []string) error {
+	p.changed = make(map[string]bool)
+	for len(args) > 0 && strings.HasPrefix(args[0], "+") {
+		name, value, _ := strings.Cut(args[0][1:], "=")
+		if err := p.fset.Lookup(name).Value.Set(value); err != nil {
+			return err
+		}
+		p.changed[name] = true
+		args = args[1:]
+	}
+	p.args = args
+	return nil
+}
+
+func (p *testParser) Args() []string           { return p.args }
+func (p *testParser) Changed(name string) bool { return p.changed[name] }
+
+func TestFlagParser(t *testing.T) {
+	var name string
+	var got []string
+	cmd := &Command{
+		Name:  "test",
+		Flags: flag.NewFlagSet("test", flag.ContinueOnError),
+		Runner: func(cmd *Command, args []string) error {
+			got = args
+			return nil
+		},
+	}
+	cmd.Flags.StringVar(&name, "name", "", "")
+	cmd.FlagParser = &testParser{fset: cmd.Flags}
+	cmd.Meta("name").Required = true
+
+	expectErrorNone(t, cmd.ParseRun([]string{"+name=x", "arg"}))
+	expectEq(t, name, "x")
+	expectEq(t, got, []string{"arg"})
+	expectEq(t, cmd.ValueSource("name"), SourceCommandLine)
+	expectEq(t, cmd.FlagValues(), map[string]string{"name": "x"})
+
+	cmd.Reset()
+	expectErrorIs(t, cmd.ParseRun([]string{"-name=x"}), ErrFlagRequired)
+}
@@ -0,0 +1,181 @@
+package cmds
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Getopt is a [FlagParser] that parses the flags the way getopt(3) does, for
+// commands that replace C tools and have to accept the same arguments.
+// Only the flags with the single letter names in it's optstring are parsed,
+// they can be grouped like "-abc" and the argument of a flag can be in the
+// same argument like "-ofile" or the next one like "-o file".
+type Getopt struct {
+	fset    *flag.FlagSet
+	opts    map[byte]getoptArg
+	posix   bool
+	args    []string
+	changed map[string]bool
+}
+
+type getoptArg int
+
+const (
+	getoptNoArg getoptArg = iota
+	getoptRequiredArg
+	getoptOptionalArg
+)
+
+// NewGetopt returns a Getopt that parses the flags of fset in optstring, in
+// the syntax of getopt(3): every letter is a flag that is set to true,
+// followed by ":" if it has an argument or "::" if the argument is optional
+// and has to be in the same argument, like "-ofile".
+// A leading "+" makes parsing stop at the first argument that isn't a flag,
+// as it does when POSIXLY_CORRECT is set in the environment, otherwise the
+// flags after it are parsed too, like GNU getopt permutes them, also when a
+// leading "-" is given, the other arguments are kept in their order.
+// A leading ":" is accepted, the errors are returned either way.
+// The flags without an argument have to be bool flags of fset, for the ones
+// with an optional argument that isn't given the flag is set to "".
+func NewGetopt(fset *flag.FlagSet, optstring string) (*Getopt, error) {
+	g := &Getopt{fset: fset, opts: make(map[byte]getoptArg)}
+	switch {
+	case strings.HasPrefix(optstring, "+"):
+		g.posix = true
+		optstring = optstring[1:]
+	case strings.HasPrefix(optstring, "-"):
+		optstring = optstring[1:]
+	default:
+		g.posix = os.Getenv("POSIXLY_CORRECT") != ""
+	}
+	optstring = strings.TrimPrefix(optstring, ":")
+
+	for i := 0; i < len(optstring); i++ {
+		c := optstring[i]
+		if c == ':' || c == '-' || c == '+' {
+			return nil, fmt.Errorf("invalid optstring character '%c'", c)
+		}
+		f := fset.Lookup(string(c))
+		if f == nil {
+			return nil, fmt.Errorf("no flag -%c for optstring", c)
+		}
+
+		kind := getoptNoArg
+		if strings.HasPrefix(optstring[i+1:], "::") {
+			kind = getoptOptionalArg
+			i += 2
+		} else if strings.HasPrefix(optstring[i+1:], ":") {
+			kind = getoptRequiredArg
+			i++
+		}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); kind == getoptNoArg && (!ok || !b.IsBoolFlag()) {
+			return nil, fmt.Errorf("flag -%c without an argument isn't a bool flag", c)
+		}
+		g.opts[c] = kind
+	}
+
+	return g, nil
+}
+
+// Parse implements [FlagParser].
+func (g *Getopt) Parse(args []string) error {
+	err := g.parse(args)
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(g.fset.Output(), err)
+	}
+	if g.fset.Usage != nil {
+		g.fset.Usage()
+	}
+	if g.fset.ErrorHandling() == flag.ExitOnError {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		os.Exit(2)
+	}
+	if g.fset.ErrorHandling() == flag.PanicOnError {
+		panic(err)
+	}
+	return err
+}
+
+func (g *Getopt) parse(args []string) error {
+	g.args = nil
+	g.changed = make(map[string]bool)
+
+	for len(args) > 0 {
+		arg := args[0]
+		args = args[1:]
+
+		if arg == "--" {
+			g.args = append(g.args, args...)
+			return nil
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			if g.posix {
+				g.args = append(g.args, arg)
+				g.args = append(g.args, args...)
+				return nil
+			}
+			g.args = append(g.args, arg)
+			continue
+		}
+
+		for i := 1; i < len(arg); i++ {
+			c := arg[i]
+			kind, ok := g.opts[c]
+			if !ok {
+				if c == 'h' || c == '?' {
+					return flag.ErrHelp
+				}
+				return fmt.Errorf("flag provided but not defined: -%c", c)
+			}
+
+			var value string
+			switch {
+			case kind == getoptNoArg:
+				value = "true"
+			case i+1 < len(arg):
+				value = arg[i+1:]
+				i = len(arg)
+			case kind == getoptRequiredArg:
+				if len(args) == 0 {
+					return fmt.Errorf("flag needs an argument: -%c", c)
+				}
+				value = args[0]
+				args = args[1:]
+			}
+
+			name := string(c)
+			if err := g.fset.Set(name, value); err != nil {
+				return fmt.Errorf("invalid value \"%s\" for flag -%s: %w", value, name, err)
+			}
+			g.changed[name] = true
+		}
+	}
+
+	return nil
+}
+
+// Args implements [FlagParser].
+func (g *Getopt) Args() []string {
+	return g.args
+}
+
+// Changed implements [FlagParser].
+func (g *Getopt) Changed(name string) bool {
+	return g.changed[name]
+}
+
+// Reset clears the arguments and flags of the last parse, for
+// [Command.Reset].
+func (g *Getopt) Reset() {
+	g.args = nil
+	g.changed = nil
+}
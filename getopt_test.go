@@ -0,0 +1,78 @@
+package cmds
+
+import (
+	"flag"
+	"io"
+	"testing"
+)
+
+func TestGetopt(t *testing.T) {
+	var a, b bool
+	var o, p string
+	var got []string
+	testCmd := func(optstring string) *Command {
+		cmd := &Command{
+			Name:  "test",
+			Flags: flag.NewFlagSet("test", flag.ContinueOnError),
+			Runner: func(cmd *Command, args []string) error {
+				got = args
+				return nil
+			},
+		}
+		cmd.Flags.SetOutput(io.Discard)
+		cmd.Flags.BoolVar(&a, "a", false, "")
+		cmd.Flags.BoolVar(&b, "b", false, "")
+		cmd.Flags.StringVar(&o, "o", "", "")
+		cmd.Flags.StringVar(&p, "p", "default", "")
+		getopt, err := NewGetopt(cmd.Flags, optstring)
+		expectErrorNone(t, err)
+		cmd.FlagParser = getopt
+		return cmd
+	}
+	reset := func() {
+		a, b, o, p, got = false, false, "", "default", nil
+	}
+
+	expectErrorNone(t, testCmd("abo:p::").ParseRun([]string{"-ab", "-ofile", "x", "-p", "y", "--", "-a"}))
+	expectTrue(t, a && b)
+	expectEq(t, o, "file")
+	expectEq(t, p, "")
+	expectEq(t, got, []string{"x", "y", "-a"})
+
+	reset()
+	cmd := testCmd("abo:p::")
+	expectErrorNone(t, cmd.ParseRun([]string{"-bo", "file", "-pvalue", "-"}))
+	expectTrue(t, !a && b)
+	expectEq(t, o, "file")
+	expectEq(t, p, "value")
+	expectEq(t, got, []string{"-"})
+	expectEq(t, cmd.ValueSource("o"), SourceCommandLine)
+	expectEq(t, cmd.ValueSource("a"), SourceDefault)
+
+	// The flags aren't changed after Reset.
+	cmd.Reset()
+	expectTrue(t, !cmd.FlagParser.Changed("o"))
+	expectEq(t, cmd.FlagParser.Args(), []string(nil))
+	expectEq(t, cmd.ValueSource("o"), SourceDefault)
+
+	reset()
+	expectErrorNone(t, testCmd("+ab").ParseRun([]string{"-a", "x", "-b"}))
+	expectTrue(t, a && !b)
+	expectEq(t, got, []string{"x", "-b"})
+
+	reset()
+	t.Setenv("POSIXLY_CORRECT", "1")
+	expectErrorNone(t, testCmd("ab").ParseRun([]string{"-a", "x", "-b"}))
+	expectEq(t, got, []string{"x", "-b"})
+	expectErrorNone(t, testCmd("-ab").ParseRun([]string{"-a", "x", "-b"}))
+	expectEq(t, got, []string{"x"})
+
+	expectErrorIs(t, testCmd("ab").ParseRun([]string{"-c"}), ErrFlag)
+	expectErrorIs(t, testCmd("o:").ParseRun([]string{"-o"}), ErrFlag)
+	expectErrorIs(t, testCmd("ab").ParseRun([]string{"-h"}), ErrHelp)
+
+	for _, optstring := range []string{"a:b::c", "o", "a-"} {
+		_, err := NewGetopt(testCmd("a").Flags, optstring)
+		expectError(t, err)
+	}
+}
@@ -0,0 +1,53 @@
+package cmds
+
+import "flag"
+
+// AdoptGlobalFlags makes cmd take the flags that packages define in
+// [flag.CommandLine], usually in their init functions, instead of sharing it,
+// so that defining a flag with the same name in cmd doesn't panic.
+// If the FlagSet of cmd is flag.CommandLine, like the one of [Default], or nil
+// it's replaced with a new one, then when parsing and in the usage message the
+// flags of flag.CommandLine that cmd doesn't define are added to it with the
+// same Values and listed under the group named group, or with the other flags
+// if it's empty.
+// It's meant for the root command, the flags are adopted before the sub-commands
+// are parsed.
+func (cmd *Command) AdoptGlobalFlags(group string) {
+	if cmd.Flags == nil || cmd.Flags == flag.CommandLine {
+		fset := flag.NewFlagSet(cmd.Name, flag.CommandLine.ErrorHandling())
+		fset.SetOutput(flag.CommandLine.Output())
+		fset.Usage = cmd.DefaultUsage()
+		cmd.Flags = fset
+	}
+	cmd.adoptGlobal = true
+	cmd.adoptGroup = group
+}
+
+// adoptGlobalFlags adds the flags of flag.CommandLine that cmd doesn't define
+// if [Command.AdoptGlobalFlags] was called.
+func (cmd *Command) adoptGlobalFlags() {
+	if !cmd.adoptGlobal || cmd.Flags == flag.CommandLine {
+		return
+	}
+
+	var adopted []string
+	flag.CommandLine.VisitAll(func(f *flag.Flag) {
+		if cmd.Flags.Lookup(f.Name) != nil {
+			return
+		}
+		cmd.Flags.Var(f.Value, f.Name, f.Usage)
+		cmd.Flags.Lookup(f.Name).DefValue = f.DefValue
+		adopted = append(adopted, f.Name)
+	})
+	if len(adopted) == 0 || cmd.adoptGroup == "" {
+		return
+	}
+
+	for i := range cmd.FlagGroups {
+		if cmd.FlagGroups[i].Name == cmd.adoptGroup {
+			cmd.FlagGroups[i].Flags = append(cmd.FlagGroups[i].Flags, adopted...)
+			return
+		}
+	}
+	cmd.FlagGroups = append(cmd.FlagGroups, FlagGroup{Name: cmd.adoptGroup, Flags: adopted})
+}
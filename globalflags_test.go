@@ -0,0 +1,31 @@
+package cmds
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestAdoptGlobalFlags(t *testing.T) {
+	// The testing package defines it's flags in flag.CommandLine.
+	cmd := &Command{Name: "test", Runner: nopRunner}
+	cmd.AdoptGlobalFlags("Global flags")
+	count := cmd.Flags.String("test.count", "", "")
+
+	expectErrorNone(t, cmd.ParseRun([]string{"-test.count", "x"}))
+	expectEq(t, *count, "x")
+	expectTrue(t, cmd.Flags.Lookup("test.run").Value == flag.CommandLine.Lookup("test.run").Value)
+
+	groups := cmd.Usage().FlagGroups
+	expectEq(t, len(groups), 2)
+	expectEq(t, groups[0].Flags[0].Name, "test.count")
+	expectEq(t, groups[1].Name, "Global flags")
+	for _, f := range groups[1].Flags {
+		if f.Name == "test.count" {
+			t.Errorf("expected -test.count to not be adopted")
+		}
+	}
+
+	// Adopting again doesn't add the flags to the group twice.
+	n := len(groups[1].Flags)
+	expectEq(t, len(cmd.Usage().FlagGroups[1].Flags), n)
+}
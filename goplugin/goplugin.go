@@ -0,0 +1,97 @@
+// Package goplugin loads sub-commands from Go plugins, see [plugin].
+//
+// It's separate from package cmds because importing package plugin makes
+// programs dynamically linked.
+package goplugin
+
+import (
+	"fmt"
+	"path/filepath"
+	"plugin"
+	"strings"
+
+	"github.com/rgzlv/cmds"
+)
+
+// Symbol is the name of the function that a plugin must export, it must be
+// of type func() []*cmds.Command.
+const Symbol = "Commands"
+
+// Load opens every file with the .so extension in dir as a Go plugin, in
+// lexical order, and adds the commands returned by it's [Symbol] function as
+// sub-commands of cmd with [cmds.Command.Graft].
+// It's not an error if dir doesn't exist, it stops at the first plugin that
+// can't be loaded, the commands of the plugins before it stay added.
+func Load(cmd *cmds.Command, dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.so"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		if err := load(cmd, path); err != nil {
+			return fmt.Errorf("plugin %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// LoadLazy is like [Load], but it only adds a sub-command to cmd for every
+// plugin, named after the file without the extension, that opens the plugin
+// with it's LoadFunc when it's used, so that programs with many plugins don't
+// open all of them to output their usage message.
+// The plugin must return a command with the same name, the other ones are
+// ignored.
+func LoadLazy(cmd *cmds.Command, dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.so"))
+	if err != nil {
+		return err
+	}
+
+	var subs []*cmds.Command
+	for _, path := range paths {
+		path := path
+		name := strings.TrimSuffix(filepath.Base(path), ".so")
+		subs = append(subs, &cmds.Command{
+			Name: name,
+			LoadFunc: func() (*cmds.Command, error) {
+				commands, err := open(path)
+				if err != nil {
+					return nil, fmt.Errorf("plugin %s: %w", path, err)
+				}
+				for _, c := range commands {
+					if c.Name == name {
+						return c, nil
+					}
+				}
+				return nil, fmt.Errorf("plugin %s: no command \"%s\"", path, name)
+			},
+		})
+	}
+	return cmd.Graft(subs...)
+}
+
+func load(cmd *cmds.Command, path string) error {
+	commands, err := open(path)
+	if err != nil {
+		return err
+	}
+	return cmd.Graft(commands...)
+}
+
+// open returns the commands of the plugin at path.
+func open(path string) ([]*cmds.Command, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	sym, err := p.Lookup(Symbol)
+	if err != nil {
+		return nil, err
+	}
+	commands, ok := sym.(func() []*cmds.Command)
+	if !ok {
+		return nil, fmt.Errorf("%s is %T, expected func() []*cmds.Command", Symbol, sym)
+	}
+	return commands(), nil
+}
@@ -0,0 +1,55 @@
+package goplugin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rgzlv/cmds"
+)
+
+func TestLoad(t *testing.T) {
+	cmd := &cmds.Command{Name: "test"}
+	dir := t.TempDir()
+
+	if err := Load(cmd, filepath.Join(dir, "missing")); err != nil {
+		t.Fatalf("expected no error for a missing directory, got %v", err)
+	}
+	if err := Load(cmd, dir); err != nil {
+		t.Fatalf("expected no error for an empty directory, got %v", err)
+	}
+
+	path := filepath.Join(dir, "invalid.so")
+	if err := os.WriteFile(path, []byte("not a plugin"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := Load(cmd, dir)
+	if err == nil || !strings.HasPrefix(err.Error(), "plugin "+path+": ") {
+		t.Fatalf("expected error for %s, got %v", path, err)
+	}
+	if len(cmd.Commands) != 0 {
+		t.Fatalf("expected no commands, got %d", len(cmd.Commands))
+	}
+}
+
+func TestLoadLazy(t *testing.T) {
+	cmd := &cmds.Command{Name: "test"}
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "invalid.so")
+	if err := os.WriteFile(path, []byte("not a plugin"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadLazy(cmd, dir); err != nil {
+		t.Fatalf("expected no error before the plugin is opened, got %v", err)
+	}
+	if len(cmd.Commands) != 1 || cmd.Commands[0].Name != "invalid" {
+		t.Fatalf("expected the command invalid, got %v", cmd.Commands)
+	}
+
+	err := cmd.ParseRun([]string{"invalid"})
+	if err == nil || !strings.Contains(err.Error(), "plugin "+path+": ") {
+		t.Fatalf("expected error for %s, got %v", path, err)
+	}
+}
@@ -0,0 +1,323 @@
+// Package grpc makes commands that call the unary methods of gRPC services,
+// with flags for the fields of the request and the response output as JSON,
+// for debugging a gRPC API from the command line.
+//
+// The services are described by their [protoreflect.ServiceDescriptor], which
+// can be read from the server with [Reflect] if it has the reflection service
+// registered, or from a file written by protoc --descriptor_set_out with
+// [LoadDescriptorSet].
+package grpc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rgzlv/cmds"
+	upstream "google.golang.org/grpc"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Service returns a command named after sd, in lower case, with a sub-command
+// for every unary method of sd that calls it on conn, the streaming methods are
+// skipped.
+// The sub-commands are named after the method in kebab case, their flags are
+// the fields of the request named after the field with "-" instead of "_",
+// message, repeated and map fields are given as JSON and bytes as base64.
+// The response is output as indented JSON.
+func Service(conn upstream.ClientConnInterface, sd protoreflect.ServiceDescriptor) *cmds.Command {
+	name := strings.ToLower(string(sd.Name()))
+	cmd := &cmds.Command{
+		Name:      name,
+		ShortDesc: "call the methods of " + string(sd.FullName()),
+		Flags:     flag.NewFlagSet(name, flag.ContinueOnError),
+	}
+	cmd.Flags.Usage = cmd.DefaultUsage()
+
+	methods := sd.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		md := methods.Get(i)
+		if md.IsStreamingClient() || md.IsStreamingServer() {
+			continue
+		}
+		cmd.Commands = append(cmd.Commands, method(conn, md))
+	}
+	return cmd
+}
+
+func method(conn upstream.ClientConnInterface, md protoreflect.MethodDescriptor) *cmds.Command {
+	name := kebabCase(string(md.Name()))
+	cmd := &cmds.Command{
+		Name:      name,
+		ShortDesc: fmt.Sprintf("call %s", md.FullName()),
+		Args:      cmds.ExactArgs(0),
+		Flags:     flag.NewFlagSet(name, flag.ContinueOnError),
+	}
+	cmd.Flags.Usage = cmd.DefaultUsage()
+
+	input := md.Input()
+	fields := input.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		flagName := strings.ReplaceAll(string(fd.Name()), "_", "-")
+		cmd.Flags.String(flagName, "", fieldUsage(fd))
+		if fd.Enum() != nil && !fd.IsList() {
+			evs := fd.Enum().Values()
+			for j := 0; j < evs.Len(); j++ {
+				cmd.Meta(flagName).Choices = append(cmd.Meta(flagName).Choices, string(evs.Get(j).Name()))
+			}
+		}
+	}
+
+	fullMethod := fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name())
+	cmd.Runner = func(cmd *cmds.Command, args []string) error {
+		obj := make(map[string]json.RawMessage)
+		var err error
+		cmd.Flags.Visit(func(f *flag.Flag) {
+			if err != nil {
+				return
+			}
+			fd := fields.ByName(protoreflect.Name(strings.ReplaceAll(f.Name, "-", "_")))
+			value := f.Value.String()
+			var raw json.RawMessage
+			if raw, err = fieldJSON(fd, value); err != nil {
+				err = fmt.Errorf("invalid value \"%s\" for flag -%s: %w", value, f.Name, err)
+				return
+			}
+			obj[fd.JSONName()] = raw
+		})
+		if err != nil {
+			return err
+		}
+
+		b, err := json.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		req := dynamicpb.NewMessage(input)
+		if err := protojson.Unmarshal(b, req); err != nil {
+			return err
+		}
+		resp := dynamicpb.NewMessage(md.Output())
+		if err := conn.Invoke(cmd.Context(), fullMethod, req, resp); err != nil {
+			return err
+		}
+
+		out, err := protojson.MarshalOptions{Multiline: true}.Marshal(resp)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(cmd.Output(), "%s\n", out)
+		return err
+	}
+
+	return cmd
+}
+
+// fieldUsage returns the usage of the flag for fd, which is it's type.
+func fieldUsage(fd protoreflect.FieldDescriptor) string {
+	switch {
+	case fd.IsMap():
+		return "map as JSON"
+	case fd.IsList():
+		return fmt.Sprintf("repeated %s as JSON", fd.Kind())
+	case fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind:
+		return fmt.Sprintf("%s as JSON", fd.Message().FullName())
+	case fd.Kind() == protoreflect.BytesKind:
+		return "bytes as base64"
+	}
+	return fd.Kind().String()
+}
+
+// fieldJSON returns the JSON of the flag value s for fd, as protojson expects
+// it.
+func fieldJSON(fd protoreflect.FieldDescriptor, s string) (json.RawMessage, error) {
+	switch {
+	case fd.IsMap() || fd.IsList() || fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind:
+		if !json.Valid([]byte(s)) {
+			return nil, errors.New("invalid JSON")
+		}
+		return json.RawMessage(s), nil
+	case fd.Kind() == protoreflect.BoolKind:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(b)
+	case fd.Kind() == protoreflect.BytesKind:
+		if _, err := base64.StdEncoding.DecodeString(s); err != nil {
+			return nil, err
+		}
+	}
+	// protojson accepts numbers and enums as strings too.
+	return json.Marshal(s)
+}
+
+// Reflect returns the service descriptors of the services that the server at
+// conn lists with it's reflection service, except for the reflection service
+// itself.
+func Reflect(ctx context.Context, conn upstream.ClientConnInterface) ([]protoreflect.ServiceDescriptor, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	request := func(req *reflectionpb.ServerReflectionRequest) (*reflectionpb.ServerReflectionResponse, error) {
+		if err := stream.Send(req); err != nil {
+			return nil, err
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		if e := resp.GetErrorResponse(); e != nil {
+			return nil, fmt.Errorf("reflection: %s", e.GetErrorMessage())
+		}
+		return resp, nil
+	}
+
+	resp, err := request(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, s := range resp.GetListServicesResponse().GetService() {
+		if !strings.HasPrefix(s.GetName(), "grpc.reflection.") {
+			names = append(names, s.GetName())
+		}
+	}
+
+	// The files of the services and the ones they import.
+	files := make(map[string]*descriptorpb.FileDescriptorProto)
+	add := func(resp *reflectionpb.ServerReflectionResponse) ([]string, error) {
+		var deps []string
+		for _, b := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			fdp := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(b, fdp); err != nil {
+				return nil, err
+			}
+			if _, ok := files[fdp.GetName()]; ok {
+				continue
+			}
+			files[fdp.GetName()] = fdp
+			deps = append(deps, fdp.GetDependency()...)
+		}
+		return deps, nil
+	}
+
+	var pending []string
+	for _, name := range names {
+		resp, err := request(&reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: name},
+		})
+		if err != nil {
+			return nil, err
+		}
+		deps, err := add(resp)
+		if err != nil {
+			return nil, err
+		}
+		pending = append(pending, deps...)
+	}
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		if _, ok := files[name]; ok {
+			continue
+		}
+		resp, err := request(&reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: name},
+		})
+		if err != nil {
+			return nil, err
+		}
+		deps, err := add(resp)
+		if err != nil {
+			return nil, err
+		}
+		pending = append(pending, deps...)
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fdp := range files {
+		set.File = append(set.File, fdp)
+	}
+	reg, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, err
+	}
+	return services(reg, names)
+}
+
+// LoadDescriptorSet returns the service descriptors in the FileDescriptorSet
+// file at path, as written by protoc --descriptor_set_out with
+// --include_imports.
+func LoadDescriptorSet(path string) ([]protoreflect.ServiceDescriptor, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(b, set); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	reg, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var names []string
+	reg.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		for i := 0; i < fd.Services().Len(); i++ {
+			names = append(names, string(fd.Services().Get(i).FullName()))
+		}
+		return true
+	})
+	return services(reg, names)
+}
+
+func services(reg *protoregistry.Files, names []string) ([]protoreflect.ServiceDescriptor, error) {
+	var sds []protoreflect.ServiceDescriptor
+	for _, name := range names {
+		d, err := reg.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			return nil, err
+		}
+		sd, ok := d.(protoreflect.ServiceDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("%s isn't a service", name)
+		}
+		sds = append(sds, sd)
+	}
+	return sds, nil
+}
+
+// kebabCase returns s in lower case with a "-" before the upper case letters
+// that start a word, like "get-feature" for "GetFeature".
+func kebabCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if i > 0 && r >= 'A' && r <= 'Z' && !(s[i-1] >= 'A' && s[i-1] <= 'Z') {
+			b.WriteByte('-')
+		}
+		b.WriteString(strings.ToLower(string(r)))
+	}
+	return b.String()
+}
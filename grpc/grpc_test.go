@@ -0,0 +1,119 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/rgzlv/cmds"
+	upstream "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func testConn(t *testing.T) *upstream.ClientConn {
+	lis := bufconn.Listen(1 << 20)
+	srv := upstream.NewServer()
+	hs := health.NewServer()
+	hs.SetServingStatus("test", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, hs)
+	reflection.Register(srv)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := upstream.NewClient("passthrough:///bufnet",
+		upstream.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		upstream.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestService(t *testing.T) {
+	conn := testConn(t)
+	sds, err := Reflect(context.Background(), conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sds) != 1 || sds[0].FullName() != "grpc.health.v1.Health" {
+		t.Fatalf("expected the health service, got %v", sds)
+	}
+
+	newCmd := func() (*cmds.Command, *bytes.Buffer) {
+		cmd := Service(conn, sds[0])
+		out := &bytes.Buffer{}
+		cmd.Stdout = out
+		return cmd, out
+	}
+
+	cmd, out := newCmd()
+	var names []string
+	for _, sub := range cmd.Commands {
+		names = append(names, sub.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"check", "list"}) {
+		t.Fatalf("expected only the unary methods, got %v", names)
+	}
+	if err := cmd.ParseRun([]string{"check", "-service", "test"}); err != nil {
+		t.Fatal(err)
+	}
+	var resp map[string]string
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil || resp["status"] != "SERVING" {
+		t.Errorf("expected SERVING, got %s", out)
+	}
+
+	cmd, _ = newCmd()
+	cmd.SetErrOutput(&bytes.Buffer{})
+	if err := cmd.ParseRun([]string{"check", "-service", "unknown"}); err == nil || errors.Is(err, cmds.Err) {
+		t.Errorf("expected an error from the call, got %v", err)
+	}
+
+	// The flags of the copies of ParseArgs are used too.
+	cmd, _ = newCmd()
+	r, err := cmd.ParseArgs([]string{"check", "-service", "unknown"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Run(context.Background()); err == nil {
+		t.Error("expected an error from the call for the unknown service")
+	}
+}
+
+func TestLoadDescriptorSet(t *testing.T) {
+	set := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{protodesc.ToFileDescriptorProto(healthpb.File_grpc_health_v1_health_proto)},
+	}
+	b, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "health.pb")
+	if err := os.WriteFile(path, b, 0o666); err != nil {
+		t.Fatal(err)
+	}
+
+	sds, err := LoadDescriptorSet(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sds) != 1 || sds[0].FullName() != "grpc.health.v1.Health" {
+		t.Errorf("expected the health service, got %v", sds)
+	}
+}
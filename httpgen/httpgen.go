@@ -0,0 +1,424 @@
+// Package httpgen makes commands for the operations of an HTTP API from it's
+// OpenAPI 3 document, like the req command of the example but with a
+// sub-command and flags for every operation.
+//
+// Only documents in JSON are supported, so that the package doesn't depend on
+// a YAML decoder, local references like "#/components/schemas/Pet" are
+// resolved.
+package httpgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/rgzlv/cmds"
+)
+
+// Options are the options for [New].
+type Options struct {
+	// BaseURL is the URL that the paths of the operations are relative to,
+	// if it's empty the URL of the first server in the document is used.
+	BaseURL string
+
+	// Client makes the requests, if it's nil [http.DefaultClient] is used.
+	Client *http.Client
+
+	// Header is added to every request, like for authorization.
+	Header http.Header
+}
+
+// New returns a command named name with a sub-command for every operation in
+// the OpenAPI document read from r.
+// The sub-commands are named after the operationId in kebab case, or the
+// method and path of the operation if it has none, their flags are the
+// parameters and the properties of the JSON request body, required ones are
+// marked as required and enums are the choices of the flag.
+// The response body is written to the output of the command, a response with a
+// status other than 2xx is an error.
+func New(name string, r io.Reader, opts Options) (*cmds.Command, error) {
+	var doc document
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("httpgen: %w", err)
+	}
+
+	baseURL := opts.BaseURL
+	if baseURL == "" && len(doc.Servers) > 0 {
+		baseURL = doc.Servers[0].URL
+	}
+	if baseURL == "" {
+		return nil, errors.New("httpgen: no base URL")
+	}
+	client := opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	cmd := &cmds.Command{
+		Name:      name,
+		ShortDesc: doc.Info.Title,
+		LongDesc:  doc.Info.Description,
+		Flags:     flag.NewFlagSet(name, flag.ContinueOnError),
+	}
+	cmd.Flags.Usage = cmd.DefaultUsage()
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		item := doc.Paths[path]
+		for _, method := range methods {
+			op, ok := item[strings.ToLower(method)]
+			if !ok {
+				continue
+			}
+			var pathParams []parameter
+			if raw, ok := item["parameters"]; ok {
+				if err := json.Unmarshal(raw, &pathParams); err != nil {
+					return nil, fmt.Errorf("httpgen: parameters of %s: %w", path, err)
+				}
+			}
+			sub, err := doc.command(method, path, op, pathParams, baseURL, client, opts.Header)
+			if err != nil {
+				return nil, fmt.Errorf("httpgen: %s %s: %w", method, path, err)
+			}
+			cmd.Commands = append(cmd.Commands, sub)
+		}
+	}
+
+	return cmd, nil
+}
+
+var methods = []string{
+	http.MethodGet,
+	http.MethodPut,
+	http.MethodPost,
+	http.MethodDelete,
+	http.MethodOptions,
+	http.MethodHead,
+	http.MethodPatch,
+	http.MethodTrace,
+}
+
+type document struct {
+	Info struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+	} `json:"info"`
+	Servers []struct {
+		URL string `json:"url"`
+	} `json:"servers"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components map[string]map[string]json.RawMessage `json:"components"`
+}
+
+type operation struct {
+	OperationID string      `json:"operationId"`
+	Summary     string      `json:"summary"`
+	Description string      `json:"description"`
+	Parameters  []parameter `json:"parameters"`
+	RequestBody *struct {
+		Ref      string `json:"$ref"`
+		Required bool   `json:"required"`
+		Content  map[string]struct {
+			Schema schema `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+}
+
+type parameter struct {
+	Ref         string `json:"$ref"`
+	Name        string `json:"name"`
+	In          string `json:"in"`
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+	Schema      schema `json:"schema"`
+}
+
+type schema struct {
+	Ref         string            `json:"$ref"`
+	Type        string            `json:"type"`
+	Description string            `json:"description"`
+	Enum        []any             `json:"enum"`
+	Default     any               `json:"default"`
+	Properties  map[string]schema `json:"properties"`
+	Required    []string          `json:"required"`
+}
+
+// resolve unmarshals the component that ref refers to into v.
+func (doc *document) resolve(ref string, v any) error {
+	parts := strings.Split(strings.TrimPrefix(ref, "#/components/"), "/")
+	if !strings.HasPrefix(ref, "#/components/") || len(parts) != 2 {
+		return fmt.Errorf("unsupported reference \"%s\"", ref)
+	}
+	raw, ok := doc.Components[parts[0]][parts[1]]
+	if !ok {
+		return fmt.Errorf("undefined reference \"%s\"", ref)
+	}
+	return json.Unmarshal(raw, v)
+}
+
+func (doc *document) resolveSchema(s schema) (schema, error) {
+	for depth := 0; s.Ref != ""; depth++ {
+		if depth == 16 {
+			return s, fmt.Errorf("reference \"%s\" nested too deeply", s.Ref)
+		}
+		var next schema
+		if err := doc.resolve(s.Ref, &next); err != nil {
+			return s, err
+		}
+		s = next
+	}
+	return s, nil
+}
+
+func (doc *document) resolveParameter(p parameter) (parameter, error) {
+	if p.Ref != "" {
+		var next parameter
+		if err := doc.resolve(p.Ref, &next); err != nil {
+			return p, err
+		}
+		p = next
+	}
+	var err error
+	p.Schema, err = doc.resolveSchema(p.Schema)
+	return p, err
+}
+
+// input is where the value of a flag goes in the request.
+type input struct {
+	in     string
+	name   string
+	schema schema
+}
+
+func (doc *document) command(method, path string, raw json.RawMessage, pathParams []parameter, baseURL string, client *http.Client, header http.Header) (*cmds.Command, error) {
+	var op operation
+	if err := json.Unmarshal(raw, &op); err != nil {
+		return nil, err
+	}
+
+	name := kebabCase(op.OperationID)
+	if name == "" {
+		name = strings.ToLower(method) + strings.ReplaceAll(strings.NewReplacer("{", "", "}", "").Replace(path), "/", "-")
+	}
+	sub := &cmds.Command{
+		Name:      name,
+		ShortDesc: op.Summary,
+		LongDesc:  op.Description,
+		Args:      cmds.ExactArgs(0),
+		Flags:     flag.NewFlagSet(name, flag.ContinueOnError),
+	}
+	sub.Flags.Usage = sub.DefaultUsage()
+
+	var inputs []*input
+	addFlag := func(in, name string, s schema, usage string, required bool) error {
+		if sub.Flags.Lookup(name) != nil {
+			return fmt.Errorf("more than one input named \"%s\"", name)
+		}
+		i := &input{in: in, name: name, schema: s}
+		def := ""
+		if s.Default != nil {
+			def = fmt.Sprint(s.Default)
+		}
+		if usage == "" {
+			usage = s.Description
+		}
+		sub.Flags.String(name, def, usage)
+		meta := sub.Meta(name)
+		meta.Required = required
+		for _, e := range s.Enum {
+			meta.Choices = append(meta.Choices, fmt.Sprint(e))
+		}
+		inputs = append(inputs, i)
+		return nil
+	}
+
+	params := make(map[string]parameter)
+	var order []string
+	for _, p := range append(pathParams, op.Parameters...) {
+		p, err := doc.resolveParameter(p)
+		if err != nil {
+			return nil, err
+		}
+		key := p.In + "/" + p.Name
+		if _, ok := params[key]; !ok {
+			order = append(order, key)
+		}
+		params[key] = p
+	}
+	for _, key := range order {
+		p := params[key]
+		if err := addFlag(p.In, p.Name, p.Schema, p.Description, p.Required || p.In == "path"); err != nil {
+			return nil, err
+		}
+	}
+
+	if op.RequestBody != nil {
+		if op.RequestBody.Ref != "" {
+			if err := doc.resolve(op.RequestBody.Ref, op.RequestBody); err != nil {
+				return nil, err
+			}
+		}
+		if content, ok := op.RequestBody.Content["application/json"]; ok {
+			s, err := doc.resolveSchema(content.Schema)
+			if err != nil {
+				return nil, err
+			}
+			required := make(map[string]bool)
+			for _, name := range s.Required {
+				required[name] = op.RequestBody.Required
+			}
+			props := make([]string, 0, len(s.Properties))
+			for name := range s.Properties {
+				props = append(props, name)
+			}
+			sort.Strings(props)
+			for _, name := range props {
+				prop, err := doc.resolveSchema(s.Properties[name])
+				if err != nil {
+					return nil, err
+				}
+				if err := addFlag("body", name, prop, "", required[name]); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	sub.Runner = func(cmd *cmds.Command, args []string) error {
+		req, err := newRequest(cmd, method, baseURL, path, inputs)
+		if err != nil {
+			return err
+		}
+		for key, values := range header {
+			req.Header[key] = append(req.Header[key], values...)
+		}
+
+		resp, err := client.Do(req.WithContext(cmd.Context()))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if _, err := io.Copy(cmd.Output(), resp.Body); err != nil {
+			return err
+		}
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("%s %s: %s", method, req.URL, resp.Status)
+		}
+		return nil
+	}
+
+	return sub, nil
+}
+
+// newRequest returns the request for the values of the flags of cmd.
+func newRequest(cmd *cmds.Command, method, baseURL, path string, inputs []*input) (*http.Request, error) {
+	set := make(map[string]bool)
+	cmd.Flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	query := url.Values{}
+	header := http.Header{}
+	body := make(map[string]any)
+	for _, i := range inputs {
+		// The value is looked up so that the copies of ParseArgs work too.
+		value := cmd.Flags.Lookup(i.name).Value.String()
+		if !set[i.name] && value == "" {
+			continue
+		}
+		switch i.in {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+i.name+"}", url.PathEscape(value))
+		case "query":
+			query.Add(i.name, value)
+		case "header":
+			header.Add(i.name, value)
+		case "cookie":
+			header.Add("Cookie", (&http.Cookie{Name: i.name, Value: value}).String())
+		case "body":
+			if !set[i.name] {
+				continue
+			}
+			v, err := jsonValue(i.schema.Type, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value \"%s\" for flag -%s: %w", value, i.name, err)
+			}
+			body[i.name] = v
+		}
+	}
+
+	u := strings.TrimSuffix(baseURL, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var r io.Reader
+	if len(body) > 0 {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, u, r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header
+	if r != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+// jsonValue returns the value of s as the JSON type typ, objects and arrays
+// are given as JSON.
+func jsonValue(typ, s string) (any, error) {
+	switch typ {
+	case "integer":
+		return strconv.ParseInt(s, 10, 64)
+	case "number":
+		return strconv.ParseFloat(s, 64)
+	case "boolean":
+		return strconv.ParseBool(s)
+	case "object", "array":
+		var v any
+		err := json.Unmarshal([]byte(s), &v)
+		return v, err
+	}
+	return s, nil
+}
+
+// kebabCase returns s in lower case with a "-" before the upper case letters
+// that start a word and instead of "_", like "get-pet-by-id" for "getPetById".
+func kebabCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if r == '_' || r == ' ' {
+			b.WriteByte('-')
+			continue
+		}
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			next := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && next) {
+				b.WriteByte('-')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,142 @@
+package httpgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rgzlv/cmds"
+)
+
+const testDoc = `{
+	"openapi": "3.0.0",
+	"info": {"title": "pet store"},
+	"paths": {
+		"/pets/{petId}": {
+			"parameters": [{"name": "petId", "in": "path", "schema": {"type": "string"}}],
+			"get": {
+				"operationId": "getPetById",
+				"summary": "get a pet",
+				"parameters": [{"$ref": "#/components/parameters/verbose"}]
+			}
+		},
+		"/pets": {
+			"post": {
+				"operationId": "addPet",
+				"requestBody": {
+					"required": true,
+					"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}
+				}
+			}
+		}
+	},
+	"components": {
+		"parameters": {
+			"verbose": {"name": "verbose", "in": "query", "schema": {"type": "string", "enum": ["yes", "no"], "default": "no"}}
+		},
+		"schemas": {
+			"Pet": {
+				"type": "object",
+				"required": ["name"],
+				"properties": {
+					"name": {"type": "string"},
+					"age": {"type": "integer"},
+					"tags": {"type": "array"}
+				}
+			}
+		}
+	}
+}`
+
+func TestNew(t *testing.T) {
+	type request struct {
+		Method, URL string
+		Body        map[string]any
+	}
+	var got request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = request{Method: r.Method, URL: r.URL.String()}
+		if r.Body != nil {
+			json.NewDecoder(r.Body).Decode(&got.Body)
+		}
+		if r.Header.Get("Authorization") != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+		io.WriteString(w, "response")
+	}))
+	defer srv.Close()
+
+	header := http.Header{"Authorization": {"token"}}
+	newCmd := func() (*cmds.Command, *bytes.Buffer) {
+		cmd, err := New("pets", strings.NewReader(testDoc), Options{BaseURL: srv.URL, Header: header})
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := &bytes.Buffer{}
+		cmd.Stdout = out
+		cmd.SetErrOutput(io.Discard)
+		return cmd, out
+	}
+
+	cmd, out := newCmd()
+	if cmd.ShortDesc != "pet store" || len(cmd.Commands) != 2 || cmd.Commands[0].Name != "add-pet" || cmd.Commands[1].Name != "get-pet-by-id" {
+		t.Fatalf("unexpected commands %+v", cmd.Commands)
+	}
+	if err := cmd.ParseRun([]string{"get-pet-by-id", "-petId", "a b"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := (request{Method: "GET", URL: "/pets/a%20b?verbose=no"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+	if out.String() != "response" {
+		t.Errorf("expected the response body to be output, got %q", out)
+	}
+
+	cmd, _ = newCmd()
+	if err := cmd.ParseRun([]string{"add-pet", "-name", "rex", "-age", "3", "-tags", `["a"]`}); err != nil {
+		t.Fatal(err)
+	}
+	want := request{Method: "POST", URL: "/pets", Body: map[string]any{"name": "rex", "age": 3.0, "tags": []any{"a"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	// The flags of the copies of ParseArgs are used too.
+	cmd, _ = newCmd()
+	r, err := cmd.ParseArgs([]string{"get-pet-by-id", "-petId", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := (request{Method: "GET", URL: "/pets/b?verbose=no"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	cmd, _ = newCmd()
+	if err := cmd.ParseRun([]string{"add-pet", "-age", "3"}); !errors.Is(err, cmds.ErrFlagRequired) {
+		t.Errorf("expected ErrFlagRequired, got %v", err)
+	}
+	cmd, _ = newCmd()
+	if err := cmd.ParseRun([]string{"get-pet-by-id", "-petId", "a", "-verbose", "maybe"}); !errors.Is(err, cmds.ErrFlagChoice) {
+		t.Errorf("expected ErrFlagChoice, got %v", err)
+	}
+
+	header = nil
+	cmd, _ = newCmd()
+	if err := cmd.ParseRun([]string{"add-pet", "-name", "rex"}); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("expected an error for the status, got %v", err)
+	}
+
+	if _, err := New("pets", strings.NewReader(`{"paths": {}}`), Options{}); err == nil {
+		t.Error("expected an error without a base URL")
+	}
+}
@@ -0,0 +1,29 @@
+package cmds
+
+import (
+	"context"
+	"time"
+)
+
+// Instrumentation is notified when [Command.Parse] and the Runner of a
+// command start and end, with the context and duration, so that invocations
+// can be traced or measured, see Command.Instrumentation.
+//
+// The context returned by ParseStart is only passed to ParseEnd, the one
+// returned by RunStart is passed to RunEnd and is the context of the Runner,
+// so that it can be used for the spans of the work done by the Runner.
+type Instrumentation interface {
+	// ParseStart is called before parsing args with the root command.
+	ParseStart(ctx context.Context, cmd *Command, args []string) context.Context
+
+	// ParseEnd is called after parsing with the leaf command that matched or
+	// the command that parsing failed at along with the error.
+	ParseEnd(ctx context.Context, cmd *Command, d time.Duration, err error)
+
+	// RunStart is called before running the Runner of the leaf command.
+	RunStart(ctx context.Context, cmd *Command, args []string) context.Context
+
+	// RunEnd is called after the Runner returns, with the error it returned
+	// or the [PanicError] if it panicked and RecoverPanics is set.
+	RunEnd(ctx context.Context, cmd *Command, d time.Duration, err error)
+}
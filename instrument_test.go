@@ -0,0 +1,64 @@
+package cmds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testInstrumentation struct {
+	events []string
+}
+
+type instrumentKey struct{}
+
+func (in *testInstrumentation) ParseStart(ctx context.Context, cmd *Command, args []string) context.Context {
+	in.events = append(in.events, fmt.Sprintf("parse start %s %v", cmd.Name, args))
+	return context.WithValue(ctx, instrumentKey{}, "parse")
+}
+
+func (in *testInstrumentation) ParseEnd(ctx context.Context, cmd *Command, d time.Duration, err error) {
+	in.events = append(in.events, fmt.Sprintf("parse end %s %s %v", ctx.Value(instrumentKey{}), strings.Join(cmd.Path(), " "), err != nil))
+}
+
+func (in *testInstrumentation) RunStart(ctx context.Context, cmd *Command, args []string) context.Context {
+	in.events = append(in.events, fmt.Sprintf("run start %s", strings.Join(cmd.Path(), " ")))
+	return context.WithValue(ctx, instrumentKey{}, "run")
+}
+
+func (in *testInstrumentation) RunEnd(ctx context.Context, cmd *Command, d time.Duration, err error) {
+	in.events = append(in.events, fmt.Sprintf("run end %s %v", ctx.Value(instrumentKey{}), err))
+}
+
+func TestInstrumentation(t *testing.T) {
+	in := &testInstrumentation{}
+	cmd := &Command{
+		Name:            "test",
+		Instrumentation: in,
+		Commands: []*Command{
+			{Name: "sub", Runner: func(cmd *Command, args []string) error {
+				in.events = append(in.events, fmt.Sprintf("runner %s", cmd.Context().Value(instrumentKey{})))
+				return errors.New("failed")
+			}},
+		},
+	}
+
+	expectError(t, cmd.ParseRun([]string{"sub", "a"}))
+	expectEq(t, in.events, []string{
+		"parse start test [sub a]",
+		"parse end parse test sub false",
+		"run start test sub",
+		"runner run",
+		"run end run failed",
+	})
+
+	in.events = nil
+	expectError(t, cmd.ParseRun([]string{"invalid"}))
+	expectEq(t, in.events, []string{
+		"parse start test [invalid]",
+		"parse end parse test true",
+	})
+}
@@ -0,0 +1,188 @@
+package cmds
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"strconv"
+
+	"github.com/rgzlv/cmds/ui"
+)
+
+// AddLogFlags adds the -log-level flag that sets the level of
+// [Command.Logger] of cmd and it's sub-commands, the -v flag that sets it
+// to debug and the -q and -quiet flags that set it to warn to the flags of
+// cmd, creating them if there are none.
+// The -q flag also silences the informational output of cmd and it's
+// sub-commands, see [Command.IsQuiet].
+// The logger outputs text to [Command.ErrOutput] at the info level by
+// default.
+func (cmd *Command) AddLogFlags() {
+	if cmd.Flags == nil {
+		cmd.Flags = flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+		cmd.Flags.Usage = cmd.DefaultUsage()
+	}
+
+	if cmd.logLevel == nil {
+		cmd.logLevel = &slog.LevelVar{}
+	}
+	if cmd.logger == nil {
+		cmd.logger = newLogFlagsLogger(cmd)
+		cmd.logFlagsLogger = true
+	}
+
+	m := cmd.messages()
+	cmd.Flags.Var(levelFlag{cmd.logLevel}, "log-level", m.LogLevelUsage)
+	cmd.Flags.Var(verboseFlag{cmd.logLevel}, "v", m.VerboseUsage)
+	cmd.Flags.Var(quietFlag{cmd.logLevel}, "q", m.QuietUsage)
+	cmd.Flags.Var(quietFlag{cmd.logLevel}, "quiet", m.QuietUsage)
+}
+
+// Progress returns a [ui.Progress] for a task of total steps that outputs to
+// [Command.ErrOutput], it's quiet if the [Command.Logger] of cmd doesn't log
+// at the info level, see [Command.IsQuiet].
+func (cmd *Command) Progress(label string, total int) *ui.Progress {
+	p := ui.NewProgress(cmd.ErrOutput(), label, total)
+	p.Quiet = cmd.IsQuiet()
+	return p
+}
+
+// Spinner returns a [ui.Spinner] that outputs to [Command.ErrOutput], it's
+// quiet the same way as [Command.Progress].
+func (cmd *Command) Spinner(label string) *ui.Spinner {
+	s := ui.NewSpinner(cmd.ErrOutput(), label)
+	s.Quiet = cmd.IsQuiet()
+	return s
+}
+
+// IsQuiet reports whether the [Command.Logger] of cmd doesn't log at the info
+// level, like with the -q flag of [Command.AddLogFlags], in which case
+// [Command.Infof], [Command.Progress] and [Command.Spinner] don't output
+// anything.
+// The data that's output, like with [Command.Print], warnings and errors
+// aren't affected.
+func (cmd *Command) IsQuiet() bool {
+	return !cmd.Logger().Enabled(context.Background(), slog.LevelInfo)
+}
+
+// SetLogger sets the logger returned by [Command.Logger] for cmd and it's
+// sub-commands, the flags added with [Command.AddLogFlags] don't affect it.
+func (cmd *Command) SetLogger(logger *slog.Logger) {
+	cmd.logger = logger
+	cmd.logFlagsLogger = false
+}
+
+// newLogFlagsLogger returns the logger of [Command.AddLogFlags] for cmd, which
+// logs at the level of cmd.logLevel.
+func newLogFlagsLogger(cmd *Command) *slog.Logger {
+	return slog.New(slog.NewTextHandler(errWriter{cmd}, &slog.HandlerOptions{Level: cmd.logLevel}))
+}
+
+// Logger returns the logger of cmd or the closest of it's parents that has
+// one, set with [Command.AddLogFlags] or [Command.SetLogger], or
+// [slog.Default] if none has.
+func (cmd *Command) Logger() *slog.Logger {
+	for c := cmd; c != nil; c = c.parent {
+		if c.logger != nil {
+			return c.logger
+		}
+	}
+	return slog.Default()
+}
+
+// errWriter writes to the [Command.ErrOutput] of cmd at the time of writing,
+// since it can change after the logger is created.
+type errWriter struct {
+	cmd *Command
+}
+
+func (w errWriter) Write(p []byte) (int, error) {
+	return w.cmd.ErrOutput().Write(p)
+}
+
+// levelFlag is the value of the -log-level flag, it accepts the level names
+// of [slog.Level.UnmarshalText] like "debug" or "warn".
+type levelFlag struct {
+	level *slog.LevelVar
+}
+
+func (f levelFlag) String() string {
+	if f.level == nil {
+		return slog.LevelInfo.String()
+	}
+	return f.level.Level().String()
+}
+
+func (f levelFlag) Set(s string) error {
+	return f.level.UnmarshalText([]byte(s))
+}
+
+func (f levelFlag) Get() any {
+	return f.level.Level()
+}
+
+// verboseFlag is the value of the -v flag.
+type verboseFlag struct {
+	level *slog.LevelVar
+}
+
+func (f verboseFlag) IsBoolFlag() bool {
+	return true
+}
+
+func (f verboseFlag) String() string {
+	if f.level == nil {
+		return "false"
+	}
+	return strconv.FormatBool(f.level.Level() <= slog.LevelDebug)
+}
+
+func (f verboseFlag) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	if v {
+		f.level.Set(slog.LevelDebug)
+	} else if f.level.Level() <= slog.LevelDebug {
+		f.level.Set(slog.LevelInfo)
+	}
+	return nil
+}
+
+func (f verboseFlag) Get() any {
+	return f.level.Level() <= slog.LevelDebug
+}
+
+// quietFlag is the value of the -q flag.
+type quietFlag struct {
+	level *slog.LevelVar
+}
+
+func (f quietFlag) IsBoolFlag() bool {
+	return true
+}
+
+func (f quietFlag) String() string {
+	if f.level == nil {
+		return "false"
+	}
+	return strconv.FormatBool(f.level.Level() >= slog.LevelWarn)
+}
+
+func (f quietFlag) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	if v {
+		f.level.Set(slog.LevelWarn)
+	} else if f.level.Level() >= slog.LevelWarn {
+		f.level.Set(slog.LevelInfo)
+	}
+	return nil
+}
+
+func (f quietFlag) Get() any {
+	return f.level.Level() >= slog.LevelWarn
+}
@@ -0,0 +1,98 @@
+package cmds
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	var b strings.Builder
+	cmd := &Command{
+		Name:   "test",
+		Stderr: &b,
+		Commands: []*Command{
+			{Name: "sub", Runner: func(cmd *Command, args []string) error {
+				cmd.Logger().Debug("debug")
+				cmd.Logger().Info("info")
+				cmd.Logger().Warn("warn")
+				return nil
+			}},
+		},
+	}
+	expectEq(t, cmd.Logger(), slog.Default())
+	cmd.AddLogFlags()
+
+	levels := func() string {
+		t.Helper()
+		var levels []string
+		for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+			if i := strings.Index(line, "level="); i >= 0 {
+				levels = append(levels, strings.Fields(line[i:])[0])
+			}
+		}
+		b.Reset()
+		return strings.Join(levels, " ")
+	}
+
+	expectErrorNone(t, cmd.ParseRun([]string{"sub"}))
+	expectEq(t, levels(), "level=INFO level=WARN")
+
+	expectErrorNone(t, cmd.ParseRun([]string{"-v", "sub"}))
+	expectEq(t, levels(), "level=DEBUG level=INFO level=WARN")
+
+	cmd.Reset()
+	expectErrorNone(t, cmd.ParseRun([]string{"-log-level", "warn", "sub"}))
+	expectEq(t, levels(), "level=WARN")
+	expectEq(t, cmd.Flags.Lookup("log-level").DefValue, "INFO")
+	expectEq(t, cmd.Flags.Lookup("v").DefValue, "false")
+
+	cmd.Reset()
+	expectErrorNone(t, cmd.ParseRun([]string{"-q", "sub"}))
+	expectEq(t, levels(), "level=WARN")
+
+	cmd.Reset()
+	expectErrorIs(t, cmd.ParseRun([]string{"-log-level", "loud", "sub"}), ErrFlag)
+	b.Reset()
+
+	// The copies of ParseArgs have their own level.
+	cmd.Reset()
+	r, err := cmd.ParseArgs([]string{"-v", "sub"})
+	expectErrorNone(t, err)
+	expectErrorNone(t, r.Run(context.Background()))
+	expectEq(t, levels(), "level=DEBUG level=INFO level=WARN")
+	expectEq(t, cmd.logLevel.Level(), slog.LevelInfo)
+	expectErrorNone(t, cmd.ParseRun([]string{"sub"}))
+	expectEq(t, levels(), "level=INFO level=WARN")
+
+	logger := slog.New(slog.NewTextHandler(&b, nil))
+	cmd.Commands[0].SetLogger(logger)
+	expectEq(t, cmd.Commands[0].Logger(), logger)
+}
+
+func TestProgress(t *testing.T) {
+	var b strings.Builder
+	cmd := &Command{
+		Name:   "test",
+		Stderr: &b,
+		Runner: func(cmd *Command, args []string) error {
+			p := cmd.Progress("copying", 2)
+			p.Add(2)
+			p.Done()
+			s := cmd.Spinner("waiting")
+			s.Start()
+			s.Stop("done")
+			return nil
+		},
+	}
+	cmd.AddLogFlags()
+
+	expectErrorNone(t, cmd.ParseRun(nil))
+	expectEq(t, b.String(), "copying: 2/2 100%\nwaiting...\nwaiting: done\n")
+
+	b.Reset()
+	cmd.Reset()
+	expectErrorNone(t, cmd.ParseRun([]string{"-q"}))
+	expectEq(t, b.String(), "")
+}
@@ -0,0 +1,151 @@
+package cmds
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Manifest is the JSON description of a command that [LoadManifest] builds a
+// [Command] from, the fields are the same as the ones of Command with the
+// same name.
+type Manifest struct {
+	Name      string   `json:"name"`
+	Aliases   []string `json:"aliases,omitempty"`
+	ShortDesc string   `json:"shortDesc,omitempty"`
+	LongDesc  string   `json:"longDesc,omitempty"`
+	ArgsUsage string   `json:"argsUsage,omitempty"`
+	Hidden    bool     `json:"hidden,omitempty"`
+
+	Flags []ManifestFlag `json:"flags,omitempty"`
+
+	// Exec is the program and it's arguments that the command runs, each of
+	// them is a [text/template] executed with the flag values as .Flags, a map
+	// of the flag names to their values, and the arguments as .Args.
+	// An element that is exactly "{{.Args}}" is replaced by the arguments
+	// instead, one element each.
+	// Commands without Exec have no Runner.
+	Exec []string `json:"exec,omitempty"`
+
+	Commands []*Manifest `json:"commands,omitempty"`
+}
+
+// ManifestFlag is the JSON description of a flag in a [Manifest].
+type ManifestFlag struct {
+	Name  string `json:"name"`
+	Usage string `json:"usage,omitempty"`
+
+	// Type is "string", which is the default, or "bool".
+	Type    string `json:"type,omitempty"`
+	Default string `json:"default,omitempty"`
+
+	Required bool     `json:"required,omitempty"`
+	Choices  []string `json:"choices,omitempty"`
+}
+
+// manifestArgs is the element of Manifest.Exec that is replaced by the
+// arguments.
+const manifestArgs = "{{.Args}}"
+
+// LoadManifest builds a command tree from the JSON [Manifest] read from r, so
+// that simple wrapper commands can be defined in data files, the result can
+// be added to an existing tree with [Command.Graft].
+// Unknown fields and invalid templates are errors, so that typos don't go
+// unnoticed.
+// Only JSON is supported so that the package doesn't need any dependencies,
+// YAML manifests can be loaded with LoadManifest of package
+// [github.com/rgzlv/cmds/config].
+func LoadManifest(r io.Reader) (*Command, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var m Manifest
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+	cmd, err := m.command()
+	if err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+	return cmd, nil
+}
+
+// ManifestFile returns a command that's loaded from the JSON [Manifest] in
+// the file at path when it's used, with it's LoadFunc, so that a tree with
+// many of them doesn't read every file to output it's usage message.
+// The command is listed with name and shortDesc until then.
+func ManifestFile(name, shortDesc, path string) *Command {
+	return &Command{
+		Name:      name,
+		ShortDesc: shortDesc,
+		LoadFunc: func() (*Command, error) {
+			f, err := os.Open(path)
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+			return LoadManifest(f)
+		},
+	}
+}
+
+func (m *Manifest) command() (*Command, error) {
+	if m.Name == "" {
+		return nil, errors.New("command without a name")
+	}
+
+	cmd := &Command{
+		Name:      m.Name,
+		Aliases:   m.Aliases,
+		ShortDesc: m.ShortDesc,
+		LongDesc:  m.LongDesc,
+		ArgsUsage: m.ArgsUsage,
+		Hidden:    m.Hidden,
+		Flags:     flag.NewFlagSet(m.Name, flag.ContinueOnError),
+	}
+	cmd.Flags.Usage = cmd.DefaultUsage()
+
+	for _, f := range m.Flags {
+		if f.Name == "" {
+			return nil, fmt.Errorf("flag without a name in command \"%s\"", m.Name)
+		}
+		cmd.DeclareFlags(f.Name)
+		switch f.Type {
+		case "", "string":
+			cmd.Flags.String(f.Name, f.Default, f.Usage)
+		case "bool":
+			def := f.Default == "true"
+			if f.Default != "" && !def && f.Default != "false" {
+				return nil, fmt.Errorf("invalid default \"%s\" for bool flag -%s", f.Default, f.Name)
+			}
+			cmd.Flags.Bool(f.Name, def, f.Usage)
+		default:
+			return nil, fmt.Errorf("invalid type \"%s\" for flag -%s", f.Type, f.Name)
+		}
+		if f.Required || f.Choices != nil {
+			meta := cmd.Meta(f.Name)
+			meta.Required = f.Required
+			meta.Choices = f.Choices
+		}
+	}
+
+	if len(m.Exec) > 0 {
+		runner, err := execRunner(m.Exec, nil)
+		if err != nil {
+			return nil, fmt.Errorf("command \"%s\": %w", m.Name, err)
+		}
+		cmd.Runner = runner
+	}
+
+	for _, sub := range m.Commands {
+		c, err := sub.command()
+		if err != nil {
+			return nil, err
+		}
+		cmd.Commands = append(cmd.Commands, c)
+	}
+
+	return cmd, nil
+}
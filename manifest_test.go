@@ -0,0 +1,75 @@
+package cmds
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestLoadManifest(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("manifest commands need echo")
+	}
+
+	cmd, err := LoadManifest(strings.NewReader(`{
+		"name": "test",
+		"commands": [{
+			"name": "greet",
+			"shortDesc": "greet someone",
+			"argsUsage": "<name...>",
+			"flags": [
+				{"name": "greeting", "default": "hello", "choices": ["hello", "hi"]},
+				{"name": "loud", "type": "bool"}
+			],
+			"exec": ["echo", "{{.Flags.greeting}},", "{{.Args}}", "loud={{.Flags.loud}}"]
+		}]
+	}`))
+	expectErrorNone(t, err)
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	expectEq(t, cmd.Commands[0].ShortDesc, "greet someone")
+	expectErrorNone(t, cmd.ParseRun([]string{"greet", "-greeting", "hi", "a", "b"}))
+	expectEq(t, out.String(), "hi, a b loud=false\n")
+
+	cmd.Reset()
+	expectErrorIs(t, cmd.ParseRun([]string{"greet", "-greeting", "hey"}), ErrFlagChoice)
+
+	for _, manifest := range []string{
+		`{}`,
+		`{"name": "test", "unknown": true}`,
+		`{"name": "test", "flags": [{"name": "n", "type": "int"}]}`,
+		`{"name": "test", "flags": [{"name": "n", "type": "bool", "default": "yes"}]}`,
+		`{"name": "test", "exec": ["{{.Flags"]}`,
+		`{"name": "test", "commands": [{"shortDesc": "no name"}]}`,
+	} {
+		_, err := LoadManifest(strings.NewReader(manifest))
+		if err == nil || !strings.HasPrefix(err.Error(), "manifest: ") {
+			t.Errorf("expected manifest error for %s, got %v", manifest, err)
+		}
+	}
+}
+
+func TestManifestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "greet.json")
+	cmd := &Command{
+		Name:     "test",
+		Commands: []*Command{ManifestFile("greet", "greet someone", path)},
+	}
+
+	// The file isn't read before it's used.
+	expectTrue(t, strings.Contains(cmd.UsageString(), "greet someone"))
+	expectErrorIs(t, cmd.ParseRun([]string{"greet"}), fs.ErrNotExist)
+
+	expectErrorNone(t, os.WriteFile(path, []byte(`{"name": "hello", "commands": [{"name": "world", "shortDesc": "say hello"}]}`), 0o644))
+	cmd.Commands = []*Command{ManifestFile("greet", "greet someone", path)}
+	expectErrorIs(t, cmd.ParseRun([]string{"greet"}), ErrMissingCommand)
+	loaded, err := cmd.Commands[0].loaded()
+	expectErrorNone(t, err)
+	expectEq(t, loaded.Name, "greet")
+	expectEq(t, loaded.Commands[0].ShortDesc, "say hello")
+}
@@ -0,0 +1,226 @@
+package cmds
+
+// Messages are the texts that the package outputs in usage messages, errors
+// and warnings, so that they can be translated.
+// The ones with verbs are formatted with [fmt.Sprintf] with the arguments in
+// the order described, explicit argument indexes like "%[2]s" can be used to
+// reorder them.
+//
+// The messages of the sentinel errors like [ErrCmd] that wrap the errors
+// aren't included since they're compared with [errors.Is], HandleError can be
+// used to output the message of the [CommandError] or [FlagError] without
+// them.
+type Messages struct {
+	// Usage message headings, all but Usage are preceded by an empty line.
+	Usage         string
+	Documentation string
+	Commands      string
+	Topics        string
+	UserAliases   string
+	Flags         string
+	Examples      string
+
+	// FlagDefault is the default value of a flag in the list of flags.
+	FlagDefault string
+
+	// Parts of [Command.Synopsis].
+	SynopsisGlobalFlags string
+	SynopsisFlags       string
+	SynopsisCommand     string
+
+	// MissingCommand is for a missing command of the root command,
+	// MissingCommandFor of a sub-command, with it's name.
+	MissingCommand    string
+	MissingCommandFor string
+
+	// UnknownCommand has the name of the unknown command.
+	UnknownCommand string
+
+	// Suggestions is appended to UnknownCommand when there are similar
+	// commands, it has the quoted names of them separated by SuggestionsSep.
+	Suggestions    string
+	SuggestionsSep string
+
+	// HelpTopic has the name of the help topic.
+	HelpTopic string
+
+	NilRunner string
+
+	// InvalidArgs has the error returned by the [ArgsFunc].
+	InvalidArgs string
+
+	// The errors of [RangeArgs] and the others, ArgsExpected with the
+	// expected number of arguments and the number of them.
+	// The expected number is Argument or Arguments with the number, in
+	// ArgsAtLeast, ArgsAtMost or in ArgsRange with the minimum.
+	// They're the ones of DefaultMessages since an [ArgsFunc] doesn't get
+	// the command.
+	ArgsExpected string
+	ArgsAtLeast  string
+	ArgsAtMost   string
+	ArgsRange    string
+	Argument     string
+	Arguments    string
+
+	// FlagRequired has the name of the flag.
+	FlagRequired string
+
+	// FlagChoice has the value, the name of the flag and the choices
+	// separated by ", ".
+	FlagChoice string
+
+	// FlagEnvInvalid and FlagConfigInvalid have the value, the name of the
+	// flag, the environment variable for FlagEnvInvalid and the error of
+	// setting the flag to a value from the environment or config.
+	FlagEnvInvalid    string
+	FlagConfigInvalid string
+
+	// SecretInvalid has the name of the flag and the error of the
+	// [SecretProvider], FileSecret is the error of [FileSecret] for an
+	// invalid reference.
+	SecretInvalid string
+	FileSecret    string
+
+	// DeprecatedCommand and DeprecatedFlag have the name of the command or
+	// flag and the Deprecated message.
+	DeprecatedCommand string
+	DeprecatedFlag    string
+
+	// DryRun is output by [Command.Do] in dry run mode with the description
+	// of the action, DryRunUsage is the usage of the -dry-run flag.
+	DryRun      string
+	DryRunUsage string
+
+	// LogLevelUsage, VerboseUsage and QuietUsage are the usage of the
+	// -log-level, -v and -q flags added by [Command.AddLogFlags].
+	LogLevelUsage string
+	VerboseUsage  string
+	QuietUsage    string
+
+	// Confirm is the prompt of [Command.Confirm] with it's prompt, NoConfirm
+	// is the error when it can't ask and YesUsage is the usage of the -yes
+	// and -y flags added by [Command.AddYesFlag].
+	Confirm   string
+	NoConfirm string
+	YesUsage  string
+
+	// NoPagerUsage and NoEmojiUsage are the usage of the -no-pager and
+	// -no-emoji flags added by [Command.AddNoPagerFlag] and
+	// [Command.AddNoEmojiFlag].
+	NoPagerUsage string
+	NoEmojiUsage string
+
+	// OutputUsage is the usage of the -output flag added by
+	// [Command.AddOutputFlag], with the names of the output formats,
+	// UnknownOutput is the error for an unknown one with it's name and the
+	// names of the output formats.
+	OutputUsage   string
+	UnknownOutput string
+
+	// NoParent is the error of the commands returned by [HelpCommand],
+	// [CompletionCommand] and [CommandsCommand] when they're run without a
+	// parent, with their name.
+	// UnknownHelp has the arguments of the help command that don't name a
+	// command or help topic.
+	NoParent    string
+	UnknownHelp string
+
+	// The errors and output of [CompletionCommand], UnsupportedShell with
+	// the name of the shell and CompletionInstalled with the path the script
+	// was installed to, CompletionFpath with the directory of it for zsh.
+	UnsupportedShell    string
+	NoShell             string
+	CompletionInstalled string
+	CompletionFpath     string
+
+	// Prompt is the prompt for the input of a command with Prompt set, with
+	// the usage of the flag or the ArgsUsage or PromptArgs of the command.
+	// PromptInvalid has the error for an invalid value of a flag.
+	Prompt        string
+	PromptArgs    string
+	PromptInvalid string
+}
+
+// DefaultMessages are the messages used by commands that don't have Messages
+// set, they can be changed to translate all commands.
+var DefaultMessages = Messages{
+	Usage:         "Usage:",
+	Documentation: "Documentation:",
+	Commands:      "Commands:",
+	Topics:        "Additional help topics:",
+	UserAliases:   "Aliases:",
+	Flags:         "Flags:",
+	Examples:      "Examples:",
+
+	FlagDefault: "(default: %s)",
+
+	SynopsisGlobalFlags: "[global flags]",
+	SynopsisFlags:       "[flags]",
+	SynopsisCommand:     "<command> [command flags] [args]",
+
+	MissingCommand:    "missing command",
+	MissingCommandFor: "missing command for \"%s\"",
+	UnknownCommand:    "no such command \"%s\"",
+	Suggestions:       ", did you mean %s?",
+	SuggestionsSep:    " or ",
+	HelpTopic:         "\"%s\" is a help topic",
+	NilRunner:         "nil runner",
+	InvalidArgs:       "invalid arguments: %v",
+	FlagRequired:      "missing required flag -%s",
+	FlagChoice:        "invalid choice \"%s\" for flag -%s, expected one of %s",
+
+	ArgsExpected: "expected %s, got %d",
+	ArgsAtLeast:  "at least %s",
+	ArgsAtMost:   "at most %s",
+	ArgsRange:    "%d to %s",
+	Argument:     "%d argument",
+	Arguments:    "%d arguments",
+
+	FlagEnvInvalid:    "invalid value \"%s\" for flag -%s from $%s: %v",
+	FlagConfigInvalid: "invalid value \"%s\" for flag -%s from config: %v",
+	SecretInvalid:     "can't resolve the secret for flag -%s: %v",
+	FileSecret:        "expected file:// and a path",
+
+	DeprecatedCommand: "command \"%s\" is deprecated, %s",
+	DeprecatedFlag:    "flag -%s is deprecated, %s",
+
+	DryRun:      "dry run: %s",
+	DryRunUsage: "output what would be done without doing it",
+
+	LogLevelUsage: "log level, one of debug, info, warn or error",
+	VerboseUsage:  "verbose output, same as -log-level debug",
+	QuietUsage:    "quiet output, same as -log-level warn",
+
+	Confirm:   "%s [y/N]",
+	NoConfirm: "can't confirm \"%s\" without a terminal, use -yes to confirm",
+	YesUsage:  "confirm without asking",
+
+	NoPagerUsage: "don't pipe the output through $PAGER",
+	NoEmojiUsage: "output ASCII instead of emoji",
+	OutputUsage:  "output format, one of %s",
+
+	UnknownOutput: "unknown output format \"%s\", expected one of %s",
+
+	NoParent:    "%s command without parent",
+	UnknownHelp: "no such command or help topic \"%s\"",
+
+	UnsupportedShell:    "unsupported shell \"%s\"",
+	NoShell:             "can't detect shell, $SHELL is not set",
+	CompletionInstalled: "Wrote completion script to %s",
+	CompletionFpath:     "Make sure %s is in $fpath before compinit runs in ~/.zshrc",
+
+	Prompt:        "%s: ",
+	PromptArgs:    "arguments",
+	PromptInvalid: "invalid value: %v",
+}
+
+// messages returns the Messages of cmd or the closest of it's parents, or
+// [DefaultMessages] if none has them set.
+func (cmd *Command) messages() *Messages {
+	for c := cmd; c != nil; c = c.parent {
+		if c.Messages != nil {
+			return c.Messages
+		}
+	}
+	return &DefaultMessages
+}
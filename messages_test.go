@@ -0,0 +1,88 @@
+package cmds
+
+import (
+	"errors"
+	"flag"
+	"testing"
+)
+
+func TestMessages(t *testing.T) {
+	m := DefaultMessages
+	m.Usage = "Lietošana:"
+	m.Commands = "Komandas:"
+	m.Flags = "Karogi:"
+	m.FlagDefault = "(noklusējums: %s)"
+	m.SynopsisGlobalFlags = "[karogi]"
+	m.SynopsisCommand = "<komanda> [argumenti]"
+	m.UnknownCommand = "nav komandas \"%s\""
+	m.MissingCommandFor = "trūkst komandas priekš \"%s\""
+	m.FlagRequired = "trūkst karoga -%s"
+	m.UnknownHelp = "nav komandas vai tēmas \"%s\""
+
+	cmd := &Command{
+		Name:     "test",
+		Messages: &m,
+		Flags: func() *flag.FlagSet {
+			fset := flag.NewFlagSet("test", flag.ContinueOnError)
+			fset.Bool("a", false, "")
+			return fset
+		}(),
+		Commands: []*Command{
+			{
+				Name: "sub",
+				Commands: []*Command{
+					{Name: "leaf", Runner: nopRunner},
+				},
+			},
+		},
+	}
+
+	expectEq(t, usage(cmd), `Lietošana: test [karogi] <komanda> [argumenti]
+
+Komandas:
+  sub
+
+Karogi:
+  -a   (noklusējums: false)
+`)
+
+	message := func(err error) string {
+		t.Helper()
+		var cmdErr *CommandError
+		if !errors.As(err, &cmdErr) {
+			t.Fatalf("expected *CommandError, got %#v", err)
+		}
+		return cmdErr.Error()
+	}
+	expectEq(t, message(cmd.ParseRun([]string{"x"})), "nav komandas \"x\"")
+	expectEq(t, message(cmd.ParseRun([]string{"sub"})), "trūkst komandas priekš \"sub\"")
+
+	cmd.Meta("a").Required = true
+	err := cmd.ParseRun([]string{"sub", "leaf"})
+	var flagErr *FlagError
+	if !errors.As(err, &flagErr) {
+		t.Fatalf("expected *FlagError, got %#v", err)
+	}
+	expectEq(t, flagErr.Error(), "trūkst karoga -a")
+
+	// Sub-commands use the messages of their parents.
+	expectEq(t, cmd.Commands[0].messages(), &m)
+	expectEq(t, (&Command{}).messages(), &DefaultMessages)
+
+	cmd.Commands = append(cmd.Commands, HelpCommand())
+	cmd.Meta("a").Required = false
+	cmd.Reset()
+	expectEq(t, cmd.ParseRun([]string{"help", "x"}).Error(), "command parse error: nav komandas vai tēmas \"x\"")
+}
+
+func TestDefaultMessagesArgs(t *testing.T) {
+	m := DefaultMessages
+	t.Cleanup(func() { DefaultMessages = m })
+	DefaultMessages.ArgsExpected = "gaidīja %s, saņēma %d"
+	DefaultMessages.ArgsAtMost = "ne vairāk kā %s"
+	DefaultMessages.Argument = "%d argumentu"
+	DefaultMessages.Arguments = "%d argumentus"
+
+	expectEq(t, MaxArgs(1)([]string{"a", "b"}).Error(), "gaidīja ne vairāk kā 1 argumentu, saņēma 2")
+	expectEq(t, ExactArgs(2)(nil).Error(), "gaidīja 2 argumentus, saņēma 0")
+}
@@ -0,0 +1,67 @@
+package cmds
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"unicode"
+)
+
+// NameCommands names the sub-commands of cmd without a Name that have a
+// Runner after the function of the Runner in kebab case, without a "run" or
+// "Run" prefix, like "echo" for runEcho and "list-all" for the method value
+// s.ListAll, unless NoRunnerNames is set, so that generated trees don't have
+// to repeat the names.
+// Function literals aren't named.
+// [Command.AddAt] names the commands it adds and the sub-commands of trees
+// that are made as literals are named once when they're first parsed or
+// listed, so that it only has to be called after appending to the Commands of
+// a command that was already parsed.
+func (cmd *Command) NameCommands() {
+	if cmd.NoRunnerNames {
+		return
+	}
+	for _, sub := range cmd.Commands {
+		if sub.Name == "" && sub.Runner != nil {
+			sub.Name = runnerName(sub.Runner)
+		}
+	}
+}
+
+// nameCommands calls [Command.NameCommands] once for cmd, so that the
+// sub-commands aren't named while they're looked up by [Command.ParseArgs]
+// from other goroutines.
+func (cmd *Command) nameCommands() {
+	cmd.lazyState().names.Do(cmd.NameCommands)
+}
+
+// runnerName returns the name of the command for the function of runner, or an
+// empty string for function literals.
+func runnerName(runner RunnerFunc) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(runner).Pointer())
+	if fn == nil {
+		return ""
+	}
+
+	// Like "example.com/pkg.runEcho" or "example.com/pkg.(*T).Run-fm".
+	name := fn.Name()
+	name = name[strings.LastIndexByte(name, '/')+1:]
+	name = strings.TrimSuffix(name, "-fm")
+	name = name[strings.LastIndexByte(name, '.')+1:]
+	if isFuncLit(name) {
+		return ""
+	}
+	for _, prefix := range []string{"run", "Run"} {
+		if rest := strings.TrimPrefix(name, prefix); rest != name && rest != "" && unicode.IsUpper(rune(rest[0])) {
+			name = rest
+		}
+	}
+	return kebabCase(name)
+}
+
+// isFuncLit reports whether name is the name that function literals get, like
+// "func1" or "1" for a nested one.
+func isFuncLit(name string) bool {
+	name = strings.TrimPrefix(name, "func")
+	return name != "" && strings.Trim(name, "0123456789") == ""
+}
@@ -0,0 +1,65 @@
+package cmds
+
+import (
+	"sync"
+	"testing"
+)
+
+func runEcho(cmd *Command, args []string) error { return nil }
+
+type testStatus struct{}
+
+func (s *testStatus) RunStatus(cmd *Command, args []string) error { return nil }
+
+func (s *testStatus) ListAll(cmd *Command, args []string) error { return nil }
+
+func TestNameCommands(t *testing.T) {
+	s := &testStatus{}
+	cmd := &Command{
+		Name: "test",
+		Commands: []*Command{
+			{Runner: runEcho},
+			{Runner: s.RunStatus},
+			{Runner: s.ListAll},
+			{Runner: func(*Command, []string) error { return nil }},
+			{Name: "named", Runner: runEcho},
+		},
+	}
+
+	expectErrorNone(t, cmd.ParseRun([]string{"echo"}))
+	var names []string
+	for _, sub := range cmd.Commands {
+		names = append(names, sub.Name)
+	}
+	expectEq(t, names, []string{"echo", "status", "list-all", "", "named"})
+
+	cmd = &Command{Name: "test", NoRunnerNames: true, Commands: []*Command{{Runner: runEcho}}}
+	expectTrue(t, cmd.Find("echo") == nil)
+	expectEq(t, cmd.Commands[0].Name, "")
+}
+
+func TestNameCommandsOnce(t *testing.T) {
+	cmd := &Command{Name: "test", Commands: []*Command{{Runner: runEcho}}}
+
+	// Looking it up doesn't name it.
+	expectTrue(t, cmd.Find("echo") == nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, err := cmd.ParseArgs([]string{"echo"})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			expectEq(t, r.Command.Name, "echo")
+		}()
+	}
+	wg.Wait()
+
+	// Added commands are named when they're added.
+	cmd.AddAt("", &Command{Runner: (&testStatus{}).ListAll})
+	expectTrue(t, cmd.Find("list-all") != nil)
+}
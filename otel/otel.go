@@ -0,0 +1,88 @@
+// Package otel is a [cmds.Instrumentation] that creates OpenTelemetry spans
+// for parsing and running commands, so that invocations show up in the
+// distributed traces of CI systems and other services.
+package otel
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rgzlv/cmds"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// The attributes set on the spans.
+const (
+	// PathKey is the [cmds.Command.Path] of the command.
+	PathKey = attribute.Key("cmds.command.path")
+
+	// FlagsKey is the [cmds.Command.FlagValues] of the command, as
+	// "name=value" strings.
+	FlagsKey = attribute.Key("cmds.command.flags")
+)
+
+// Instrumentation creates a span named "parse <root>" for parsing and one
+// named after the path of the leaf command, like "tool db migrate", for
+// running it's Runner, children of the span in the context that the command
+// was run with if any.
+// The context of the Runner contains the run span, so that the spans of the
+// work it does are it's children.
+type Instrumentation struct {
+	tracer trace.Tracer
+}
+
+// New returns an Instrumentation that uses a tracer from tp.
+func New(tp trace.TracerProvider) *Instrumentation {
+	return &Instrumentation{tracer: tp.Tracer("github.com/rgzlv/cmds/otel")}
+}
+
+func (in *Instrumentation) ParseStart(ctx context.Context, cmd *cmds.Command, args []string) context.Context {
+	ctx, _ = in.tracer.Start(ctx, "parse "+cmd.Name, trace.WithSpanKind(trace.SpanKindInternal))
+	return ctx
+}
+
+func (in *Instrumentation) ParseEnd(ctx context.Context, cmd *cmds.Command, d time.Duration, err error) {
+	span := trace.SpanFromContext(ctx)
+	if cmd != nil {
+		span.SetAttributes(PathKey.StringSlice(cmd.Path()))
+	}
+	end(span, err)
+}
+
+func (in *Instrumentation) RunStart(ctx context.Context, cmd *cmds.Command, args []string) context.Context {
+	ctx, _ = in.tracer.Start(ctx, strings.Join(cmd.Path(), " "),
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(PathKey.StringSlice(cmd.Path()), FlagsKey.StringSlice(flags(cmd))))
+	return ctx
+}
+
+func (in *Instrumentation) RunEnd(ctx context.Context, cmd *cmds.Command, d time.Duration, err error) {
+	end(trace.SpanFromContext(ctx), err)
+}
+
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// flags returns the flags set for cmd and it's parents as "name=value"
+// strings, with the values of secret flags redacted.
+func flags(cmd *cmds.Command) []string {
+	var flags []string
+	for c := cmd; c != nil; c = c.Parent() {
+		var cmdFlags []string
+		for name, value := range c.FlagValues() {
+			cmdFlags = append(cmdFlags, name+"="+value)
+		}
+		sort.Strings(cmdFlags)
+		flags = append(cmdFlags, flags...)
+	}
+	return flags
+}
@@ -0,0 +1,65 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"reflect"
+	"testing"
+
+	"github.com/rgzlv/cmds"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInstrumentation(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+
+	var runnerSpan trace.SpanContext
+	cmd := &cmds.Command{
+		Name:            "tool",
+		Instrumentation: New(tp),
+		Flags:           flag.NewFlagSet("tool", flag.ContinueOnError),
+		Commands: []*cmds.Command{
+			{Name: "fail", Runner: func(cmd *cmds.Command, args []string) error {
+				runnerSpan = trace.SpanContextFromContext(cmd.Context())
+				return errors.New("failed")
+			}},
+		},
+	}
+	cmd.Flags.String("token", "", "")
+	cmd.Flags.Bool("v", false, "")
+	cmd.Meta("token").Secret = true
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "ci job")
+	if err := cmd.ParseRunContext(ctx, []string{"-v", "-token", "x", "fail"}); err == nil {
+		t.Fatal("expected error")
+	}
+	parent.End()
+
+	spans := rec.Ended()
+	expectEq(t, len(spans), 3)
+	parse, run := spans[0], spans[1]
+
+	expectEq(t, parse.Name(), "parse tool")
+	expectEq(t, parse.Parent().SpanID(), parent.SpanContext().SpanID())
+	expectEq(t, parse.Attributes()[0], PathKey.StringSlice([]string{"tool", "fail"}))
+	expectEq(t, parse.Status().Code, codes.Unset)
+
+	expectEq(t, run.Name(), "tool fail")
+	expectEq(t, run.Parent().SpanID(), parent.SpanContext().SpanID())
+	expectEq(t, run.SpanContext().SpanID(), runnerSpan.SpanID())
+	expectEq(t, run.Attributes()[1], FlagsKey.StringSlice([]string{"token=REDACTED", "v=true"}))
+	expectEq(t, run.Status().Code, codes.Error)
+	expectEq(t, run.Status().Description, "failed")
+}
+
+func expectEq(t *testing.T, a, b any) {
+	t.Helper()
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("expected equal values, got %#+v != %#+v", a, b)
+	}
+}
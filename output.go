@@ -0,0 +1,303 @@
+package cmds
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/rgzlv/cmds/ui"
+)
+
+// Renderer outputs v to w in some format, for [Command.Print].
+// The arg is what follows "=" in the value of the -output flag, like the
+// template of "template={{.Name}}", or an empty string.
+type Renderer func(w io.Writer, v any, arg string) error
+
+var (
+	renderersMu sync.RWMutex
+	renderers   = map[string]Renderer{
+		"json":     renderJSON,
+		"table":    renderTable,
+		"template": renderTemplate,
+	}
+)
+
+// RegisterRenderer registers r as the renderer of the output format name,
+// replacing the one that's registered already, if any.
+// The "json", "table" and "template" formats are built in, importing
+// github.com/rgzlv/cmds/config registers "yaml".
+func RegisterRenderer(name string, r Renderer) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	renderers[name] = r
+}
+
+func lookupRenderer(name string) Renderer {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	return renderers[name]
+}
+
+// rendererNames returns the sorted names of the registered renderers.
+func rendererNames() []string {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// defaultOutputFormat is the output format of [Command.Print] when none is
+// set.
+const defaultOutputFormat = "table"
+
+// AddOutputFlag adds the -output flag that sets OutputFormat to the flags of
+// cmd, creating them if there are none.
+// Setting it to a format that isn't registered with [RegisterRenderer] is a
+// parse error.
+// The usage of the flag lists the renderers registered when it's shown,
+// unless it's set to something else.
+func (cmd *Command) AddOutputFlag() {
+	if cmd.Flags == nil {
+		cmd.Flags = flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+		cmd.Flags.Usage = cmd.DefaultUsage()
+	}
+	cmd.Flags.Var(outputFlag{cmd}, "output", "")
+}
+
+// Print outputs v to [Command.Output] with the renderer of the OutputFormat
+// of cmd or the closest of it's parents that has one set, or as a table if
+// none has, so that Runners output structured values the same way.
+func (cmd *Command) Print(v any) error {
+	format := defaultOutputFormat
+	for c := cmd; c != nil; c = c.parent {
+		if c.OutputFormat != "" {
+			format = c.OutputFormat
+			break
+		}
+	}
+
+	name, arg, _ := strings.Cut(format, "=")
+	r := lookupRenderer(name)
+	if r == nil {
+		return fmt.Errorf("%w: %w", Err, unknownOutputError(cmd, name))
+	}
+	return r(cmd.Output(), v, arg)
+}
+
+// outputFlag is the value of the -output flag.
+type outputFlag struct {
+	cmd *Command
+}
+
+func (f outputFlag) String() string {
+	if f.cmd == nil || f.cmd.OutputFormat == "" {
+		return defaultOutputFormat
+	}
+	return f.cmd.OutputFormat
+}
+
+func (f outputFlag) Set(s string) error {
+	name, _, _ := strings.Cut(s, "=")
+	if lookupRenderer(name) == nil {
+		return unknownOutputError(f.cmd, name)
+	}
+	f.cmd.OutputFormat = s
+	return nil
+}
+
+func (f outputFlag) Get() any {
+	return f.String()
+}
+
+func (f outputFlag) usage() string {
+	return fmt.Sprintf(f.cmd.messages().OutputUsage, strings.Join(rendererNames(), ", "))
+}
+
+// unknownOutputError returns the error of the output format name that isn't
+// registered.
+func unknownOutputError(cmd *Command, name string) error {
+	return fmt.Errorf(cmd.messages().UnknownOutput, name, strings.Join(rendererNames(), ", "))
+}
+
+// FlagUsage returns the usage of f for tools that present flags some other
+// way, the one of the -output flag is made when it's needed so that it lists
+// the renderers registered after it was added.
+func FlagUsage(f *flag.Flag) string {
+	if of, ok := f.Value.(outputFlag); ok && f.Usage == "" && of.cmd != nil {
+		return of.usage()
+	}
+	return f.Usage
+}
+
+func renderJSON(w io.Writer, v any, arg string) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(v)
+}
+
+func renderTemplate(w io.Writer, v any, arg string) error {
+	if arg == "" {
+		return errors.New("missing template, expected template=<text>")
+	}
+	tmpl, err := template.New("output").Parse(arg)
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Execute(w, v); err != nil {
+		return err
+	}
+	if !strings.HasSuffix(arg, "\n") {
+		_, err = io.WriteString(w, "\n")
+	}
+	return err
+}
+
+// renderTable outputs a slice of structs or maps as a table with a column for
+// every field or key, with a header row of their names upper-cased, a single
+// struct or map as a table of names and values and anything else on lines of
+// it's own.
+// The columns are aligned by their [ui.Width], so that wide characters don't
+// misalign them.
+func renderTable(w io.Writer, v any, arg string) error {
+	var rows [][]string
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		var columns []string
+		if rv.Len() > 0 {
+			columns = tableColumns(rv.Index(0))
+		}
+		if columns == nil {
+			for i := 0; i < rv.Len(); i++ {
+				rows = append(rows, []string{fmt.Sprint(rv.Index(i).Interface())})
+			}
+			break
+		}
+		upper := make([]string, len(columns))
+		for i, c := range columns {
+			upper[i] = strings.ToUpper(c)
+		}
+		rows = append(rows, upper)
+		for i := 0; i < rv.Len(); i++ {
+			row := make([]string, len(columns))
+			for j, c := range columns {
+				row[j] = fmt.Sprint(tableValue(rv.Index(i), c))
+			}
+			rows = append(rows, row)
+		}
+	case reflect.Struct, reflect.Map:
+		for _, c := range tableColumns(rv) {
+			rows = append(rows, []string{c + ":", fmt.Sprint(tableValue(rv, c))})
+		}
+	default:
+		rows = append(rows, []string{fmt.Sprint(v)})
+	}
+	return writeColumns(w, rows, 3)
+}
+
+// writeColumns writes rows to w with the columns padded to the widest cell in
+// them and gap spaces between them, the last column isn't padded.
+func writeColumns(w io.Writer, rows [][]string, gap int) error {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], ui.Width(cell))
+		}
+	}
+
+	var b strings.Builder
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(row)-1 {
+				b.WriteString(cell)
+				break
+			}
+			b.WriteString(ui.Pad(cell, widths[i]+gap))
+		}
+		b.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// tableColumns returns the names of the exported fields of a struct, by their
+// json tag if they have one, or the sorted keys of a map, or nil for other
+// values.
+func tableColumns(rv reflect.Value) []string {
+	rv = reflect.Indirect(rv)
+	if rv.Kind() == reflect.Interface {
+		rv = reflect.Indirect(rv.Elem())
+	}
+	switch rv.Kind() {
+	case reflect.Struct:
+		var columns []string
+		for _, f := range reflect.VisibleFields(rv.Type()) {
+			if name := tableField(f); name != "" {
+				columns = append(columns, name)
+			}
+		}
+		return columns
+	case reflect.Map:
+		columns := make([]string, 0, rv.Len())
+		for _, k := range rv.MapKeys() {
+			columns = append(columns, fmt.Sprint(k.Interface()))
+		}
+		sort.Strings(columns)
+		return columns
+	}
+	return nil
+}
+
+// tableField returns the column name of f, or an empty string if it isn't
+// output.
+func tableField(f reflect.StructField) string {
+	if !f.IsExported() || f.Anonymous {
+		return ""
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return f.Name
+	}
+	return name
+}
+
+// tableValue returns the value of the column of rv, or an empty string if
+// rv doesn't have it.
+func tableValue(rv reflect.Value, column string) any {
+	rv = reflect.Indirect(rv)
+	if rv.Kind() == reflect.Interface {
+		rv = reflect.Indirect(rv.Elem())
+	}
+	switch rv.Kind() {
+	case reflect.Struct:
+		for _, f := range reflect.VisibleFields(rv.Type()) {
+			if tableField(f) == column {
+				return rv.FieldByIndex(f.Index).Interface()
+			}
+		}
+	case reflect.Map:
+		for _, k := range rv.MapKeys() {
+			if fmt.Sprint(k.Interface()) == column {
+				return rv.MapIndex(k).Interface()
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,85 @@
+package cmds
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+type testPerson struct {
+	Name   string `json:"name"`
+	Age    int
+	Secret string `json:"-"`
+}
+
+func TestPrint(t *testing.T) {
+	var b strings.Builder
+	var value any
+	cmd := &Command{
+		Name:   "test",
+		Stdout: &b,
+		Stderr: io.Discard,
+		Commands: []*Command{
+			{Name: "ls", Runner: func(cmd *Command, args []string) error {
+				return cmd.Print(value)
+			}},
+		},
+	}
+	cmd.AddOutputFlag()
+	expectEq(t, FlagUsage(cmd.Flags.Lookup("output")), "output format, one of json, table, template")
+	expectEq(t, cmd.Flags.Lookup("output").DefValue, "table")
+
+	people := []testPerson{{Name: "ann", Age: 30, Secret: "x"}, {Name: "bob", Age: 4}}
+	tests := []struct {
+		args  []string
+		value any
+		want  string
+	}{
+		{nil, people, "NAME   AGE\nann    30\nbob    4\n"},
+		{nil, &people[0], "name:   ann\nAge:    30\n"},
+		{nil, []map[string]int{{"b": 2, "a": 1}}, "A   B\n1   2\n"},
+		{nil, []string{"a", "b"}, "a\nb\n"},
+		{nil, []testPerson{{Name: "日本"}, {Name: "abcde"}}, "NAME    AGE\n日本    0\nabcde   0\n"},
+		{nil, "text", "text\n"},
+		{[]string{"-output", "json"}, people[1], "{\n\t\"name\": \"bob\",\n\t\"Age\": 4\n}\n"},
+		{[]string{"-output", "template={{range .}}{{.Name}} {{end}}"}, people, "ann bob \n"},
+	}
+	for _, test := range tests {
+		b.Reset()
+		cmd.Reset()
+		value = test.value
+		expectErrorNone(t, cmd.ParseRun(append(test.args, "ls")))
+		expectEq(t, b.String(), test.want)
+	}
+
+	cmd.Reset()
+	expectErrorIs(t, cmd.ParseRun([]string{"-output", "xml", "ls"}), ErrFlag)
+	cmd.Reset()
+	expectError(t, cmd.ParseRun([]string{"-output", "template", "ls"}))
+
+	RegisterRenderer("count", func(w io.Writer, v any, arg string) error {
+		_, err := io.WriteString(w, arg+"\n")
+		return err
+	})
+	defer func() {
+		renderersMu.Lock()
+		delete(renderers, "count")
+		renderersMu.Unlock()
+	}()
+	b.Reset()
+	cmd.Reset()
+	expectErrorNone(t, cmd.ParseRun([]string{"-output", "count=3", "ls"}))
+	expectEq(t, b.String(), "3\n")
+
+	// Renderers registered after the flag was added are listed.
+	expectEq(t, FlagUsage(cmd.Flags.Lookup("output")), "output format, one of count, json, table, template")
+
+	// Print and the flag fail the same way.
+	setErr := cmd.Flags.Lookup("output").Value.Set("xml")
+	cmd.Reset()
+	cmd.OutputFormat = "xml"
+	printErr := cmd.Print(nil)
+	expectErrorIs(t, printErr, Err)
+	expectEq(t, printErr.Error(), Err.Error()+": "+setErr.Error())
+	expectEq(t, setErr.Error(), "unknown output format \"xml\", expected one of count, json, table, template")
+}
@@ -0,0 +1,141 @@
+package cmds
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// AddNoPagerFlag adds the -no-pager flag that sets NoPager to the flags of
+// cmd, creating them if there are none.
+func (cmd *Command) AddNoPagerFlag() {
+	if cmd.Flags == nil {
+		cmd.Flags = flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+		cmd.Flags.Usage = cmd.DefaultUsage()
+	}
+	cmd.Flags.BoolVar(&cmd.NoPager, "no-pager", cmd.NoPager, cmd.messages().NoPagerUsage)
+}
+
+// IsNoPager reports whether cmd or any of it's parents has NoPager set.
+func (cmd *Command) IsNoPager() bool {
+	for c := cmd; c != nil; c = c.parent {
+		if c.NoPager {
+			return true
+		}
+	}
+	return false
+}
+
+// Pager returns a writer for the output of cmd that pipes it through $PAGER,
+// or less if it isn't set, once it's longer than the terminal, for commands
+// that output long lists. It has to be closed when the output is done, which
+// waits for the pager to exit.
+// Output that fits the terminal is written to [Command.Output] when it's
+// closed, without a pager.
+// If the Output of cmd isn't a terminal, [Command.IsNoPager] or $PAGER is
+// empty or "cat", it writes to Output directly.
+// Like git, LESS is set to "FRX" for the pager if it isn't set, so that less
+// exits if the output fits after all and passes colors through.
+func (cmd *Command) Pager() io.WriteCloser {
+	w := cmd.Output()
+	if cmd.IsNoPager() || !isTerminal(w) {
+		return nopWriteCloser{w}
+	}
+
+	name, ok := os.LookupEnv("PAGER")
+	if !ok {
+		name = "less"
+	}
+	args := strings.Fields(name)
+	lines := terminalHeight(w) - 1
+	if len(args) == 0 || args[0] == "cat" || lines < 1 {
+		return nopWriteCloser{w}
+	}
+	return &pager{w: w, errw: cmd.ErrOutput(), args: args, lines: lines}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// pager buffers the output until it's more than lines long and then pipes it
+// through the command args.
+type pager struct {
+	w, errw io.Writer
+	args    []string
+	lines   int
+
+	buf bytes.Buffer
+	cmd *exec.Cmd
+	in  io.WriteCloser
+}
+
+func (p *pager) Write(b []byte) (int, error) {
+	if p.in != nil {
+		return p.write(b)
+	}
+
+	p.buf.Write(b)
+	if bytes.Count(p.buf.Bytes(), []byte("\n")) <= p.lines {
+		return len(b), nil
+	}
+	if err := p.start(); err != nil {
+		// The output isn't lost if the pager can't be run.
+		p.in = nopWriteCloser{p.w}
+	}
+	_, err := p.write(p.buf.Bytes())
+	p.buf.Reset()
+	return len(b), err
+}
+
+// write writes b to the pager, which quitting before reading everything isn't
+// an error for.
+func (p *pager) write(b []byte) (int, error) {
+	n, err := p.in.Write(b)
+	if errors.Is(err, syscall.EPIPE) || errors.Is(err, os.ErrClosed) {
+		return len(b), nil
+	}
+	return n, err
+}
+
+func (p *pager) start() error {
+	c := exec.Command(p.args[0], p.args[1:]...)
+	c.Stdout, c.Stderr = p.w, p.errw
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		c.Env = append(os.Environ(), "LESS=FRX")
+	}
+	in, err := c.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := c.Start(); err != nil {
+		return err
+	}
+	p.cmd, p.in = c, in
+	return nil
+}
+
+// Close writes the output that was buffered or waits for the pager to exit.
+func (p *pager) Close() error {
+	if p.in == nil {
+		_, err := p.w.Write(p.buf.Bytes())
+		p.buf.Reset()
+		return err
+	}
+	if p.cmd == nil {
+		return nil
+	}
+	p.in.Close()
+	err := p.cmd.Wait()
+	p.cmd = nil
+	return err
+}
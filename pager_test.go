@@ -0,0 +1,63 @@
+package cmds
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestPager(t *testing.T) {
+	if _, err := exec.LookPath("sed"); err != nil {
+		t.Skip("no sed to page with")
+	}
+	defer func(isTerm func(any) bool, height func(io.Writer) int) {
+		isTerminal, terminalHeight = isTerm, height
+	}(isTerminal, terminalHeight)
+	isTerminal = func(any) bool { return true }
+	terminalHeight = func(io.Writer) int { return 4 }
+	t.Setenv("PAGER", "sed s/^/>/")
+
+	var b strings.Builder
+	var n int
+	cmd := &Command{
+		Name:   "test",
+		Stdout: &b,
+		Runner: func(cmd *Command, args []string) error {
+			p := cmd.Pager()
+			for i := 0; i < n; i++ {
+				fmt.Fprintf(p, "%d\n", i)
+			}
+			return p.Close()
+		},
+	}
+	cmd.AddNoPagerFlag()
+
+	// Fits the terminal.
+	n = 3
+	expectErrorNone(t, cmd.ParseRun(nil))
+	expectEq(t, b.String(), "0\n1\n2\n")
+
+	b.Reset()
+	n = 5
+	expectErrorNone(t, cmd.ParseRun(nil))
+	expectEq(t, b.String(), ">0\n>1\n>2\n>3\n>4\n")
+
+	b.Reset()
+	cmd.Reset()
+	expectErrorNone(t, cmd.ParseRun([]string{"-no-pager"}))
+	expectEq(t, b.String(), "0\n1\n2\n3\n4\n")
+
+	b.Reset()
+	cmd.Reset()
+	t.Setenv("PAGER", "cat")
+	expectErrorNone(t, cmd.ParseRun(nil))
+	expectEq(t, b.String(), "0\n1\n2\n3\n4\n")
+
+	// The output isn't lost without a pager.
+	b.Reset()
+	t.Setenv("PAGER", "/nonexistent/pager")
+	expectErrorNone(t, cmd.ParseRun(nil))
+	expectEq(t, b.String(), "0\n1\n2\n3\n4\n")
+}
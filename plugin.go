@@ -0,0 +1,102 @@
+package cmds
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Plugin returns a command that runs the program in $PATH for the sub-command
+// name of cmd, as described in Plugins, or nil if there's no such program.
+// The program is run with the arguments after name, the streams of cmd and an
+// environment that has CMDS_COMMAND set to the names of the commands from the
+// root to the plugin separated by spaces and CMDS_FLAG_<NAME> set to the value
+// of every flag of cmd and it's parents, upper-cased and with the bytes that
+// can't be in a variable name replaced with underscores, except for the
+// Secret ones.
+// If the program exits with a non-zero code, the error returned by the Runner
+// is an [ExitCoder] with that code.
+func (cmd *Command) Plugin(name string) *Command {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return nil
+	}
+	path, err := exec.LookPath(strings.Join(append(cmd.Path(), name), "-"))
+	if err != nil {
+		return nil
+	}
+
+	return &Command{
+		Name:   name,
+		Flags:  flag.NewFlagSet(name, flag.ContinueOnError),
+		Runner: pluginRunner(path),
+		parent: cmd,
+	}
+}
+
+func pluginRunner(path string) RunnerFunc {
+	return func(cmd *Command, args []string) error {
+		return cmd.runProgram(path, args, cmd.pluginEnv())
+	}
+}
+
+// programWaitDelay is how long runProgram waits for the program to exit after
+// interrupting it before killing it.
+const programWaitDelay = 5 * time.Second
+
+// runProgram runs the program name with args, the streams of cmd and env
+// added to the environment, until it exits or the context of cmd is done.
+// When the context is done the program is interrupted, so that it can clean
+// up like the command would, and killed if it doesn't exit within
+// programWaitDelay, on Windows it's killed right away.
+func (cmd *Command) runProgram(name string, args []string, env []string) error {
+	c := exec.CommandContext(cmd.Context(), name, args...)
+	c.Stdin = cmd.Input()
+	c.Stdout = cmd.Output()
+	c.Stderr = cmd.ErrOutput()
+	if env != nil {
+		c.Env = append(os.Environ(), env...)
+	}
+	if runtime.GOOS != "windows" {
+		c.Cancel = func() error {
+			return c.Process.Signal(os.Interrupt)
+		}
+		c.WaitDelay = programWaitDelay
+	}
+	return c.Run()
+}
+
+// pluginEnv returns the environment that describes cmd to it's plugin
+// program, the flags of sub-commands come after the ones of their parents so
+// they take precedence.
+func (cmd *Command) pluginEnv() []string {
+	env := []string{"CMDS_COMMAND=" + strings.Join(cmd.Path(), " ")}
+	var cmds []*Command
+	for c := cmd.parent; c != nil; c = c.parent {
+		cmds = append([]*Command{c}, cmds...)
+	}
+	for _, c := range cmds {
+		if c.Flags == nil {
+			continue
+		}
+		c.Flags.VisitAll(func(f *flag.Flag) {
+			if m := c.FlagMeta[f.Name]; m != nil && m.Secret {
+				return
+			}
+			env = append(env, "CMDS_FLAG_"+strings.ToUpper(shellIdent(f.Name))+"="+f.Value.String())
+		})
+	}
+	return env
+}
+
+// plugins reports whether cmd or any of it's parents has Plugins set.
+func (cmd *Command) plugins() bool {
+	for c := cmd; c != nil; c = c.parent {
+		if c.Plugins {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package cmds
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestPlugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts need a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho \"$CMDS_COMMAND|$CMDS_FLAG_NAME|$CMDS_FLAG_DRY_RUN|${CMDS_FLAG_TOKEN-unset}|$*\"\nexit $1\n"
+	if err := os.WriteFile(filepath.Join(dir, "test-remote-hello"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	testCmd := func(out *bytes.Buffer) *Command {
+		cmd := &Command{
+			Name:    "test",
+			Plugins: true,
+			Stdout:  out,
+			Flags: func() *flag.FlagSet {
+				fset := flag.NewFlagSet("test", flag.ContinueOnError)
+				fset.String("name", "", "")
+				fset.Bool("dry-run", false, "")
+				fset.String("token", "", "")
+				return fset
+			}(),
+			Commands: []*Command{
+				{Name: "remote", Commands: []*Command{{Name: "add", Runner: nopRunner}}},
+			},
+		}
+		cmd.Meta("token").Secret = true
+		return cmd
+	}
+
+	var out bytes.Buffer
+	expectErrorNone(t, testCmd(&out).ParseRun([]string{"-name", "x", "-token", "t", "remote", "hello", "0", "a"}))
+	// The Secret flags aren't in the environment.
+	expectEq(t, out.String(), "test remote hello|x|false|unset|0 a\n")
+
+	out.Reset()
+	cmd := testCmd(&out)
+	err := cmd.ParseRun([]string{"remote", "hello", "3"})
+	expectError(t, err)
+	expectEq(t, cmd.ExitCode(err), 3)
+
+	expectErrorIs(t, testCmd(&out).ParseRun([]string{"hello"}), ErrUnknownCommand)
+	expectErrorIs(t, testCmd(&out).ParseRun([]string{"remote", "../remote-hello"}), ErrUnknownCommand)
+
+	cmd = testCmd(&out)
+	cmd.Plugins = false
+	expectErrorIs(t, cmd.ParseRun([]string{"remote", "hello"}), ErrUnknownCommand)
+}
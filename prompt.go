@@ -0,0 +1,102 @@
+package cmds
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rgzlv/cmds/term"
+)
+
+// isTerminal and terminalHeight are [term.IsTTY] and [term.Height], they're
+// variables so that tests can prompt and page without a terminal.
+var (
+	isTerminal     = term.IsTTY
+	terminalHeight = term.Height
+)
+
+// interactive reports whether cmd prompts for missing input, which is when it
+// has Prompt set and it's Input is a terminal.
+func (cmd *Command) interactive() bool {
+	return cmd.Prompt && isTerminal(cmd.Input())
+}
+
+// promptFlags prompts for the Required flags of cmd that aren't set, after
+// they're resolved, and records their Source as SourcePrompt.
+// Invalid values are prompted for again, a flag that's left empty stays unset
+// so that it fails validation.
+func (cmd *Command) promptFlags() error {
+	if len(cmd.FlagMeta) == 0 || !cmd.interactive() {
+		return nil
+	}
+
+	var err error
+	cmd.Flags.VisitAll(func(f *flag.Flag) {
+		meta := cmd.FlagMeta[f.Name]
+		if err != nil || meta == nil || !meta.Required || cmd.sources[f.Name] != SourceDefault {
+			return
+		}
+
+		label := FlagUsage(f)
+		if label == "" {
+			label = "-" + f.Name
+		}
+		for {
+			var value string
+			value, err = cmd.prompt(label, meta.Secret)
+			if err != nil || value == "" {
+				return
+			}
+			if setErr := f.Value.Set(value); setErr != nil {
+				fmt.Fprintf(cmd.ErrOutput(), cmd.messages().PromptInvalid+"\n", setErr)
+				continue
+			}
+			cmd.sources[f.Name] = SourcePrompt
+			return
+		}
+	})
+	return err
+}
+
+// promptArgs prompts for more arguments for cmd after it's Args failed with
+// err and returns the arguments with the ones that were entered, split at
+// spaces, and the error of Args for them.
+func (cmd *Command) promptArgs(args []string, err error) ([]string, error) {
+	label := cmd.ArgsUsage
+	if label == "" {
+		label = cmd.messages().PromptArgs
+	}
+	line, promptErr := cmd.prompt(label, false)
+	if promptErr != nil || line == "" {
+		return args, err
+	}
+	args = append(append([]string(nil), args...), strings.Fields(line)...)
+	return args, cmd.Args(args)
+}
+
+// prompt outputs label as a prompt to [Command.ErrOutput] and returns the
+// line read from [Command.Input], without echoing it if secret is set.
+// The end of the input isn't an error, it returns an empty line.
+func (cmd *Command) prompt(label string, secret bool) (string, error) {
+	w := cmd.ErrOutput()
+	fmt.Fprintf(w, cmd.messages().Prompt, label)
+
+	var line string
+	var err error
+	if secret {
+		line, err = term.ReadPassword(cmd.Input())
+		// The newline isn't echoed either.
+		fmt.Fprintln(w)
+	} else {
+		line, err = term.ReadLine(cmd.Input())
+	}
+	if errors.Is(err, io.EOF) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", Err, err)
+	}
+	return strings.TrimSpace(line), nil
+}
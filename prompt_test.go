@@ -0,0 +1,57 @@
+package cmds
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestPrompt(t *testing.T) {
+	defer func(f func(any) bool) { isTerminal = f }(isTerminal)
+	isTerminal = func(any) bool { return true }
+
+	var stderr strings.Builder
+	var got []string
+	cmd := &Command{
+		Name:      "login",
+		Prompt:    true,
+		Stderr:    &stderr,
+		Flags:     flag.NewFlagSet("login", flag.ContinueOnError),
+		ArgsUsage: "<host>",
+		Args:      ExactArgs(1),
+		Runner: func(cmd *Command, args []string) error {
+			got = args
+			return nil
+		},
+	}
+	cmd.Flags.String("user", "", "user name")
+	cmd.Flags.Int("port", 0, "")
+	cmd.Flags.String("password", "", "password")
+	cmd.Meta("user").Required = true
+	cmd.Meta("port").Required = true
+	cmd.Meta("password").Required = true
+	cmd.Meta("password").Secret = true
+
+	cmd.Stdin = strings.NewReader("hunter2\nx\n22\nexample.com\n")
+	expectErrorNone(t, cmd.ParseRun([]string{"-user", "a"}))
+	expectEq(t, cmd.Flags.Lookup("port").Value.String(), "22")
+	expectEq(t, cmd.Flags.Lookup("password").Value.String(), "hunter2")
+	expectEq(t, cmd.ValueSource("port"), SourcePrompt)
+	expectEq(t, cmd.ValueSource("user"), SourceCommandLine)
+	expectEq(t, got, []string{"example.com"})
+	expectEq(t, stderr.String(), "password: \n-port: invalid value: parse error\n-port: <host>: ")
+
+	// Empty input leaves the flag unset.
+	cmd.Reset()
+	stderr.Reset()
+	cmd.Stdin = strings.NewReader("\n")
+	expectErrorIs(t, cmd.ParseRun([]string{"-user", "a", "-port", "1", "h"}), ErrFlagRequired)
+
+	// Not a terminal.
+	isTerminal = func(any) bool { return false }
+	cmd.Reset()
+	stderr.Reset()
+	cmd.Stdin = strings.NewReader("x\n")
+	expectErrorIs(t, cmd.ParseRun([]string{"-user", "a", "-port", "1", "-password", "p"}), ErrArgs)
+	expectEq(t, stderr.String(), "")
+}
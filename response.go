@@ -0,0 +1,111 @@
+package cmds
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// maxResponseDepth is how deeply response files can refer to other response
+// files, so that a file that refers to itself doesn't loop forever.
+const maxResponseDepth = 16
+
+// ExpandResponseFiles returns args with every argument that starts with "@"
+// replaced by the arguments read from the file named by the rest of it, for
+// commands that get more arguments than the OS allows or that are run by
+// build systems.
+// The arguments in the file are separated by whitespace, which can be kept
+// by quoting with single quotes, inside of which nothing is special, or
+// double quotes, inside of which backslash escapes a double quote or a
+// backslash, or by escaping it with a backslash outside of quotes.
+// The files can refer to other response files.
+// An argument that starts with "@@" is kept with the first "@" removed, and
+// the arguments after "--" are kept as they are.
+func ExpandResponseFiles(args []string) ([]string, error) {
+	return expandResponseFiles(args, 0)
+}
+
+func expandResponseFiles(args []string, depth int) ([]string, error) {
+	var expanded []string
+	for i, arg := range args {
+		switch {
+		case arg == "--":
+			return append(expanded, args[i:]...), nil
+		case strings.HasPrefix(arg, "@@"):
+			expanded = append(expanded, arg[1:])
+		case strings.HasPrefix(arg, "@") && len(arg) > 1:
+			if depth >= maxResponseDepth {
+				return nil, fmt.Errorf("response file %s: nested too deeply", arg[1:])
+			}
+			data, err := os.ReadFile(arg[1:])
+			if err != nil {
+				return nil, err
+			}
+			fileArgs, err := splitResponseFile(string(data))
+			if err != nil {
+				return nil, fmt.Errorf("response file %s: %w", arg[1:], err)
+			}
+			fileArgs, err = expandResponseFiles(fileArgs, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			expanded = append(expanded, fileArgs...)
+		default:
+			expanded = append(expanded, arg)
+		}
+	}
+	return expanded, nil
+}
+
+// splitResponseFile splits the contents of a response file into arguments
+// with the quoting rules described in [ExpandResponseFiles].
+func splitResponseFile(s string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				arg.WriteByte(c)
+			}
+		case quote == '"':
+			if c == '\\' && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\') {
+				i++
+				arg.WriteByte(s[i])
+			} else if c == '"' {
+				quote = 0
+			} else {
+				arg.WriteByte(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inArg = true
+		case c == '\\' && i+1 < len(s):
+			i++
+			arg.WriteByte(s[i])
+			inArg = true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteByte(c)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
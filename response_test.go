@@ -0,0 +1,45 @@
+package cmds
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandResponseFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	inner := write("inner.txt", "c\n")
+	outer := write("outer.txt", "-m 'a b' \"x \\\"y\\\" \\\\\" a\\ b\n\t''\n@"+inner+"\n")
+	self := write("self.txt", "@"+filepath.Join(dir, "self.txt"))
+	quote := write("quote.txt", "'a")
+
+	args, err := ExpandResponseFiles([]string{"req", "@" + outer, "@@x", "@", "--", "@" + inner})
+	expectErrorNone(t, err)
+	expectEq(t, args, []string{"req", "-m", "a b", "x \"y\" \\", "a b", "", "c", "@x", "@", "--", "@" + inner})
+
+	_, err = ExpandResponseFiles([]string{"@" + self})
+	expectError(t, err)
+	_, err = ExpandResponseFiles([]string{"@" + quote})
+	expectError(t, err)
+
+	var got []string
+	cmd := &Command{
+		Name:          "test",
+		ResponseFiles: true,
+		Runner: func(cmd *Command, args []string) error {
+			got = args
+			return nil
+		},
+	}
+	expectErrorNone(t, cmd.ParseRun([]string{"@" + inner}))
+	expectEq(t, got, []string{"c"})
+	expectErrorIs(t, cmd.ParseRun([]string{"@" + filepath.Join(dir, "missing")}), ErrCmd)
+}
@@ -0,0 +1,200 @@
+package cmds
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"reflect"
+	"sync/atomic"
+)
+
+// ParseResult is the result of [Command.ParseArgs].
+type ParseResult struct {
+	// Command is a copy of the leaf command that matched, it's Flags are the
+	// flags parsed for this invocation and it's [Command.Parent] is a copy of
+	// it's parent command and so on up to the root command.
+	Command *Command
+
+	// Args are the arguments that should be passed to the Runner of Command.
+	Args []string
+}
+
+// ParseArgs is like [Command.Parse] but it doesn't modify cmd or it's
+// sub-commands, so that one tree can be parsed from multiple goroutines at the
+// same time, like by a server that embeds the program.
+//
+// The commands from the root to the leaf command that matched are copied for
+// the invocation, with FlagSets that have the same flags as the originals but
+// their own values, which start out as the values of the original flags.
+// That means that the variables that the flags were defined with aren't set,
+// the values have to be looked up in the Flags of the command passed to the
+// Runner, for example with [flag.Getter].
+// Flag values that aren't pointers, like the ones defined with [flag.Func],
+// can't be copied and are shared with the originals.
+func (cmd *Command) ParseArgs(args []string) (*ParseResult, error) {
+	leafCmd, args, err := cmd.parseHandle(args, true)
+	if err != nil || leafCmd == nil {
+		return nil, err
+	}
+	return &ParseResult{Command: leafCmd, Args: args}, nil
+}
+
+// Run runs the Runner of r.Command with r.Args and ctx, the same way as
+// [Command.ParseRunContext] does after parsing.
+func (r *ParseResult) Run(ctx context.Context) error {
+	return r.Command.root().runLeaf(ctx, r.Command, r.Args)
+}
+
+// Clone returns a copy of cmd and it's sub-commands with copies of their
+// FlagSets, like the ones that [Command.ParseArgs] makes of the commands it
+// matches, so that the copy can be changed and parsed without modifying cmd,
+// like by tests that run in parallel.
+// The FlagsFunc of the commands are called, the commands with a LoadFunc
+// aren't loaded and share the loaded command with cmd.
+func (cmd *Command) Clone() *Command {
+	cmd.loadFlags()
+	cmd.nameCommands()
+	c := cmd.parseCopy(nil)
+	c.findIndex, c.usageCache = atomic.Value{}, atomic.Value{}
+	c.Commands = make([]*Command, len(cmd.Commands))
+	for i, sub := range cmd.Commands {
+		c.Commands[i] = sub.Clone()
+	}
+	return c
+}
+
+// parseCopy returns a copy of cmd with parent and a copy of it's FlagSet, for
+// parsing without modifying cmd.
+func (cmd *Command) parseCopy(parent *Command) *Command {
+	// The copy shares the lazyState of cmd.
+	cmd.lazyState()
+	c := *cmd
+	c.parent = parent
+	c.ctx = nil
+	c.cleanups = nil
+	c.exitHooks = nil
+	c.sources = nil
+	if cmd.logLevel != nil {
+		// So that the log flags set the level of the copy.
+		c.logLevel = &slog.LevelVar{}
+		c.logLevel.Set(cmd.logLevel.Level())
+		if cmd.logFlagsLogger {
+			c.logger = newLogFlagsLogger(&c)
+		}
+	}
+	if cmd.Flags != nil {
+		c.Flags = cloneFlags(cmd.Flags)
+		if isDefaultUsage(cmd.Flags.Usage) {
+			c.Flags.Usage = c.DefaultUsage()
+		}
+		c.rebindFlags(cmd)
+	}
+	return &c
+}
+
+// rebindFlags makes the flags of c that set the fields of cmd, like the
+// -dry-run flag added with [Command.AddDryRunFlag] or the log level of
+// [Command.AddLogFlags], set the fields of c instead, c being a copy of cmd
+// with cloned flags.
+func (c *Command) rebindFlags(cmd *Command) {
+	bools := []struct{ orig, copy *bool }{
+		{&cmd.DryRun, &c.DryRun},
+		{&cmd.Yes, &c.Yes},
+		{&cmd.NoPager, &c.NoPager},
+		{&cmd.NoEmoji, &c.NoEmoji},
+	}
+	c.Flags.VisitAll(func(f *flag.Flag) {
+		orig := cmd.Flags.Lookup(f.Name).Value
+		switch v := orig.(type) {
+		case outputFlag:
+			if v.cmd == cmd {
+				f.Value = outputFlag{c}
+			}
+			return
+		case levelFlag:
+			if v.level == cmd.logLevel {
+				f.Value = levelFlag{c.logLevel}
+			}
+			return
+		case verboseFlag:
+			if v.level == cmd.logLevel {
+				f.Value = verboseFlag{c.logLevel}
+			}
+			return
+		case quietFlag:
+			if v.level == cmd.logLevel {
+				f.Value = quietFlag{c.logLevel}
+			}
+			return
+		}
+		rv := reflect.ValueOf(orig)
+		if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Bool {
+			return
+		}
+		for _, b := range bools {
+			if rv.Pointer() == reflect.ValueOf(b.orig).Pointer() {
+				f.Value = boolValue(b.copy)
+				return
+			}
+		}
+	})
+}
+
+// boolValue returns the value of a bool flag that sets *p.
+func boolValue(p *bool) flag.Value {
+	fset := flag.NewFlagSet("", flag.ContinueOnError)
+	fset.BoolVar(p, "b", *p, "")
+	return fset.Lookup("b").Value
+}
+
+// cloneFlags returns a new FlagSet with the same settings and flags as fset,
+// with copies of the flag values, the flags that share a value, like aliases,
+// share it's copy.
+func cloneFlags(fset *flag.FlagSet) *flag.FlagSet {
+	clone := flag.NewFlagSet(fset.Name(), fset.ErrorHandling())
+	clone.SetOutput(fset.Output())
+	clone.Usage = fset.Usage
+	copies := make(map[flag.Value]flag.Value)
+	fset.VisitAll(func(f *flag.Flag) {
+		// Only pointers are looked up, other values may not be comparable.
+		ptr := reflect.ValueOf(f.Value).Kind() == reflect.Pointer
+		var value flag.Value
+		if ptr {
+			value = copies[f.Value]
+		}
+		if value == nil {
+			value = cloneValue(f.Value)
+			if ptr {
+				copies[f.Value] = value
+			}
+		}
+		clone.Var(value, f.Name, f.Usage)
+		clone.Lookup(f.Name).DefValue = f.DefValue
+	})
+	return clone
+}
+
+// cloneValue returns a copy of v if it's a pointer, which the values of the
+// flag package are, or v itself otherwise.
+func cloneValue(v flag.Value) flag.Value {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return v
+	}
+
+	c := reflect.New(rv.Elem().Type())
+	c.Elem().Set(rv.Elem())
+	if cv, ok := c.Interface().(flag.Value); ok {
+		return cv
+	}
+	return v
+}
+
+// isDefaultUsage reports whether usage was returned by
+// [Command.DefaultUsage], of any command.
+func isDefaultUsage(usage func()) bool {
+	if usage == nil {
+		return false
+	}
+	return reflect.ValueOf(usage).Pointer() == reflect.ValueOf((&Command{}).DefaultUsage()).Pointer()
+}
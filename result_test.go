@@ -0,0 +1,221 @@
+package cmds
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	var count int
+	cmd := &Command{
+		Name:  "test",
+		Flags: flag.NewFlagSet("test", flag.ContinueOnError),
+		Commands: []*Command{
+			{
+				Name:  "echo",
+				Flags: flag.NewFlagSet("echo", flag.ContinueOnError),
+				Runner: func(cmd *Command, args []string) error {
+					n := cmd.Flags.Lookup("n").Value.(flag.Getter).Get().(int)
+					fmt.Fprint(cmd.Output(), strings.Repeat(strings.Join(args, " "), n))
+					return nil
+				},
+			},
+			HelpCommand(),
+		},
+	}
+	cmd.Flags.IntVar(&count, "c", 1, "")
+	cmd.Commands[0].Flags.Int("n", 1, "")
+	cmd.Commands[0].Flags.Usage = cmd.Commands[0].DefaultUsage()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r, err := cmd.ParseArgs([]string{"-c", fmt.Sprint(i), "echo", "-n", "2", fmt.Sprint(i)})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			expectEq(t, r.Command.Path(), []string{"test", "echo"})
+			expectEq(t, r.Command.Parent().Flags.Lookup("c").Value.String(), fmt.Sprint(i))
+
+			var b strings.Builder
+			r.Command.Stdout = &b
+			expectErrorNone(t, r.Run(context.Background()))
+			expectEq(t, b.String(), fmt.Sprintf("%d%d", i, i))
+		}(i)
+	}
+	wg.Wait()
+
+	// The tree isn't modified.
+	expectEq(t, count, 1)
+	expectEq(t, cmd.Commands[0].Parent(), (*Command)(nil))
+	expectEq(t, cmd.Commands[0].Flags.Lookup("n").Value.String(), "1")
+	expectEq(t, cmd.Commands[0].Flags.Parsed(), false)
+	expectEq(t, cmd.Commands[1].Flags.Parsed(), false)
+
+	// The usage message is of the copies.
+	var b strings.Builder
+	cmd.Commands[0].Flags.SetOutput(&b)
+	_, err := cmd.ParseArgs([]string{"echo", "-h"})
+	expectErrorIs(t, err, ErrHelp)
+	expectTrue(t, strings.HasPrefix(b.String(), "Usage: test echo [-n int]\n"))
+
+	b.Reset()
+	r, err := cmd.ParseArgs([]string{"help", "-json"})
+	expectErrorNone(t, err)
+	r.Command.Stdout = &b
+	expectErrorNone(t, r.Run(context.Background()))
+	expectTrue(t, strings.HasPrefix(b.String(), "{\n\t\"name\": \"test\""))
+}
+
+func TestParseArgsFlagsFunc(t *testing.T) {
+	var calls atomic.Int32
+	cmd := &Command{
+		Name: "test",
+		Commands: []*Command{
+			{
+				Name: "echo",
+				FlagsFunc: func() *flag.FlagSet {
+					calls.Add(1)
+					fset := flag.NewFlagSet("echo", flag.ContinueOnError)
+					fset.Int("n", 1, "")
+					return fset
+				},
+				Runner: nopRunner,
+			},
+		},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r, err := cmd.ParseArgs([]string{"echo", "-n", fmt.Sprint(i)})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			expectEq(t, r.Command.Flags.Lookup("n").Value.String(), fmt.Sprint(i))
+		}(i)
+	}
+	wg.Wait()
+	expectEq(t, calls.Load(), int32(1))
+}
+
+func TestParseArgsLoadFunc(t *testing.T) {
+	var calls atomic.Int32
+	cmd := &Command{
+		Name: "test",
+		Commands: []*Command{
+			{
+				Name: "echo",
+				LoadFunc: func() (*Command, error) {
+					calls.Add(1)
+					fset := flag.NewFlagSet("echo", flag.ContinueOnError)
+					fset.Int("n", 1, "")
+					return &Command{Name: "loaded", Flags: fset, Runner: nopRunner}, nil
+				},
+			},
+		},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r, err := cmd.ParseArgs([]string{"echo", "-n", fmt.Sprint(i)})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			expectEq(t, r.Command.Path(), []string{"test", "echo"})
+			expectEq(t, r.Command.Flags.Lookup("n").Value.String(), fmt.Sprint(i))
+		}(i)
+	}
+	wg.Wait()
+	expectEq(t, calls.Load(), int32(1))
+
+	// The tree isn't modified.
+	expectTrue(t, cmd.Commands[0].LoadFunc != nil)
+	expectTrue(t, cmd.Commands[0].Flags == nil)
+}
+
+func TestParseArgsFieldFlags(t *testing.T) {
+	var done bool
+	cmd := &Command{
+		Name: "test",
+		Commands: []*Command{
+			{Name: "rm", Runner: func(cmd *Command, args []string) error {
+				return cmd.Do("remove", func() error {
+					done = true
+					return nil
+				})
+			}},
+		},
+	}
+	cmd.AddDryRunFlag()
+	cmd.AddYesFlag()
+	cmd.AddOutputFlag()
+	cmd.AddNoPagerFlag()
+	cmd.AddNoEmojiFlag()
+
+	tests := []struct {
+		flag  string
+		check func(c *Command) bool
+	}{
+		{"-dry-run", (*Command).IsDryRun},
+		{"-yes", (*Command).IsYes},
+		{"-y", (*Command).IsYes},
+		{"-no-pager", (*Command).IsNoPager},
+		{"-no-emoji", (*Command).IsNoEmoji},
+		{"-output=json", func(c *Command) bool { return c.Parent().OutputFormat == "json" }},
+	}
+	for _, test := range tests {
+		r, err := cmd.ParseArgs([]string{test.flag, "rm"})
+		expectErrorNone(t, err)
+		if !test.check(r.Command) {
+			t.Errorf("%s isn't set on the parsed command", test.flag)
+		}
+	}
+
+	r, err := cmd.ParseArgs([]string{"-dry-run", "rm"})
+	expectErrorNone(t, err)
+	r.Command.Stdout = &strings.Builder{}
+	expectErrorNone(t, r.Run(context.Background()))
+	expectTrue(t, !done)
+
+	// The tree isn't modified.
+	expectTrue(t, !cmd.DryRun && !cmd.Yes && !cmd.NoPager && !cmd.NoEmoji)
+	expectEq(t, cmd.OutputFormat, "")
+}
+
+func TestClone(t *testing.T) {
+	var n int
+	cmd := &Command{
+		Name:     "test",
+		Commands: []*Command{{Runner: runEcho, Flags: flag.NewFlagSet("echo", flag.ContinueOnError)}},
+	}
+	cmd.Commands[0].Flags.IntVar(&n, "n", 1, "")
+	cmd.AddDryRunFlag()
+
+	c := cmd.Clone()
+	c.Stdout = &strings.Builder{}
+	expectErrorNone(t, c.ParseRun([]string{"-dry-run", "echo", "-n", "2"}))
+	expectTrue(t, c.DryRun)
+	expectEq(t, c.Commands[0].Flags.Lookup("n").Value.String(), "2")
+
+	// The tree isn't modified.
+	expectTrue(t, !cmd.DryRun)
+	expectEq(t, n, 1)
+	expectEq(t, cmd.Commands[0].Name, "echo")
+	expectEq(t, cmd.Commands[0].Parent(), (*Command)(nil))
+}
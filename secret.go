@@ -0,0 +1,47 @@
+package cmds
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SecretProvider resolves a reference to a secret, like
+// "vault://kv/token", to the value of the secret, it's passed the whole
+// reference including the scheme.
+type SecretProvider func(ref string) (string, error)
+
+// FileSecret is a [SecretProvider] for the "file" scheme that resolves
+// references like "file:///run/secrets/token" to the contents of the file
+// without the trailing newline, like the secrets of Docker and systemd.
+func FileSecret(ref string) (string, error) {
+	path, ok := strings.CutPrefix(ref, "file://")
+	if !ok || path == "" {
+		return "", errors.New(DefaultMessages.FileSecret)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// resolveSecret returns value resolved by the SecretProviders of root if
+// it's a reference to a secret, or value as is.
+func (cmd *Command) resolveSecret(root *Command, name, value string) (string, error) {
+	scheme, _, ok := strings.Cut(value, "://")
+	if !ok {
+		return value, nil
+	}
+	provider := root.SecretProviders[scheme]
+	if provider == nil {
+		return value, nil
+	}
+
+	secret, err := provider(value)
+	if err != nil {
+		return "", newFlagErrorReason(cmd, name, err, fmt.Sprintf(cmd.messages().SecretInvalid, name, err))
+	}
+	return secret, nil
+}
@@ -0,0 +1,55 @@
+package cmds
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSecretProviders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_TOKEN", "file://"+path)
+	t.Setenv("TEST_URL", "https://example.com")
+
+	var token, password, url, other string
+	testCmd := func(config testConfig) *Command {
+		return &Command{
+			Name:      "test",
+			Runner:    nopRunner,
+			EnvPrefix: "test",
+			Config:    config,
+			SecretProviders: map[string]SecretProvider{
+				"file": FileSecret,
+				"vault": func(ref string) (string, error) {
+					if ref == "vault://kv/password" {
+						return "hunter2", nil
+					}
+					return "", errors.New("not found")
+				},
+			},
+			Flags: func() *flag.FlagSet {
+				fset := flag.NewFlagSet("test", flag.ContinueOnError)
+				fset.StringVar(&token, "token", "", "")
+				fset.StringVar(&password, "password", "", "")
+				fset.StringVar(&url, "url", "", "")
+				fset.StringVar(&other, "other", "", "")
+				return fset
+			}(),
+		}
+	}
+
+	expectErrorNone(t, testCmd(testConfig{"test.password": "vault://kv/password"}).ParseRun([]string{"-other", "vault://kv/other"}))
+	expectEq(t, []string{token, password, url, other}, []string{"s3cret", "hunter2", "https://example.com", "vault://kv/other"})
+
+	err := testCmd(testConfig{"test.password": "vault://kv/missing"}).ParseRun(nil)
+	expectErrorIs(t, err, ErrFlag)
+	expectEq(t, err.Error(), "command error: flag parse error: can't resolve the secret for flag -password: not found")
+
+	_, err = FileSecret("file://")
+	expectError(t, err)
+}
@@ -0,0 +1,130 @@
+package cmds
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Source is where the value of a flag came from, returned by
+// [Command.ValueSource].
+//
+// The flags of each command are resolved right after they're parsed, so that
+// the flags that are Required in their [FlagMeta] can be set from any of the
+// sources and sub-commands can depend on the flags of their parents. A flag
+// that isn't given on the command line is set from the first that has a
+// value of:
+//
+//  1. The environment variable in the Env of it's FlagMeta, or the one named
+//     after the EnvPrefix of the root command.
+//  2. The Config of the root command.
+//  3. Otherwise it keeps it's default value.
+//
+// After that the flags that are still not set are prompted for if the
+// command has Prompt set, see [Command.Prompt].
+//
+// The values from the environment and Config are resolved by the
+// SecretProviders of the root command first.
+//
+// The values are set with [flag.Value.Set] the same way as on the command
+// line, for every type of flag.
+//
+//go:generate stringer -type Source
+type Source int
+
+const (
+	SourceDefault Source = iota
+	SourceConfig
+	SourceEnv
+	SourceCommandLine
+	SourcePrompt
+)
+
+// ValueSource returns where the value of the flag name of cmd, or of the
+// closest of it's parents that has the flag, came from during the last parse,
+// it's SourceDefault for flags that don't exist.
+func (cmd *Command) ValueSource(name string) Source {
+	for c := cmd; c != nil; c = c.parent {
+		if c.Flags == nil || c.Flags.Lookup(name) == nil {
+			continue
+		}
+		return c.sources[name]
+	}
+	return SourceDefault
+}
+
+// envName returns the environment variable that sets the flag name of cmd,
+// or an empty string if there's none.
+func (cmd *Command) envName(root *Command, name string) string {
+	if meta := cmd.FlagMeta[name]; meta != nil && meta.Env != "" {
+		return meta.Env
+	}
+	if root.EnvPrefix == "" {
+		return ""
+	}
+	return strings.ToUpper(shellIdent(root.EnvPrefix + "_" + name))
+}
+
+// resolveFlags sets the flags of cmd that weren't given on the command line
+// from the sources described in [Source] and records where the values came
+// from.
+func (cmd *Command) resolveFlags(root *Command) error {
+	// The map is reused between parses, so that parsing again doesn't allocate
+	// it.
+	if cmd.sources == nil {
+		cmd.sources = make(map[string]Source)
+	} else {
+		clear(cmd.sources)
+	}
+	cmd.visitSet(func(f *flag.Flag) {
+		cmd.sources[f.Name] = SourceCommandLine
+	})
+
+	// The path is only needed for Config.
+	var path []string
+	if root.Config != nil {
+		path = cmd.Path()
+	}
+	var err error
+	cmd.Flags.VisitAll(func(f *flag.Flag) {
+		if err != nil || cmd.sources[f.Name] == SourceCommandLine {
+			return
+		}
+
+		if env := cmd.envName(root, f.Name); env != "" {
+			if value, ok := os.LookupEnv(env); ok {
+				if value, err = cmd.resolveSecret(root, f.Name, value); err != nil {
+					return
+				}
+				if setErr := cmd.Flags.Set(f.Name, value); setErr != nil {
+					err = newFlagErrorReason(cmd, f.Name, setErr, fmt.Sprintf(cmd.messages().FlagEnvInvalid, value, f.Name, env, setErr))
+					return
+				}
+				cmd.sources[f.Name] = SourceEnv
+				return
+			}
+		}
+
+		if root.Config == nil {
+			return
+		}
+		value, ok, lookupErr := root.Config.Lookup(path, f.Name)
+		if lookupErr != nil {
+			err = fmt.Errorf("%w: %w", Err, lookupErr)
+			return
+		}
+		if !ok {
+			return
+		}
+		if value, err = cmd.resolveSecret(root, f.Name, value); err != nil {
+			return
+		}
+		if setErr := cmd.Flags.Set(f.Name, value); setErr != nil {
+			err = newFlagErrorReason(cmd, f.Name, setErr, fmt.Sprintf(cmd.messages().FlagConfigInvalid, value, f.Name, setErr))
+			return
+		}
+		cmd.sources[f.Name] = SourceConfig
+	})
+	return err
+}
@@ -0,0 +1,73 @@
+package cmds
+
+import (
+	"errors"
+	"flag"
+	"testing"
+)
+
+func TestValueSource(t *testing.T) {
+	t.Setenv("TEST_TOKEN", "env")
+	t.Setenv("METHOD", "HEAD")
+	t.Setenv("TEST_N", "x")
+
+	var token, name, method string
+	testCmd := func() *Command {
+		cmd := &Command{
+			Name:      "test",
+			EnvPrefix: "test",
+			Config:    testConfig{"test.token": "config", "test.name": "config"},
+			Flags: func() *flag.FlagSet {
+				fset := flag.NewFlagSet("test", flag.ContinueOnError)
+				fset.StringVar(&token, "token", "", "")
+				fset.StringVar(&name, "name", "", "")
+				fset.String("unset", "default", "")
+				return fset
+			}(),
+			Commands: []*Command{
+				{
+					Name:   "req",
+					Runner: nopRunner,
+					Flags: func() *flag.FlagSet {
+						fset := flag.NewFlagSet("req", flag.ContinueOnError)
+						fset.StringVar(&method, "m", "GET", "")
+						return fset
+					}(),
+				},
+			},
+		}
+		cmd.Commands[0].Meta("m").Env = "METHOD"
+		return cmd
+	}
+
+	cmd := testCmd()
+	expectErrorNone(t, cmd.ParseRun([]string{"req"}))
+	expectEq(t, []string{token, name, method}, []string{"env", "config", "HEAD"})
+	req := cmd.Commands[0]
+	expectEq(t, req.ValueSource("token"), SourceEnv)
+	expectEq(t, req.ValueSource("name"), SourceConfig)
+	expectEq(t, req.ValueSource("m"), SourceEnv)
+	expectEq(t, req.ValueSource("unset"), SourceDefault)
+	expectEq(t, req.ValueSource("missing"), SourceDefault)
+	expectEq(t, SourceCommandLine.String(), "SourceCommandLine")
+
+	cmd = testCmd()
+	expectErrorNone(t, cmd.ParseRun([]string{"-token", "flag", "req", "-m", "PUT"}))
+	expectEq(t, []string{token, method}, []string{"flag", "PUT"})
+	expectEq(t, cmd.ValueSource("token"), SourceCommandLine)
+	expectEq(t, cmd.Commands[0].ValueSource("m"), SourceCommandLine)
+
+	cmd = testCmd()
+	cmd.Reset()
+	expectEq(t, cmd.ValueSource("token"), SourceDefault)
+
+	cmd = testCmd()
+	cmd.Flags.Int("n", 0, "")
+	err := cmd.ParseRun([]string{"req"})
+	expectErrorIs(t, err, ErrFlag)
+	var flagErr *FlagError
+	if !errors.As(err, &flagErr) {
+		t.Fatalf("expected *FlagError, got %#v", err)
+	}
+	expectEq(t, flagErr.Flag, "n")
+}
@@ -0,0 +1,187 @@
+package cmds
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CommandSpec is the description of a command and it's sub-commands that
+// [Command.GenJSONSpec] outputs, for tools like documentation sites that
+// present a CLI from it's definition.
+type CommandSpec struct {
+	Name        string         `json:"name"`
+	Aliases     []string       `json:"aliases,omitempty"`
+	Summary     string         `json:"summary,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Usage       string         `json:"usage"`
+	Args        string         `json:"args,omitempty"`
+	Deprecated  string         `json:"deprecated,omitempty"`
+	Examples    []Example      `json:"examples,omitempty"`
+	Flags       []FlagSpec     `json:"flags,omitempty"`
+	Commands    []*CommandSpec `json:"commands,omitempty"`
+}
+
+// FlagSpec is the description of a flag in a [CommandSpec], or of a flag to
+// define with [FlagsFromMap].
+type FlagSpec struct {
+	Name    string `json:"name"`
+	Usage   string `json:"usage,omitempty"`
+	Default string `json:"default,omitempty"`
+
+	// Type is the type of the value of the flag, one of the ones that
+	// [FlagsFromMap] supports, or empty for other types.
+	Type string `json:"type,omitempty"`
+
+	Bool       bool     `json:"bool,omitempty"`
+	Required   bool     `json:"required,omitempty"`
+	Choices    []string `json:"choices,omitempty"`
+	Env        string   `json:"env,omitempty"`
+	Deprecated string   `json:"deprecated,omitempty"`
+}
+
+// Spec returns the description of cmd and it's sub-commands, without the
+// hidden ones and help topics.
+func (cmd *Command) Spec() *CommandSpec {
+	return cmd.spec(cmd.root())
+}
+
+func (cmd *Command) spec(root *Command) *CommandSpec {
+	v := treeView{deprecated: true}
+	s := &CommandSpec{
+		Name:        cmd.Name,
+		Aliases:     cmd.Aliases,
+		Summary:     cmd.Short(),
+		Description: cmd.LongDesc,
+		Usage:       cmd.Synopsis(),
+		Args:        cmd.ArgsUsage,
+		Deprecated:  cmd.Deprecated,
+		Examples:    cmd.Examples,
+	}
+	for _, f := range v.flags(cmd) {
+		meta := cmd.FlagMeta[f.Name]
+		fs := FlagSpec{
+			Name:    f.Name,
+			Usage:   FlagUsage(f),
+			Default: f.DefValue,
+			Type:    flagType(f),
+			Bool:    isBoolFlag(f),
+			Env:     cmd.envName(root, f.Name),
+		}
+		if meta != nil {
+			fs.Required = meta.Required
+			fs.Choices = meta.Choices
+			fs.Deprecated = meta.Deprecated
+		}
+		s.Flags = append(s.Flags, fs)
+	}
+	for _, sub := range v.commands(cmd) {
+		s.Commands = append(s.Commands, sub.spec(root))
+	}
+	return s
+}
+
+// GenJSONSpec writes the [CommandSpec] of cmd to w as indented JSON.
+func (cmd *Command) GenJSONSpec(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(cmd.Spec())
+}
+
+// GenUsageSpec writes the description of cmd and it's sub-commands to w in the
+// KDL format of usage (https://usage.jdx.dev), which tools that consume it
+// generate completions, documentation and man pages from.
+// The flags with a single letter name are the short flags, like "-v", the
+// other ones are the long flags, like "--name", since both forms are accepted.
+func (cmd *Command) GenUsageSpec(w io.Writer) error {
+	s := cmd.Spec()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "name %s\n", kdlQuote(s.Name))
+	fmt.Fprintf(&b, "bin %s\n", kdlQuote(s.Name))
+	if s.Summary != "" {
+		fmt.Fprintf(&b, "about %s\n", kdlQuote(s.Summary))
+	}
+	if s.Description != "" {
+		fmt.Fprintf(&b, "long_about %s\n", kdlQuote(s.Description))
+	}
+	writeUsageSpecBody(&b, s, "")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeUsageSpecBody(b *strings.Builder, s *CommandSpec, indent string) {
+	for _, f := range s.Flags {
+		usage := "--" + f.Name
+		if len(f.Name) == 1 {
+			usage = "-" + f.Name
+		}
+		if !f.Bool {
+			usage += " <" + f.Name + ">"
+		}
+		fmt.Fprintf(b, "%sflag %s", indent, kdlQuote(usage))
+		if f.Usage != "" {
+			fmt.Fprintf(b, " help=%s", kdlQuote(f.Usage))
+		}
+		if f.Required {
+			b.WriteString(" required=#true")
+		}
+		if f.Default != "" && !f.Bool {
+			fmt.Fprintf(b, " default=%s", kdlQuote(f.Default))
+		}
+		if f.Env != "" {
+			fmt.Fprintf(b, " env=%s", kdlQuote(f.Env))
+		}
+		if f.Deprecated != "" {
+			fmt.Fprintf(b, " deprecated=%s", kdlQuote(f.Deprecated))
+		}
+		if len(f.Choices) > 0 {
+			fmt.Fprintf(b, " {\n%s\tchoices", indent)
+			for _, c := range f.Choices {
+				fmt.Fprintf(b, " %s", kdlQuote(c))
+			}
+			fmt.Fprintf(b, "\n%s}", indent)
+		}
+		b.WriteString("\n")
+	}
+
+	for _, arg := range strings.Fields(s.Args) {
+		fmt.Fprintf(b, "%sarg %s\n", indent, kdlQuote(arg))
+	}
+
+	for _, sub := range s.Commands {
+		fmt.Fprintf(b, "%scmd %s", indent, kdlQuote(sub.Name))
+		if sub.Summary != "" {
+			fmt.Fprintf(b, " help=%s", kdlQuote(sub.Summary))
+		}
+		if sub.Description != "" {
+			fmt.Fprintf(b, " long_help=%s", kdlQuote(sub.Description))
+		}
+		if sub.Deprecated != "" {
+			fmt.Fprintf(b, " deprecated=%s", kdlQuote(sub.Deprecated))
+		}
+
+		var body strings.Builder
+		for _, alias := range sub.Aliases {
+			fmt.Fprintf(&body, "%s\talias %s\n", indent, kdlQuote(alias))
+		}
+		writeUsageSpecBody(&body, sub, indent+"\t")
+		if body.Len() > 0 {
+			fmt.Fprintf(b, " {\n%s%s}", body.String(), indent)
+		}
+		b.WriteString("\n")
+	}
+}
+
+// kdlQuote returns s as a quoted KDL string.
+func kdlQuote(s string) string {
+	return `"` + strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\n", `\n`,
+		"\r", `\r`,
+		"\t", `\t`,
+	).Replace(s) + `"`
+}
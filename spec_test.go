@@ -0,0 +1,70 @@
+package cmds
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"testing"
+)
+
+func specTestCmd() *Command {
+	cmd := &Command{
+		Name:      "test",
+		ShortDesc: "test things",
+		EnvPrefix: "test",
+		Flags:     flag.NewFlagSet("test", flag.ContinueOnError),
+		Commands: []*Command{
+			{
+				Name:      "req",
+				Aliases:   []string{"r"},
+				ShortDesc: "make a \"request\"",
+				ArgsUsage: "<url> [data...]",
+				Flags:     flag.NewFlagSet("req", flag.ContinueOnError),
+			},
+			{Name: "hidden", Hidden: true},
+			{Name: "topic", LongDesc: "a help topic"},
+		},
+	}
+	cmd.Flags.Bool("v", false, "verbose output")
+	cmd.Flags.String("secret", "", "")
+	cmd.Meta("secret").Hidden = true
+	req := cmd.Commands[0]
+	req.Flags.String("method", "GET", "HTTP method")
+	req.Meta("method").Choices = []string{"GET", "HEAD"}
+	req.Meta("method").Required = true
+	return cmd
+}
+
+func TestGenJSONSpec(t *testing.T) {
+	var b bytes.Buffer
+	expectErrorNone(t, specTestCmd().GenJSONSpec(&b))
+
+	var s CommandSpec
+	expectErrorNone(t, json.Unmarshal(b.Bytes(), &s))
+	expectEq(t, s.Name, "test")
+	expectEq(t, s.Flags, []FlagSpec{{Name: "v", Usage: "verbose output", Default: "false", Type: "bool", Bool: true, Env: "TEST_V"}})
+	expectEq(t, len(s.Commands), 1)
+	expectEq(t, s.Commands[0].Args, "<url> [data...]")
+	expectEq(t, s.Commands[0].Flags, []FlagSpec{{
+		Name: "method", Usage: "HTTP method", Default: "GET", Type: "string", Required: true,
+		Choices: []string{"GET", "HEAD"}, Env: "TEST_METHOD",
+	}})
+}
+
+func TestGenUsageSpec(t *testing.T) {
+	var b bytes.Buffer
+	expectErrorNone(t, specTestCmd().GenUsageSpec(&b))
+	expectEq(t, b.String(), `name "test"
+bin "test"
+about "test things"
+flag "-v" help="verbose output" env="TEST_V"
+cmd "req" help="make a \"request\"" {
+	alias "r"
+	flag "--method <method>" help="HTTP method" required=#true default="GET" env="TEST_METHOD" {
+		choices "GET" "HEAD"
+	}
+	arg "<url>"
+	arg "[data...]"
+}
+`)
+}
@@ -0,0 +1,37 @@
+package cmds
+
+import (
+	"flag"
+
+	"github.com/rgzlv/cmds/ui"
+)
+
+// AddNoEmojiFlag adds the -no-emoji flag that sets NoEmoji to the flags of
+// cmd, creating them if there are none.
+func (cmd *Command) AddNoEmojiFlag() {
+	if cmd.Flags == nil {
+		cmd.Flags = flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+		cmd.Flags.Usage = cmd.DefaultUsage()
+	}
+	cmd.Flags.BoolVar(&cmd.NoEmoji, "no-emoji", cmd.NoEmoji, cmd.messages().NoEmojiUsage)
+}
+
+// IsNoEmoji reports whether cmd or any of it's parents has NoEmoji set.
+func (cmd *Command) IsNoEmoji() bool {
+	for c := cmd; c != nil; c = c.parent {
+		if c.NoEmoji {
+			return true
+		}
+	}
+	return false
+}
+
+// Status returns a [ui.Status] that outputs to [Command.ErrOutput], with ASCII
+// prefixes if [Command.IsNoEmoji] or where [ui.NewStatus] uses them, and
+// quiet the same way as [Command.Progress].
+func (cmd *Command) Status() *ui.Status {
+	s := ui.NewStatus(cmd.ErrOutput())
+	s.ASCII = s.ASCII || cmd.IsNoEmoji()
+	s.Quiet = cmd.IsQuiet()
+	return s
+}
@@ -0,0 +1,38 @@
+package cmds
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	t.Setenv("NO_EMOJI", "")
+	t.Setenv("LC_ALL", "en_US.UTF-8")
+	var b strings.Builder
+	cmd := &Command{
+		Name:   "test",
+		Stderr: &b,
+		Runner: func(cmd *Command, args []string) error {
+			s := cmd.Status()
+			s.OK("built")
+			s.Fail("lint")
+			return nil
+		},
+	}
+	cmd.AddNoEmojiFlag()
+	cmd.AddLogFlags()
+
+	expectErrorNone(t, cmd.ParseRun(nil))
+	expectEq(t, b.String(), "✅ built\n❌ lint\n")
+
+	b.Reset()
+	cmd.Reset()
+	expectErrorNone(t, cmd.ParseRun([]string{"-no-emoji"}))
+	expectEq(t, b.String(), "[ok] built\n[fail] lint\n")
+
+	b.Reset()
+	cmd.Reset()
+	t.Setenv("NO_EMOJI", "1")
+	expectErrorNone(t, cmd.ParseRun([]string{"-q"}))
+	expectEq(t, b.String(), "[fail] lint\n")
+}
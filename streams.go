@@ -0,0 +1,37 @@
+package cmds
+
+import (
+	"io"
+	"os"
+)
+
+// Input returns the Stdin of cmd or the closest of it's parents, or
+// [os.Stdin] if none has it set.
+func (cmd *Command) Input() io.Reader {
+	for c := cmd; c != nil; c = c.parent {
+		if c.Stdin != nil {
+			return c.Stdin
+		}
+	}
+	return os.Stdin
+}
+
+// Output returns the Stdout of cmd or the closest of it's parents, or
+// [os.Stdout] if none has it set.
+func (cmd *Command) Output() io.Writer {
+	for c := cmd; c != nil; c = c.parent {
+		if c.Stdout != nil {
+			return c.Stdout
+		}
+	}
+	return os.Stdout
+}
+
+// setFlagsOutput makes the FlagSet of cmd output it's errors and usage
+// message to [Command.ErrOutput] if it's set and the FlagSet's output isn't.
+func (cmd *Command) setFlagsOutput() {
+	w := cmd.errOutputSet()
+	if w != nil && cmd.Flags.Output() == os.Stderr {
+		cmd.Flags.SetOutput(w)
+	}
+}
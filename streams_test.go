@@ -0,0 +1,46 @@
+package cmds
+
+import (
+	"flag"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStreams(t *testing.T) {
+	var out, errOut strings.Builder
+	cmd := &Command{
+		Name:   "test",
+		Stdin:  strings.NewReader("input"),
+		Stdout: &out,
+		Stderr: &errOut,
+		Flags:  flag.NewFlagSet("test", flag.ContinueOnError),
+		Commands: []*Command{
+			{Name: "cat", Runner: func(cmd *Command, args []string) error {
+				_, err := io.Copy(cmd.Output(), cmd.Input())
+				return err
+			}},
+			HelpCommand(),
+		},
+	}
+
+	cmd.Flags.Usage = cmd.DefaultUsage()
+
+	expectEq(t, (&Command{}).Input(), io.Reader(os.Stdin))
+	expectEq(t, (&Command{}).Output(), io.Writer(os.Stdout))
+
+	expectErrorNone(t, cmd.ParseRun([]string{"cat"}))
+	expectEq(t, out.String(), "input")
+	out.Reset()
+
+	expectErrorNone(t, cmd.ParseRun([]string{"help"}))
+	expectTrue(t, strings.HasPrefix(errOut.String(), "Usage: test"))
+	errOut.Reset()
+
+	expectErrorNone(t, cmd.ParseRun([]string{"help", "-json"}))
+	expectTrue(t, strings.HasPrefix(out.String(), "{\n\t\"name\": \"test\""))
+
+	expectErrorIs(t, cmd.ParseRun([]string{"-x"}), ErrFlag)
+	expectTrue(t, strings.HasPrefix(errOut.String(), "flag provided but not defined: -x\n"))
+}
@@ -0,0 +1,361 @@
+package cmds
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// StructDescriber is implemented by the structs given to [FromStruct] to
+// describe the commands made from their methods, since the doc comments of
+// methods aren't available at run time.
+// Describe is called with the name of the method and it's command, to set
+// fields like ShortDesc, ArgsUsage and Args.
+type StructDescriber interface {
+	Describe(method string, cmd *Command)
+}
+
+// FromStruct returns a command made from the struct that v points to.
+// The exported fields of the struct are the flags of the command, named after
+// the field in kebab case, like "dry-run" for DryRun, or after it's "flag" tag,
+// with the usage in it's "usage" tag and the current value as the default, a
+// field with the tag `flag:"-"` is skipped.
+// The fields can be a string, bool, int, int64, uint, uint64, float64,
+// [time.Duration] or implement [encoding.TextMarshaler] and
+// [encoding.TextUnmarshaler].
+//
+// The exported methods of v with one of these signatures are the
+// sub-commands, named after the method in kebab case, except for a method
+// named Run which is the Runner of the command itself:
+//
+//	func(args []string) error
+//	func(cmd *Command, args []string) error
+//
+// The other methods are ignored, if v implements [StructDescriber] it's
+// called for every command.
+// The command is named after the type of the struct in kebab case.
+//
+// Exported fields of struct types with a "cmd" tag, embedded or not, are
+// sub-commands made from the field the same way instead of flags, so that a
+// whole tree can be defined by one nested struct, the tag is the name of the
+// sub-command and optionally it's ShortDesc after a comma, like
+// `cmd:"serve,run the server"`, without a name it's named after the field in
+// kebab case.
+// The methods that embedded sub-commands promote to v are their own and not
+// sub-commands of v.
+func FromStruct(v any) (*Command, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("FromStruct needs a pointer to a struct")
+	}
+	return fromStruct(rv, kebabCase(rv.Elem().Type().Name()))
+}
+
+// fromStruct returns the command named name made from the struct that rv
+// points to.
+func fromStruct(rv reflect.Value, name string) (*Command, error) {
+	cmd := &Command{
+		Name:  name,
+		Flags: flag.NewFlagSet(name, flag.ContinueOnError),
+	}
+	cmd.Flags.Usage = cmd.DefaultUsage()
+	if err := structFlags(cmd, rv.Elem()); err != nil {
+		return nil, err
+	}
+
+	describer, _ := rv.Interface().(StructDescriber)
+	for _, m := range structMethods(rv.Type()) {
+		runner := methodRunner(rv.Method(m.index))
+		if m.name == "Run" {
+			cmd.Runner = runner
+			if describer != nil {
+				describer.Describe(m.name, cmd)
+			}
+			continue
+		}
+
+		sub := &Command{
+			Name:   m.cmdName,
+			Runner: runner,
+		}
+		if describer != nil {
+			describer.Describe(m.name, sub)
+		}
+		cmd.Commands = append(cmd.Commands, sub)
+	}
+
+	for _, sc := range structCommands(rv.Elem().Type()) {
+		sub, err := fromStruct(rv.Elem().Field(sc.index).Addr(), sc.name)
+		if err != nil {
+			return nil, fmt.Errorf("command %s: %w", sc.name, err)
+		}
+		if sub.ShortDesc == "" {
+			sub.ShortDesc = sc.usage
+		}
+		cmd.Commands = append(cmd.Commands, sub)
+	}
+
+	return cmd, nil
+}
+
+// Typed returns a command named name with the flags of the struct F, or that
+// F points to, that runs run with the context of the command and a new F with
+// the values of the flags, so that the Runner doesn't have to share a struct
+// with the flags through a closure.
+// The flags are the same as the ones of [FromStruct], except that the default
+// is the zero value or the value in the "default" tag of the field.
+// It panics if F isn't a struct or a pointer to one, or has a field of a type
+// that isn't supported or an invalid default, like the flag package panics
+// when a flag is defined twice.
+func Typed[F any](name string, run func(ctx context.Context, flags F, args []string) error) *Command {
+	rt := reflect.TypeOf((*F)(nil)).Elem()
+	ptr := rt.Kind() == reflect.Pointer
+	if ptr {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("Typed needs a struct or a pointer to one, got %s", rt))
+	}
+
+	// The flags are defined with a value of F with the defaults, the Runner
+	// makes a new one from the FlagSet, so that it works with copies of the
+	// command too.
+	defaults := reflect.New(rt).Elem()
+	for _, sf := range structFields(rt) {
+		def, ok := rt.Field(sf.index).Tag.Lookup("default")
+		if !ok {
+			continue
+		}
+		if err := setFieldString(defaults.Field(sf.index), def); err != nil {
+			panic(fmt.Sprintf("invalid default \"%s\" of field %s: %v", def, sf.field, err))
+		}
+	}
+
+	cmd := &Command{
+		Name:  name,
+		Flags: flag.NewFlagSet(name, flag.ContinueOnError),
+	}
+	cmd.Flags.Usage = cmd.DefaultUsage()
+	if err := structFlags(cmd, defaults); err != nil {
+		panic(err.Error())
+	}
+
+	cmd.Runner = func(cmd *Command, args []string) error {
+		rv := reflect.New(rt)
+		for _, sf := range structFields(rt) {
+			if err := setField(rv.Elem().Field(sf.index), cmd.Flags.Lookup(sf.name)); err != nil {
+				return fmt.Errorf("field %s from flag -%s: %w", sf.field, sf.name, err)
+			}
+		}
+		if !ptr {
+			rv = rv.Elem()
+		}
+		return run(cmd.Context(), rv.Interface().(F), args)
+	}
+	return cmd
+}
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// structField is an exported field of a struct given to [FromStruct] or
+// [Command.UnmarshalConfig] and the name of it's flag.
+type structField struct {
+	index int
+	field string
+	name  string
+	usage string
+}
+
+// structMethod is a method of a struct given to [FromStruct] with one of the
+// signatures of a Runner.
+type structMethod struct {
+	index   int
+	name    string
+	cmdName string
+}
+
+// The fields and methods of the struct types given to FromStruct, so that
+// making commands from the same type again doesn't go through them again,
+// like encoding/json caches the fields of the types it encodes.
+var (
+	structFieldsCache   sync.Map // map[reflect.Type][]structField
+	structCommandsCache sync.Map // map[reflect.Type][]structField
+	structMethodsCache  sync.Map // map[reflect.Type][]structMethod
+)
+
+// structFields returns the fields of the struct type t that are flags.
+func structFields(t reflect.Type) []structField {
+	if fields, ok := structFieldsCache.Load(t); ok {
+		return fields.([]structField)
+	}
+
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if _, ok := field.Tag.Lookup("cmd"); ok || !field.IsExported() {
+			continue
+		}
+		name, ok := field.Tag.Lookup("flag")
+		if name == "-" {
+			continue
+		}
+		if !ok || name == "" {
+			name = kebabCase(field.Name)
+		}
+		fields = append(fields, structField{index: i, field: field.Name, name: name, usage: field.Tag.Get("usage")})
+	}
+
+	actual, _ := structFieldsCache.LoadOrStore(t, fields)
+	return actual.([]structField)
+}
+
+// structCommands returns the fields of the struct type t that are
+// sub-commands, with the ShortDesc in the tag as their usage.
+func structCommands(t reflect.Type) []structField {
+	if commands, ok := structCommandsCache.Load(t); ok {
+		return commands.([]structField)
+	}
+
+	var commands []structField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("cmd")
+		if !ok || field.Type.Kind() != reflect.Struct || !field.IsExported() {
+			continue
+		}
+		name, short, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = kebabCase(field.Name)
+		}
+		commands = append(commands, structField{index: i, field: field.Name, name: name, usage: short})
+	}
+
+	actual, _ := structCommandsCache.LoadOrStore(t, commands)
+	return actual.([]structField)
+}
+
+var (
+	runnerArgsType = reflect.TypeOf(func(args []string) error { return nil })
+	runnerType     = reflect.TypeOf(func(cmd *Command, args []string) error { return nil })
+)
+
+// structMethods returns the methods of the type t, a pointer to a struct,
+// that are commands.
+func structMethods(t reflect.Type) []structMethod {
+	if methods, ok := structMethodsCache.Load(t); ok {
+		return methods.([]structMethod)
+	}
+
+	// The methods promoted from embedded sub-commands.
+	promoted := make(map[string]bool)
+	for _, sc := range structCommands(t.Elem()) {
+		if field := t.Elem().Field(sc.index); field.Anonymous {
+			ft := reflect.PointerTo(field.Type)
+			for j := 0; j < ft.NumMethod(); j++ {
+				promoted[ft.Method(j).Name] = true
+			}
+		}
+	}
+
+	var methods []structMethod
+	for i := 0; i < t.NumMethod(); i++ {
+		method := t.Method(i)
+		if promoted[method.Name] {
+			continue
+		}
+		// Without the receiver.
+		in := make([]reflect.Type, method.Type.NumIn()-1)
+		for j := range in {
+			in[j] = method.Type.In(j + 1)
+		}
+		out := make([]reflect.Type, method.Type.NumOut())
+		for j := range out {
+			out[j] = method.Type.Out(j)
+		}
+		if ft := reflect.FuncOf(in, out, method.Type.IsVariadic()); ft == runnerArgsType || ft == runnerType {
+			methods = append(methods, structMethod{index: i, name: method.Name, cmdName: kebabCase(method.Name)})
+		}
+	}
+
+	actual, _ := structMethodsCache.LoadOrStore(t, methods)
+	return actual.([]structMethod)
+}
+
+// structFlags defines the flags for the fields of the struct rv in the flags
+// of cmd.
+func structFlags(cmd *Command, rv reflect.Value) error {
+	fset, rt := cmd.Flags, rv.Type()
+	for _, sf := range structFields(rt) {
+		name, usage := sf.name, sf.usage
+		cmd.DeclareFlags(name)
+		p := rv.Field(sf.index).Addr().Interface()
+		switch p := p.(type) {
+		case *string:
+			fset.StringVar(p, name, *p, usage)
+		case *bool:
+			fset.BoolVar(p, name, *p, usage)
+		case *int:
+			fset.IntVar(p, name, *p, usage)
+		case *int64:
+			fset.Int64Var(p, name, *p, usage)
+		case *uint:
+			fset.UintVar(p, name, *p, usage)
+		case *uint64:
+			fset.Uint64Var(p, name, *p, usage)
+		case *float64:
+			fset.Float64Var(p, name, *p, usage)
+		case *time.Duration:
+			fset.DurationVar(p, name, *p, usage)
+		default:
+			ptr := reflect.TypeOf(p)
+			if !ptr.Implements(textUnmarshalerType) || !ptr.Implements(textMarshalerType) {
+				return fmt.Errorf("unsupported type %s of field %s", rt.Field(sf.index).Type, sf.field)
+			}
+			fset.TextVar(p.(encoding.TextUnmarshaler), name, rv.Field(sf.index).Interface().(encoding.TextMarshaler), usage)
+		}
+	}
+	return nil
+}
+
+// methodRunner returns a [RunnerFunc] that calls method, or nil if it doesn't
+// have one of the signatures described in [FromStruct].
+func methodRunner(method reflect.Value) RunnerFunc {
+	switch f := method.Interface().(type) {
+	case func(args []string) error:
+		return func(cmd *Command, args []string) error {
+			return f(args)
+		}
+	case func(cmd *Command, args []string) error:
+		return f
+	}
+	return nil
+}
+
+// kebabCase returns s in lower case with a "-" before the upper case letters
+// that start a word, like "dry-run" for "DryRun" and "http-addr" for
+// "HTTPAddr".
+func kebabCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			next := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && next) {
+				b.WriteByte('-')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
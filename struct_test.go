@@ -0,0 +1,197 @@
+package cmds
+
+import (
+	"context"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testServer struct {
+	HTTPAddr netip.AddrPort `usage:"address to listen on"`
+	Timeout  time.Duration
+	DryRun   bool
+	Name     string `flag:"n"`
+	Skipped  string `flag:"-"`
+
+	got []string
+}
+
+func (s *testServer) ListAll(cmd *Command, args []string) error {
+	s.got = append([]string{cmd.Name}, args...)
+	return nil
+}
+
+func (s *testServer) String() string {
+	return "ignored"
+}
+
+func (s *testServer) Describe(method string, cmd *Command) {
+	if method == "ListAll" {
+		cmd.ShortDesc = "list everything"
+	}
+}
+
+type testEcho struct {
+	got []string
+}
+
+func (e *testEcho) Run(args []string) error {
+	e.got = args
+	return nil
+}
+
+func TestFromStruct(t *testing.T) {
+	s := &testServer{Timeout: time.Second}
+	cmd, err := FromStruct(s)
+	expectErrorNone(t, err)
+	expectEq(t, cmd.Name, "test-server")
+	expectEq(t, len(cmd.Commands), 1)
+	expectEq(t, cmd.Commands[0].Name, "list-all")
+	expectEq(t, cmd.Commands[0].ShortDesc, "list everything")
+	expectEq(t, cmd.Flags.Lookup("timeout").DefValue, "1s")
+	expectEq(t, cmd.Flags.Lookup("http-addr").Usage, "address to listen on")
+	expectTrue(t, cmd.Flags.Lookup("skipped") == nil)
+
+	expectErrorNone(t, cmd.ParseRun([]string{"-http-addr", "127.0.0.1:80", "-dry-run", "-n", "x", "list-all", "a"}))
+	expectEq(t, s.HTTPAddr, netip.MustParseAddrPort("127.0.0.1:80"))
+	expectTrue(t, s.DryRun)
+	expectEq(t, s.Name, "x")
+	expectEq(t, s.got, []string{"list-all", "a"})
+
+	echo := &testEcho{}
+	cmd, err = FromStruct(echo)
+	expectErrorNone(t, err)
+	expectErrorNone(t, cmd.ParseRun([]string{"b"}))
+	expectEq(t, echo.got, []string{"b"})
+
+	_, err = FromStruct(struct{}{})
+	expectError(t, err)
+	_, err = FromStruct(&struct{ C chan int }{})
+	expectError(t, err)
+}
+
+func TestKebabCase(t *testing.T) {
+	for s, want := range map[string]string{
+		"Run":      "run",
+		"DryRun":   "dry-run",
+		"HTTPAddr": "http-addr",
+		"ListV2":   "list-v2",
+		"getURL":   "get-url",
+	} {
+		expectEq(t, kebabCase(s), want)
+	}
+}
+
+func TestStructCache(t *testing.T) {
+	_, err := FromStruct(&testServer{})
+	expectErrorNone(t, err)
+	fields, ok := structFieldsCache.Load(reflect.TypeOf(testServer{}))
+	expectTrue(t, ok)
+	expectEq(t, len(fields.([]structField)), 4)
+	methods, ok := structMethodsCache.Load(reflect.TypeOf(&testServer{}))
+	expectTrue(t, ok)
+	expectEq(t, methods.([]structMethod), []structMethod{{index: 1, name: "ListAll", cmdName: "list-all"}})
+
+	// Commands made from the same type don't share their flags.
+	a, b := &testServer{}, &testServer{}
+	cmdA, err := FromStruct(a)
+	expectErrorNone(t, err)
+	_, err = FromStruct(b)
+	expectErrorNone(t, err)
+	expectErrorNone(t, cmdA.ParseRun([]string{"-n", "a", "list-all"}))
+	expectEq(t, a.Name, "a")
+	expectEq(t, b.Name, "")
+}
+
+type testGreetFlags struct {
+	Greeting string `default:"hello" usage:"the greeting"`
+	Count    int    `flag:"n" default:"1"`
+	Addr     netip.Addr
+	Loud     bool
+}
+
+func TestTyped(t *testing.T) {
+	var got testGreetFlags
+	var gotArgs []string
+	cmd := Typed("greet", func(ctx context.Context, flags testGreetFlags, args []string) error {
+		got, gotArgs = flags, args
+		return nil
+	})
+	expectEq(t, cmd.Synopsis(), "greet [-addr value] [-greeting string] [-loud] [-n int]")
+
+	expectErrorNone(t, cmd.ParseRun([]string{"-n", "3", "-addr", "127.0.0.1", "-loud", "a"}))
+	expectEq(t, got, testGreetFlags{Greeting: "hello", Count: 3, Addr: netip.MustParseAddr("127.0.0.1"), Loud: true})
+	expectEq(t, gotArgs, []string{"a"})
+
+	// Every run gets a new value.
+	cmd.Reset()
+	expectErrorNone(t, cmd.ParseRun(nil))
+	expectEq(t, got, testGreetFlags{Greeting: "hello", Count: 1})
+
+	var gotPtr *testGreetFlags
+	ptrCmd := Typed("greet", func(ctx context.Context, flags *testGreetFlags, args []string) error {
+		gotPtr = flags
+		return nil
+	})
+	r, err := ptrCmd.ParseArgs([]string{"-greeting", "hi"})
+	expectErrorNone(t, err)
+	expectErrorNone(t, r.Run(context.Background()))
+	expectEq(t, gotPtr.Greeting, "hi")
+
+	defer func() {
+		expectTrue(t, recover() != nil)
+	}()
+	Typed("bad", func(ctx context.Context, flags struct {
+		N int `default:"x"`
+	}, args []string) error {
+		return nil
+	})
+}
+
+type testTool struct {
+	Verbose bool
+
+	Serve  `cmd:"serve,run the server"`
+	Config testToolConfig `cmd:""`
+}
+
+type Serve struct {
+	Addr string
+
+	got string
+}
+
+func (s *Serve) Run(args []string) error {
+	s.got = s.Addr
+	return nil
+}
+
+type testToolConfig struct {
+	got []string
+}
+
+func (c *testToolConfig) Get(args []string) error {
+	c.got = args
+	return nil
+}
+
+func TestFromStructTree(t *testing.T) {
+	tool := &testTool{}
+	cmd, err := FromStruct(tool)
+	expectErrorNone(t, err)
+	expectEq(t, cmd.Name, "test-tool")
+	// The Run method promoted from serve isn't the Runner of tool.
+	expectTrue(t, cmd.Runner == nil)
+	expectEq(t, len(cmd.Commands), 2)
+
+	serve := cmd.Find("serve")
+	expectEq(t, serve.ShortDesc, "run the server")
+	expectErrorNone(t, cmd.ParseRun([]string{"-verbose", "serve", "-addr", ":80"}))
+	expectTrue(t, tool.Verbose)
+	expectEq(t, tool.Serve.got, ":80")
+
+	expectErrorNone(t, cmd.ParseRun([]string{"config", "get", "a"}))
+	expectEq(t, tool.Config.got, []string{"a"})
+}
@@ -0,0 +1,107 @@
+package cmds
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// unknownCommandError returns the error for the unknown sub-command name of
+// cmd, with suggestions of similar commands.
+func (cmd *Command) unknownCommandError(name string) error {
+	m := cmd.messages()
+	msg := fmt.Sprintf(m.UnknownCommand, name)
+	suggestions := cmd.suggestions(name)
+	if len(suggestions) > 0 {
+		quoted := make([]string, len(suggestions))
+		for i, s := range suggestions {
+			quoted[i] = fmt.Sprintf("\"%s\"", s)
+		}
+		msg += fmt.Sprintf(m.Suggestions, strings.Join(quoted, m.SuggestionsSep))
+	}
+
+	return fmt.Errorf("%w: %w", ErrCmd, &CommandError{
+		Path:        cmd.Path(),
+		Arg:         name,
+		Err:         ErrUnknownCommand,
+		Suggestions: suggestions,
+		msg:         msg,
+	})
+}
+
+// maxSuggestDistance is the largest edit distance between an unknown command
+// and a sub-command for the sub-command to be suggested, it's less for names
+// shorter than 4 characters so that short names don't match everything.
+const maxSuggestDistance = 2
+
+// suggestions returns the names of the sub-commands of cmd that are close to
+// the unknown command name, closest first.
+// Sub-commands that start with name are suggested too, hidden and deprecated
+// ones aren't.
+func (cmd *Command) suggestions(name string) []string {
+	type suggestion struct {
+		name string
+		dist int
+	}
+
+	maxDist := maxSuggestDistance
+	if n := len([]rune(name)) / 2; n < maxDist {
+		maxDist = n
+	}
+
+	var found []suggestion
+	for _, sub := range (treeView{}).commands(cmd) {
+		dist := maxDist + 1
+		for _, n := range append([]string{sub.Name}, sub.Aliases...) {
+			if d := editDistance(strings.ToLower(name), strings.ToLower(n)); d < dist {
+				dist = d
+			}
+			if strings.HasPrefix(n, name) && dist > maxDist {
+				dist = maxDist
+			}
+		}
+		if dist <= maxDist {
+			found = append(found, suggestion{name: sub.Name, dist: dist})
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].dist < found[j].dist
+	})
+
+	var names []string
+	for _, s := range found {
+		names = append(names, s.name)
+	}
+	return names
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if d := prev[j] + 1; d < cur[j] {
+				cur[j] = d
+			}
+			if d := cur[j-1] + 1; d < cur[j] {
+				cur[j] = d
+			}
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(rb)]
+}
@@ -0,0 +1,29 @@
+package cmds
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSuggestions(t *testing.T) {
+	cmd := testCompletionTree()
+	cmd.Commands = append(cmd.Commands, &Command{Name: "server", Aliases: []string{"srv"}, Runner: nopRunner})
+
+	err := cmd.ParseRun([]string{"serv"})
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("expected *CommandError, got %#v", err)
+	}
+	expectEq(t, cmdErr.Suggestions, []string{"serve", "server"})
+	expectEq(t, cmdErr.Error(), "no such command \"serv\", did you mean \"serve\" or \"server\"?")
+
+	expectEq(t, cmd.suggestions("sr"), []string{"server"})
+	expectEq(t, cmd.suggestions("DB"), []string{"db"})
+	expectEq(t, cmd.suggestions("strat"), []string(nil))
+	expectEq(t, cmd.suggestions("internl"), []string(nil))
+	expectEq(t, cmd.suggestions("xyz"), []string(nil))
+
+	expectEq(t, editDistance("kitten", "sitting"), 3)
+	expectEq(t, editDistance("", "abc"), 3)
+	expectEq(t, editDistance("žē", "že"), 1)
+}
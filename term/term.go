@@ -0,0 +1,136 @@
+// Package term detects what the terminal that output goes to supports, used
+// by the usage messages of package cmds and available to Runners, so that
+// output adapts the same way between interactive and piped use.
+package term
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// fder is implemented by [os.File] and the other writers that are backed by a
+// file descriptor.
+type fder interface {
+	Fd() uintptr
+}
+
+// IsTTY reports whether f, usually an [os.File] like [os.Stdin], is a
+// terminal.
+func IsTTY(f any) bool {
+	fd, ok := f.(fder)
+	return ok && isTerminal(fd)
+}
+
+// Width returns the number of columns of the terminal w is, or 0 if w isn't a
+// terminal or it's size isn't known.
+// The COLUMNS environment variable overrides the size of the terminal, like
+// in most shells.
+func Width(w io.Writer) int {
+	if !IsTTY(w) {
+		return 0
+	}
+	if n := envSize("COLUMNS"); n > 0 {
+		return n
+	}
+	return width(w.(fder))
+}
+
+// Height returns the number of rows of the terminal w is, or 0 if w isn't a
+// terminal or it's size isn't known.
+// The LINES environment variable overrides the size of the terminal, like
+// COLUMNS does for [Width].
+func Height(w io.Writer) int {
+	if !IsTTY(w) {
+		return 0
+	}
+	if n := envSize("LINES"); n > 0 {
+		return n
+	}
+	return height(w.(fder))
+}
+
+// envSize returns the value of the environment variable name, or 0 if it
+// isn't a positive number.
+func envSize(name string) int {
+	n, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// Color reports whether colors should be output to w.
+// Following https://no-color.org and https://bixense.com/clicolors, a
+// non-empty NO_COLOR disables them, a CLICOLOR_FORCE other than "0" enables
+// them even when w isn't a terminal and a CLICOLOR of "0" disables them.
+// Otherwise colors are output to terminals, unless TERM is "dumb".
+func Color(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := os.Getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return true
+	}
+	if os.Getenv("CLICOLOR") == "0" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return IsTTY(w)
+}
+
+// ReadLine reads a line from r without the line ending, a byte at a time so
+// that nothing after it is read.
+func ReadLine(r io.Reader) (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := r.Read(b)
+		if n > 0 {
+			if b[0] == '\n' {
+				break
+			}
+			line = append(line, b[0])
+		}
+		if err == io.EOF && len(line) > 0 {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimSuffix(string(line), "\r"), nil
+}
+
+// ReadPassword reads a line from r like [ReadLine], without echoing it if r is
+// a terminal.
+func ReadPassword(r io.Reader) (string, error) {
+	f, ok := r.(fder)
+	if !ok || !isTerminal(f) {
+		return ReadLine(r)
+	}
+	restore, err := noEcho(f)
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+	return ReadLine(r)
+}
+
+// Emoji reports whether emoji can be output, for status icons and the like
+// that have a plain fallback.
+// A non-empty NO_EMOJI disables them, like NO_COLOR does colors, and so do a
+// TERM of "dumb" and a locale that isn't UTF-8, from the first of LC_ALL,
+// LC_CTYPE and LANG that's set.
+func Emoji() bool {
+	if os.Getenv("NO_EMOJI") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if locale := os.Getenv(name); locale != "" {
+			locale = strings.ToLower(locale)
+			return strings.Contains(locale, "utf-8") || strings.Contains(locale, "utf8")
+		}
+	}
+	return true
+}
@@ -0,0 +1,32 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package term
+
+import "os"
+
+// isTerminal reports whether f is a character device, which is the best that
+// can be done without the terminal APIs of the platform.
+func isTerminal(f fder) bool {
+	s, ok := f.(interface{ Stat() (os.FileInfo, error) })
+	if !ok {
+		return false
+	}
+	fi, err := s.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// width and height return 0 since the size of the terminal isn't known,
+// COLUMNS and LINES can be used to set it.
+func width(f fder) int {
+	return 0
+}
+
+func height(f fder) int {
+	return 0
+}
+
+// noEcho doesn't do anything, since echoing the input can't be turned off
+// without the terminal APIs of the platform.
+func noEcho(f fder) (restore func(), err error) {
+	return func() {}, nil
+}
@@ -0,0 +1,92 @@
+package term
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestColor(t *testing.T) {
+	for _, env := range []string{"NO_COLOR", "CLICOLOR", "CLICOLOR_FORCE", "TERM"} {
+		t.Setenv(env, "")
+	}
+	var b bytes.Buffer
+	if Color(&b) {
+		t.Error("Color is true for a buffer")
+	}
+
+	t.Setenv("CLICOLOR_FORCE", "1")
+	if !Color(&b) {
+		t.Error("Color is false with CLICOLOR_FORCE")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if Color(&b) {
+		t.Error("Color is true with NO_COLOR")
+	}
+}
+
+func TestWidth(t *testing.T) {
+	t.Setenv("COLUMNS", "100")
+	if n := envSize("COLUMNS"); n != 100 {
+		t.Errorf("envSize returns %d, expected 100", n)
+	}
+	t.Setenv("COLUMNS", "x")
+	if n := envSize("COLUMNS"); n != 0 {
+		t.Errorf("envSize returns %d, expected 0", n)
+	}
+
+	// Not terminals.
+	f, err := os.CreateTemp(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for _, w := range []io.Writer{f, &bytes.Buffer{}} {
+		if IsTTY(w) || Width(w) != 0 || Height(w) != 0 {
+			t.Errorf("%T is a terminal", w)
+		}
+	}
+}
+
+func TestReadLine(t *testing.T) {
+	r := strings.NewReader("first\r\nsecond\nlast")
+	for _, want := range []string{"first", "second", "last"} {
+		line, err := ReadLine(r)
+		if err != nil || line != want {
+			t.Errorf("ReadLine returns %q, %v, expected %q", line, err, want)
+		}
+	}
+	if _, err := ReadLine(r); err != io.EOF {
+		t.Errorf("ReadLine returns %v at the end, expected EOF", err)
+	}
+
+	// Not a terminal, so it's the same as ReadLine.
+	line, err := ReadPassword(strings.NewReader("secret\n"))
+	if err != nil || line != "secret" {
+		t.Errorf("ReadPassword returns %q, %v", line, err)
+	}
+}
+
+func TestEmoji(t *testing.T) {
+	for _, env := range []string{"NO_EMOJI", "TERM", "LC_ALL", "LC_CTYPE", "LANG"} {
+		t.Setenv(env, "")
+	}
+	if !Emoji() {
+		t.Error("Emoji is false without a locale")
+	}
+	t.Setenv("LANG", "en_US.UTF-8")
+	if !Emoji() {
+		t.Error("Emoji is false for a UTF-8 locale")
+	}
+	t.Setenv("LC_ALL", "C")
+	if Emoji() {
+		t.Error("Emoji is true for the C locale")
+	}
+	t.Setenv("LC_ALL", "")
+	t.Setenv("NO_EMOJI", "1")
+	if Emoji() {
+		t.Error("Emoji is true with NO_EMOJI")
+	}
+}
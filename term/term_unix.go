@@ -0,0 +1,59 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package term
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+type winsize struct {
+	rows, cols, xpixel, ypixel uint16
+}
+
+func ioctl(fd, req uintptr, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func getWinsize(fd uintptr) (winsize, bool) {
+	var ws winsize
+	err := ioctl(fd, uintptr(syscall.TIOCGWINSZ), unsafe.Pointer(&ws))
+	return ws, err == nil
+}
+
+func isTerminal(f fder) bool {
+	_, ok := getWinsize(f.Fd())
+	return ok
+}
+
+func width(f fder) int {
+	ws, _ := getWinsize(f.Fd())
+	return int(ws.cols)
+}
+
+func height(f fder) int {
+	ws, _ := getWinsize(f.Fd())
+	return int(ws.rows)
+}
+
+// noEcho turns off echoing the input of the terminal f and returns a function
+// that turns it back on.
+func noEcho(f fder) (restore func(), err error) {
+	var old syscall.Termios
+	if err := ioctl(f.Fd(), ioctlGetTermios, unsafe.Pointer(&old)); err != nil {
+		return nil, err
+	}
+	t := old
+	t.Lflag &^= syscall.ECHO
+	t.Lflag |= syscall.ICANON | syscall.ISIG
+	if err := ioctl(f.Fd(), ioctlSetTermios, unsafe.Pointer(&t)); err != nil {
+		return nil, err
+	}
+	return func() {
+		ioctl(f.Fd(), ioctlSetTermios, unsafe.Pointer(&old))
+	}, nil
+}
@@ -0,0 +1,65 @@
+package cmds
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/rgzlv/cmds/ui"
+)
+
+// CommandsCommand returns a "commands" command to add to the Commands of
+// another command, that outputs the tree of sub-commands of the command it was
+// added to with their ShortDesc, indented by their depth, for debugging large
+// trees or the ones that plugins add to.
+// With the -flat flag every command is output with it's full path instead and
+// with -hidden the hidden commands are included, the help topics aren't.
+// The commands with a LoadFunc are loaded to output their sub-commands.
+func CommandsCommand() *Command {
+	fset := flag.NewFlagSet("commands", flag.ContinueOnError)
+	fset.Bool("flat", false, "output the full path of every command")
+	fset.Bool("hidden", false, "include hidden commands")
+
+	return &Command{
+		Name:      "commands",
+		ShortDesc: "list all commands",
+		Flags:     fset,
+		Args:      ExactArgs(0),
+		Runner: func(cmd *Command, args []string) error {
+			if cmd.parent == nil {
+				return fmt.Errorf("%w: %w", ErrCmd, fmt.Errorf(cmd.messages().NoParent, cmd.Name))
+			}
+			flat := cmd.Flags.Lookup("flat").Value.String() == "true"
+			hidden := cmd.Flags.Lookup("hidden").Value.String() == "true"
+
+			// The path is the full one for -flat and the one from the parent
+			// for the indentation otherwise.
+			var path []string
+			if flat {
+				path = cmd.parent.Path()
+			}
+			t := &ui.Table{Gap: 3}
+			var add func(c *Command, path []string)
+			add = func(c *Command, path []string) {
+				c.nameCommands()
+				for _, sub := range c.Commands {
+					if sub.Name == "" || (sub.Hidden && !hidden) || sub.IsTopic() {
+						continue
+					}
+					sub, _ := sub.loaded()
+					subPath := append(path[:len(path):len(path)], sub.Name)
+					if flat {
+						t.Add(strings.Join(subPath, " "), sub.Short())
+					} else {
+						t.Add(strings.Repeat("  ", len(subPath)-1)+sub.Name, sub.Short())
+					}
+					add(sub, subPath)
+				}
+			}
+			add(cmd.parent, path)
+
+			_, err := t.WriteTo(cmd.Output())
+			return err
+		},
+	}
+}
@@ -0,0 +1,53 @@
+package cmds
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCommandsCommand(t *testing.T) {
+	cmd := &Command{
+		Name: "tool",
+		Commands: []*Command{
+			{
+				Name:      "db",
+				ShortDesc: "manage the database",
+				Commands: []*Command{
+					{Name: "migrate", ShortDesc: "run migrations", Runner: nopRunner},
+					{Name: "secret", Hidden: true, Runner: nopRunner},
+				},
+			},
+			{Name: "topic", LongDesc: "About things."},
+			CommandsCommand(),
+		},
+	}
+
+	var out strings.Builder
+	cmd.Stdout = &out
+	expectErrorNone(t, cmd.ParseRun([]string{"commands"}))
+	expectEq(t, out.String(), `db          manage the database
+  migrate   run migrations
+commands    list all commands
+`)
+
+	cmd.Reset()
+	out.Reset()
+	expectErrorNone(t, cmd.ParseRun([]string{"commands", "-flat", "-hidden"}))
+	expectEq(t, out.String(), `tool db           manage the database
+tool db migrate   run migrations
+tool db secret
+tool commands     list all commands
+`)
+
+	// The flags of the copies of ParseArgs are used too.
+	cmd.Reset()
+	out.Reset()
+	r, err := cmd.ParseArgs([]string{"commands", "-flat"})
+	expectErrorNone(t, err)
+	expectErrorNone(t, r.Run(context.Background()))
+	expectEq(t, out.String(), `tool db           manage the database
+tool db migrate   run migrations
+tool commands     list all commands
+`)
+}
@@ -0,0 +1,157 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rgzlv/cmds/term"
+)
+
+// barWidth is the number of columns of the bar of [Progress] between the
+// brackets.
+const barWidth = 30
+
+// Progress reports the progress of a task with a known number of steps.
+// On terminals it's a bar that's redrawn in place, otherwise it degrades to
+// a line for every tenth of the task, so that logs of piped output stay
+// readable.
+// It's safe to use from multiple goroutines.
+type Progress struct {
+	// Quiet makes the progress not be output at all.
+	Quiet bool
+
+	w     io.Writer
+	label string
+	total int
+	tty   bool
+
+	mu   sync.Mutex
+	n    int
+	last int
+}
+
+// NewProgress returns a Progress for a task of total steps that outputs to w
+// with label before the progress, nothing is output until the first update.
+func NewProgress(w io.Writer, label string, total int) *Progress {
+	return &Progress{w: w, label: label, total: total, tty: term.IsTTY(w), last: -1}
+}
+
+// Add adds n steps to the done steps of the task.
+func (p *Progress) Add(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.set(p.n + n)
+}
+
+// Set sets the number of done steps of the task to n.
+func (p *Progress) Set(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.set(n)
+}
+
+// Done marks the task as done, which ends the line of the bar on terminals.
+func (p *Progress) Done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.set(p.total)
+	if p.tty && !p.Quiet {
+		io.WriteString(p.w, "\n")
+	}
+}
+
+func (p *Progress) set(n int) {
+	p.n = min(max(n, 0), p.total)
+	if p.Quiet {
+		return
+	}
+
+	percent := 100
+	if p.total > 0 {
+		percent = p.n * 100 / p.total
+	}
+	if p.tty {
+		filled := barWidth * percent / 100
+		bar := strings.Repeat("=", filled) + strings.Repeat(" ", barWidth-filled)
+		fmt.Fprintf(p.w, "\r\x1b[K%s [%s] %d/%d %3d%%", p.label, bar, p.n, p.total, percent)
+		return
+	}
+	if percent/10 > p.last/10 || p.last < 0 {
+		fmt.Fprintf(p.w, "%s: %d/%d %d%%\n", p.label, p.n, p.total, percent)
+		p.last = percent
+	}
+}
+
+// spinnerFrames are the frames of [Spinner] on terminals.
+var spinnerFrames = []string{"|", "/", "-", `\`}
+
+// spinnerInterval is the time between the frames of [Spinner].
+var spinnerInterval = 100 * time.Millisecond
+
+// Spinner reports that a task with an unknown number of steps is running.
+// On terminals it's a spinner after the label that's redrawn in place until
+// it's stopped, otherwise it degrades to a line when it's started and one when
+// it's stopped.
+type Spinner struct {
+	// Quiet makes the spinner not be output at all.
+	Quiet bool
+
+	w     io.Writer
+	label string
+	tty   bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewSpinner returns a Spinner that outputs to w with label before the
+// spinner.
+func NewSpinner(w io.Writer, label string) *Spinner {
+	return &Spinner{w: w, label: label, tty: term.IsTTY(w)}
+}
+
+// Start starts the spinner, it must be stopped with [Spinner.Stop] before it's
+// started again.
+func (s *Spinner) Start() {
+	if s.Quiet {
+		return
+	}
+	if !s.tty {
+		fmt.Fprintf(s.w, "%s...\n", s.label)
+		return
+	}
+
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(spinnerInterval)
+		defer ticker.Stop()
+		for i := 0; ; i++ {
+			fmt.Fprintf(s.w, "\r\x1b[K%s %s", s.label, spinnerFrames[i%len(spinnerFrames)])
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops the spinner and outputs the label followed by result, like
+// "done" or "failed".
+func (s *Spinner) Stop(result string) {
+	if s.Quiet {
+		return
+	}
+	if s.stop != nil {
+		close(s.stop)
+		<-s.done
+		s.stop, s.done = nil, nil
+		io.WriteString(s.w, "\r\x1b[K")
+	}
+	fmt.Fprintf(s.w, "%s: %s\n", s.label, result)
+}
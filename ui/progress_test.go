@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	var b strings.Builder
+	p := NewProgress(&b, "copying", 20)
+	for i := 0; i < 20; i++ {
+		p.Add(1)
+	}
+	p.Done()
+	expectEq(t, strings.Count(b.String(), "\n"), 11)
+	expectEq(t, strings.HasPrefix(b.String(), "copying: 1/20 5%\ncopying: 2/20 10%\n"), true)
+	expectEq(t, strings.HasSuffix(b.String(), "copying: 20/20 100%\n"), true)
+
+	b.Reset()
+	p = NewProgress(&b, "copying", 4)
+	p.tty = true
+	p.Set(1)
+	p.Done()
+	expectEq(t, b.String(), "\r\x1b[Kcopying [=======                       ] 1/4  25%"+
+		"\r\x1b[Kcopying [==============================] 4/4 100%\n")
+
+	b.Reset()
+	p = NewProgress(&b, "copying", 4)
+	p.Quiet = true
+	p.Add(2)
+	p.Done()
+	expectEq(t, b.String(), "")
+}
+
+func TestSpinner(t *testing.T) {
+	var b strings.Builder
+	s := NewSpinner(&b, "waiting")
+	s.Start()
+	s.Stop("done")
+	expectEq(t, b.String(), "waiting...\nwaiting: done\n")
+
+	defer func(interval time.Duration) { spinnerInterval = interval }(spinnerInterval)
+	spinnerInterval = time.Millisecond
+	b.Reset()
+	s.tty = true
+	s.Start()
+	time.Sleep(10 * time.Millisecond)
+	s.Stop("done")
+	expectEq(t, strings.HasPrefix(b.String(), "\r\x1b[Kwaiting |\r\x1b[Kwaiting /"), true)
+	expectEq(t, strings.HasSuffix(b.String(), "\r\x1b[Kwaiting: done\n"), true)
+
+	b.Reset()
+	s.Quiet = true
+	s.Start()
+	s.Stop("done")
+	expectEq(t, b.String(), "")
+}
@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/rgzlv/cmds/term"
+)
+
+// Status reports the results of the steps of a command on lines prefixed
+// with an icon for whether the step succeeded, succeeded with a warning or
+// failed, so that commands report them consistently.
+type Status struct {
+	// ASCII makes the prefixes "[ok]", "[warn]" and "[fail]" instead of
+	// emoji.
+	ASCII bool
+
+	// Quiet makes OK not output anything, warnings and failures are still
+	// output.
+	Quiet bool
+
+	w io.Writer
+}
+
+// NewStatus returns a Status that outputs to w, with ASCII set unless
+// [term.Emoji].
+func NewStatus(w io.Writer) *Status {
+	return &Status{w: w, ASCII: !term.Emoji()}
+}
+
+// OK reports a step that succeeded.
+func (s *Status) OK(format string, args ...any) {
+	if s.Quiet {
+		return
+	}
+	s.print("✅", "[ok]", format, args)
+}
+
+// Warn reports a step that succeeded with a warning.
+func (s *Status) Warn(format string, args ...any) {
+	s.print("⚠️", "[warn]", format, args)
+}
+
+// Fail reports a step that failed.
+func (s *Status) Fail(format string, args ...any) {
+	s.print("❌", "[fail]", format, args)
+}
+
+func (s *Status) print(icon, ascii, format string, args []any) {
+	if s.ASCII {
+		icon = ascii
+	}
+	fmt.Fprintf(s.w, "%s %s\n", icon, fmt.Sprintf(format, args...))
+}
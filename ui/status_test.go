@@ -0,0 +1,23 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	var b strings.Builder
+	s := &Status{w: &b}
+	s.OK("built %s", "tool")
+	s.Warn("no tests")
+	s.Fail("lint")
+	expectEq(t, b.String(), "✅ built tool\n⚠️ no tests\n❌ lint\n")
+
+	b.Reset()
+	s.ASCII = true
+	s.Quiet = true
+	s.OK("built")
+	s.Warn("no tests")
+	s.Fail("lint")
+	expectEq(t, b.String(), "[warn] no tests\n[fail] lint\n")
+}
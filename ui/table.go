@@ -0,0 +1,169 @@
+// Package ui contains helpers for laying out command line output, used by the
+// usage messages and documentation of package cmds and available to Runners.
+package ui
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Table lays out rows of two columns so that the second column of every row
+// starts at the same offset, like the lists of commands and flags in usage
+// messages.
+type Table struct {
+	// Indent is the number of spaces before the first column.
+	Indent int
+
+	// Gap is the number of spaces between the widest first column and the
+	// second column.
+	Gap int
+
+	// MinLeft is the minimum width of the first column, for aligning the
+	// second column with other tables.
+	MinLeft int
+
+	// Width is the width to wrap the second column at, wrapped lines are
+	// indented to the start of the second column.
+	// If it's 0 the second column isn't wrapped.
+	Width int
+
+	rows [][2]string
+}
+
+// Add adds a row to the table.
+func (t *Table) Add(left, right string) {
+	t.rows = append(t.rows, [2]string{left, right})
+}
+
+// Len returns the number of rows in the table.
+func (t *Table) Len() int {
+	return len(t.rows)
+}
+
+// LeftWidth returns the width of the first column, which is the width of the
+// widest first column of any row or MinLeft if that's bigger.
+func (t *Table) LeftWidth() int {
+	longest := t.MinLeft
+	for _, row := range t.rows {
+		if l := Width(row[0]); l > longest {
+			longest = l
+		}
+	}
+	return longest
+}
+
+// String returns the laid out table.
+func (t *Table) String() string {
+	var b strings.Builder
+	left := t.LeftWidth()
+	indent := strings.Repeat(" ", t.Indent)
+	cont := strings.Repeat(" ", t.Indent+left+t.Gap)
+
+	for _, row := range t.rows {
+		b.WriteString(indent)
+		b.WriteString(row[0])
+		if row[1] == "" {
+			b.WriteByte('\n')
+			continue
+		}
+		b.WriteString(strings.Repeat(" ", left-Width(row[0])+t.Gap))
+
+		var lines []string
+		if t.Width > 0 {
+			lines = Wrap(row[1], t.Width-t.Indent-left-t.Gap)
+		} else {
+			lines = strings.Split(row[1], "\n")
+		}
+		for i, line := range lines {
+			if i > 0 {
+				b.WriteString(cont)
+			}
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+	}
+
+	return b.String()
+}
+
+// WriteTo writes the laid out table to w.
+func (t *Table) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, t.String())
+	return int64(n), err
+}
+
+// Wrap splits s into lines at spaces so that the lines are at most width wide
+// if possible, words longer than width are put on their own line.
+// Existing line breaks in s are preserved.
+func Wrap(s string, width int) []string {
+	if width < 1 {
+		width = 1
+	}
+
+	var lines []string
+	for _, para := range strings.Split(s, "\n") {
+		var line string
+		for _, word := range strings.Fields(para) {
+			if line != "" && Width(line)+1+Width(word) > width {
+				lines = append(lines, line)
+				line = ""
+			}
+			if line != "" {
+				line += " "
+			}
+			line += word
+		}
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// Width returns the number of columns s takes up when output to a terminal,
+// ignoring escape sequences like colors and OSC 8 hyperlinks, see
+// [RuneWidth].
+func Width(s string) int {
+	var n int
+	for i := 0; i < len(s); {
+		if s[i] == '\x1b' {
+			i += escapeLen(s[i:])
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		n += RuneWidth(r)
+	}
+	return n
+}
+
+// escapeLen returns the length of the escape sequence at the start of s.
+func escapeLen(s string) int {
+	if len(s) < 2 {
+		return len(s)
+	}
+
+	switch s[1] {
+	case '[':
+		// CSI, ends with a byte in the range 0x40-0x7e.
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+		}
+	case ']':
+		// OSC, ends with BEL or ST.
+		for i := 2; i < len(s); i++ {
+			if s[i] == '\a' {
+				return i + 1
+			}
+			if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+	default:
+		return 2
+	}
+
+	return len(s)
+}
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTable(t *testing.T) {
+	tbl := &Table{Indent: 2, Gap: 2}
+	tbl.Add("a", "first")
+	tbl.Add("bcd", "second")
+	tbl.Add("e", "")
+	expectEq(t, tbl.String(), "  a    first\n  bcd  second\n  e\n")
+
+	tbl.MinLeft = 5
+	expectEq(t, tbl.LeftWidth(), 5)
+	expectEq(t, tbl.String(), "  a      first\n  bcd    second\n  e\n")
+}
+
+func TestTableWrap(t *testing.T) {
+	tbl := &Table{Gap: 1, Width: 12}
+	tbl.Add("ab", "one two three four")
+	expectEq(t, tbl.String(), "ab one two\n   three\n   four\n")
+}
+
+func TestWrap(t *testing.T) {
+	expectEq(t, Wrap("one two three", 7), []string{"one two", "three"})
+	expectEq(t, Wrap("abcdefgh ij", 4), []string{"abcdefgh", "ij"})
+	expectEq(t, Wrap("a\nb c", 10), []string{"a", "b c"})
+}
+
+func TestWidth(t *testing.T) {
+	expectEq(t, Width("abc"), 3)
+	expectEq(t, Width("žāē"), 3)
+	expectEq(t, Width("日本語"), 6)
+	expectEq(t, Width("e\u0301"), 1)
+	expectEq(t, Width("👍"), 2)
+	expectEq(t, Width("한국어"), 6)
+	expectEq(t, Width("\x1b[31mred\x1b[0m"), 3)
+	expectEq(t, Width("\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\"), 4)
+}
+
+func expectEq(t *testing.T, a, b any) {
+	t.Helper()
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("expected equal values, got %#+v != %#+v", a, b)
+	}
+}
@@ -0,0 +1,32 @@
+package ui
+
+import "testing"
+
+func TestRuneWidth(t *testing.T) {
+	for r, want := range map[rune]int{
+		'a':          1,
+		'ž':          1,
+		'\u0301':     0,
+		'\u200d':     0,
+		'\ufe0f':     0,
+		'日':          2,
+		'ア':          2,
+		'ｱ':          1,
+		'🚀':          2,
+		'\U0002a6d6': 2,
+	} {
+		expectEq(t, RuneWidth(r), want)
+	}
+}
+
+func TestPad(t *testing.T) {
+	expectEq(t, Pad("ab", 4), "ab  ")
+	expectEq(t, Pad("日本", 5), "日本 ")
+	expectEq(t, Pad("abc", 2), "abc")
+
+	// Wide first columns are aligned by their width.
+	tbl := &Table{Gap: 1}
+	tbl.Add("日本", "a")
+	tbl.Add("abc", "b")
+	expectEq(t, tbl.String(), "日本 a\nabc  b\n")
+}
@@ -0,0 +1,141 @@
+package cmds
+
+import (
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// UnmarshalConfig sets the fields of the struct that v points to from the
+// values of the flags of cmd and it's parents, which are the values resolved
+// from the command line, environment and Config as described in [Source], so
+// that the Runner can use one typed value for it's options.
+// A field is set from the flag named in it's "flag" tag, like
+// `flag:"dry-run"`, the other fields are left as they are, the flags of cmd
+// take precedence over the ones of it's parents with the same name.
+// The value is the one returned by [flag.Getter] if it can be assigned to the
+// field, otherwise the string value of the flag is parsed according to the
+// type of the field, which can be a string, bool, integer, float,
+// [time.Duration] or implement [encoding.TextUnmarshaler].
+func (cmd *Command) UnmarshalConfig(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("UnmarshalConfig needs a pointer to a struct")
+	}
+	rv = rv.Elem()
+
+	for _, sf := range configFields(rv.Type()) {
+		f := cmd.inheritedFlag(sf.name)
+		if f == nil {
+			return fmt.Errorf("no flag -%s for field %s", sf.name, sf.field)
+		}
+		if err := setField(rv.Field(sf.index), f); err != nil {
+			return fmt.Errorf("field %s from flag -%s: %w", sf.field, sf.name, err)
+		}
+	}
+	return nil
+}
+
+// configFieldsCache caches the fields of the types given to UnmarshalConfig,
+// like structFieldsCache.
+var configFieldsCache sync.Map // map[reflect.Type][]structField
+
+// configFields returns the fields of the struct type t that have a "flag"
+// tag.
+func configFields(t reflect.Type) []structField {
+	if fields, ok := configFieldsCache.Load(t); ok {
+		return fields.([]structField)
+	}
+
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := field.Tag.Lookup("flag")
+		if !ok || name == "-" || !field.IsExported() {
+			continue
+		}
+		fields = append(fields, structField{index: i, field: field.Name, name: name})
+	}
+
+	actual, _ := configFieldsCache.LoadOrStore(t, fields)
+	return actual.([]structField)
+}
+
+// inheritedFlag returns the flag name of cmd or the closest of it's parents that
+// has it.
+func (cmd *Command) inheritedFlag(name string) *flag.Flag {
+	for c := cmd; c != nil; c = c.parent {
+		if c.Flags == nil {
+			continue
+		}
+		if f := c.Flags.Lookup(name); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func setField(field reflect.Value, f *flag.Flag) error {
+	if getter, ok := f.Value.(flag.Getter); ok {
+		if value := reflect.ValueOf(getter.Get()); value.IsValid() && value.Type().AssignableTo(field.Type()) {
+			field.Set(value)
+			return nil
+		}
+	}
+
+	return setFieldString(field, f.Value.String())
+}
+
+// setFieldString sets field to the value of s parsed according to it's type.
+func setFieldString(field reflect.Value, s string) error {
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 0, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 0, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package cmds
+
+import (
+	"flag"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestUnmarshalConfig(t *testing.T) {
+	t.Setenv("TEST_TIMEOUT", "3s")
+
+	type options struct {
+		Verbose bool          `flag:"v"`
+		Method  string        `flag:"m"`
+		N       int32         `flag:"n"`
+		Ratio   float32       `flag:"ratio"`
+		Timeout time.Duration `flag:"timeout"`
+		Addr    netip.Addr    `flag:"addr"`
+		Port    uint16        `flag:"port"`
+		Ignored string        `flag:"-"`
+		Other   string
+	}
+
+	var got options
+	var unmarshalErr error
+	cmd := &Command{
+		Name:      "test",
+		EnvPrefix: "test",
+		Config:    testConfig{"test.req.m": "HEAD"},
+		Flags: func() *flag.FlagSet {
+			fset := flag.NewFlagSet("test", flag.ContinueOnError)
+			fset.Bool("v", false, "")
+			fset.String("m", "root", "")
+			fset.Duration("timeout", time.Second, "")
+			fset.String("addr", "127.0.0.1", "")
+			return fset
+		}(),
+		Commands: []*Command{
+			{
+				Name: "req",
+				Flags: func() *flag.FlagSet {
+					fset := flag.NewFlagSet("req", flag.ContinueOnError)
+					fset.String("m", "GET", "")
+					fset.Int("n", 1, "")
+					fset.Float64("ratio", 0.5, "")
+					fset.String("port", "80", "")
+					return fset
+				}(),
+				Runner: func(cmd *Command, args []string) error {
+					unmarshalErr = cmd.UnmarshalConfig(&got)
+					return nil
+				},
+			},
+		},
+	}
+
+	expectErrorNone(t, cmd.ParseRun([]string{"-v", "req", "-n", "7", "-port", "8080"}))
+	expectErrorNone(t, unmarshalErr)
+	expectEq(t, got, options{
+		Verbose: true,
+		Method:  "HEAD",
+		N:       7,
+		Ratio:   0.5,
+		Timeout: 3 * time.Second,
+		Addr:    netip.MustParseAddr("127.0.0.1"),
+		Port:    8080,
+	})
+
+	expectError(t, cmd.Commands[0].UnmarshalConfig(got))
+	expectError(t, cmd.Commands[0].UnmarshalConfig(&struct {
+		Missing string `flag:"missing"`
+	}{}))
+	expectError(t, cmd.Commands[0].UnmarshalConfig(&struct {
+		M []string `flag:"m"`
+	}{}))
+}
@@ -0,0 +1,713 @@
+package cmds
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rgzlv/cmds/term"
+	"github.com/rgzlv/cmds/ui"
+)
+
+// Example is an example invocation of a command shown in the "Examples:"
+// section of [Command.DefaultUsage].
+type Example struct {
+	// Comment optionally describes what the example does, it's output on
+	// the line before the example.
+	Comment string `json:"comment,omitempty"`
+
+	// Command is the example command line, it may span multiple lines.
+	Command string `json:"command"`
+}
+
+// FlagGroup is a group of flags that [Command.DefaultUsage] lists together in
+// the given order, under a heading with the group's name.
+// The flags of groups without a name are listed first under the "Flags:"
+// heading, followed by the remaining flags that aren't in any group in the
+// FlagOrder of the command.
+type FlagGroup struct {
+	Name  string
+	Flags []string
+}
+
+// FlagOrder is the order that the usage message, the synopsis and completion
+// list the flags of a command in, except for the ones in it's FlagGroups.
+type FlagOrder int
+
+const (
+	// SortedFlags lists the flags in lexical order, like
+	// [flag.FlagSet.VisitAll].
+	SortedFlags FlagOrder = iota
+
+	// DeclaredFlags lists the flags in the order they were declared in, so
+	// that related flags like -user and -password are next to each other.
+	// A FlagSet doesn't record the order, so it's only known for the flags
+	// declared by [FromStruct], manifests, [ExecCommand] and
+	// [Command.DeclareFlags], the other flags follow them in lexical order.
+	DeclaredFlags
+)
+
+// DeclareFlags records names as the next flags of cmd in the order they were
+// declared in, for [DeclaredFlags].
+// It's for flags declared in the FlagSet directly, which doesn't record it.
+func (cmd *Command) DeclareFlags(names ...string) {
+	cmd.declared = append(cmd.declared, names...)
+}
+
+// visitFlags calls fn for each of the flags of cmd in it's FlagOrder.
+func (cmd *Command) visitFlags(fn func(*flag.Flag)) {
+	if cmd.Flags == nil {
+		return
+	}
+	if cmd.FlagOrder != DeclaredFlags {
+		cmd.Flags.VisitAll(fn)
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, name := range cmd.declared {
+		if f := cmd.Flags.Lookup(name); f != nil && !seen[name] {
+			seen[name] = true
+			fn(f)
+		}
+	}
+	cmd.Flags.VisitAll(func(f *flag.Flag) {
+		if !seen[f.Name] {
+			fn(f)
+		}
+	})
+}
+
+// UsageTextFunc returns text that [Command.DefaultUsage] outputs around the
+// generated usage message of cmd.
+type UsageTextFunc func(cmd *Command) string
+
+// UsageText returns a [UsageTextFunc] that always returns s.
+func UsageText(s string) UsageTextFunc {
+	return func(*Command) string {
+		return s
+	}
+}
+
+// Usage is the information that [Command.DefaultUsage] outputs, returned by
+// [Command.Usage] for tools that want to present it in some other way.
+type Usage struct {
+	Header     string           `json:"header,omitempty"`
+	Name       string           `json:"name"`
+	Synopsis   string           `json:"synopsis,omitempty"`
+	ShortDesc  string           `json:"shortDesc,omitempty"`
+	LongDesc   string           `json:"longDesc,omitempty"`
+	DocsURL    string           `json:"docsURL,omitempty"`
+	Commands   []UsageCommand   `json:"commands,omitempty"`
+	Topics     []UsageCommand   `json:"topics,omitempty"`
+	Aliases    []UsageAlias     `json:"aliases,omitempty"`
+	FlagGroups []UsageFlagGroup `json:"flagGroups,omitempty"`
+	Examples   []Example        `json:"examples,omitempty"`
+	Footer     string           `json:"footer,omitempty"`
+
+	// Hyperlinks makes DocsURL be output as OSC 8 terminal hyperlinks.
+	Hyperlinks bool `json:"-"`
+
+	// Width is the width to wrap the descriptions of commands and flags at,
+	// if it's 0 they aren't wrapped.
+	Width int `json:"-"`
+
+	// Messages are the headings and other texts of the usage message, if
+	// it's nil [DefaultMessages] are used.
+	Messages *Messages `json:"-"`
+}
+
+// UsageCommand is a sub-command or help topic in [Usage].
+type UsageCommand struct {
+	Name      string   `json:"name"`
+	Aliases   []string `json:"aliases,omitempty"`
+	ShortDesc string   `json:"shortDesc,omitempty"`
+	DocsURL   string   `json:"docsURL,omitempty"`
+}
+
+// UsageAlias is one of the UserAliases of a command in [Usage].
+type UsageAlias struct {
+	Name string   `json:"name"`
+	Args []string `json:"args"`
+}
+
+// UsageFlagGroup is a group of flags in [Usage], the group without a name
+// contains the flags listed under the "Flags:" heading.
+type UsageFlagGroup struct {
+	Name  string      `json:"name,omitempty"`
+	Flags []UsageFlag `json:"flags"`
+}
+
+// UsageFlag is a flag in [Usage].
+type UsageFlag struct {
+	Name    string `json:"name"`
+	Usage   string `json:"usage,omitempty"`
+	Default string `json:"default"`
+}
+
+// Usage returns the information that [Command.DefaultUsage] outputs.
+func (cmd *Command) Usage() *Usage {
+	cmd.adoptGlobalFlags()
+	cmd.loadFlags()
+	u := &Usage{
+		Name:      cmd.Name,
+		Synopsis:  cmd.Synopsis(),
+		ShortDesc: cmd.Short(),
+		LongDesc:  cmd.LongDesc,
+		DocsURL:   cmd.DocsURL,
+		Examples:  cmd.Examples,
+
+		Hyperlinks: cmd.hyperlinks(),
+	}
+	if m := cmd.messages(); m != &DefaultMessages {
+		u.Messages = m
+	}
+
+	if cmd.UsageHeader != nil {
+		u.Header = strings.TrimRight(cmd.UsageHeader(cmd), "\n")
+	}
+	if cmd.UsageFooter != nil {
+		u.Footer = strings.TrimRight(cmd.UsageFooter(cmd), "\n")
+	}
+
+	cmd.nameCommands()
+	for _, sub := range cmd.Commands {
+		if sub.Name == "" || sub.Hidden {
+			continue
+		}
+		uc := UsageCommand{
+			Name:      sub.Name,
+			ShortDesc: sub.Short(),
+			DocsURL:   sub.DocsURL,
+		}
+		if !cmd.HideAliases {
+			uc.Aliases = sub.Aliases
+		}
+		if sub.IsTopic() {
+			u.Topics = append(u.Topics, uc)
+		} else {
+			u.Commands = append(u.Commands, uc)
+		}
+	}
+
+	aliases, _ := cmd.aliases()
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		// Aliases shadowed by sub-commands are never used.
+		if cmd.Find(name) == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		u.Aliases = append(u.Aliases, UsageAlias{Name: name, Args: aliases[name]})
+	}
+
+	if cmd.Flags != nil {
+		u.FlagGroups = cmd.usageFlagGroups()
+	}
+
+	return u
+}
+
+// maxSynopsisFlags is the most flags that are listed in the synopsis of a
+// leaf command before they're replaced with "[flags]".
+const maxSynopsisFlags = 4
+
+// Synopsis returns a one line summary of how to invoke cmd, like
+// "tool [global flags] <command> [command flags] [args]" for commands with
+// sub-commands or "tool echo [-c] [text...]" for leaf commands.
+// It starts with the [Command.Path] of cmd and ends with it's ArgsUsage.
+func (cmd *Command) Synopsis() string {
+	cmd.loadFlags()
+	var b strings.Builder
+	b.WriteString(strings.TrimSpace(strings.Join(cmd.Path(), " ")))
+	m := cmd.messages()
+
+	var nFlags int
+	if cmd.Flags != nil {
+		cmd.Flags.VisitAll(func(*flag.Flag) { nFlags++ })
+	}
+
+	switch {
+	case len(cmd.Commands) > 0:
+		if nFlags > 0 {
+			if cmd.parent == nil {
+				b.WriteString(" " + m.SynopsisGlobalFlags)
+			} else {
+				b.WriteString(" " + m.SynopsisFlags)
+			}
+		}
+		b.WriteString(" " + m.SynopsisCommand)
+	case nFlags > maxSynopsisFlags:
+		b.WriteString(" " + m.SynopsisFlags)
+	case nFlags > 0:
+		cmd.visitFlags(func(f *flag.Flag) {
+			if isBoolFlag(f) {
+				fmt.Fprintf(&b, " [-%s]", f.Name)
+				return
+			}
+			name, _ := flag.UnquoteUsage(f)
+			fmt.Fprintf(&b, " [-%s %s]", f.Name, name)
+		})
+	}
+
+	if cmd.ArgsUsage != "" && len(cmd.Commands) == 0 {
+		b.WriteString(" " + cmd.ArgsUsage)
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+func (cmd *Command) usageFlagGroups() []UsageFlagGroup {
+	usageFlag := func(f *flag.Flag) UsageFlag {
+		return UsageFlag{
+			Name:    f.Name,
+			Usage:   FlagUsage(f),
+			Default: f.DefValue,
+		}
+	}
+
+	grouped := make(map[string]bool)
+	for _, g := range cmd.FlagGroups {
+		for _, name := range g.Flags {
+			grouped[name] = true
+		}
+	}
+
+	var groups []UsageFlagGroup
+	v := treeView{deprecated: true}
+
+	unnamed := UsageFlagGroup{}
+	for _, g := range cmd.FlagGroups {
+		if g.Name != "" {
+			continue
+		}
+		for _, name := range g.Flags {
+			if f := cmd.Flags.Lookup(name); f != nil && v.hasFlag(cmd, f) {
+				unnamed.Flags = append(unnamed.Flags, usageFlag(f))
+			}
+		}
+	}
+	for _, f := range v.flags(cmd) {
+		if !grouped[f.Name] {
+			unnamed.Flags = append(unnamed.Flags, usageFlag(f))
+		}
+	}
+	if len(unnamed.Flags) > 0 {
+		groups = append(groups, unnamed)
+	}
+
+	for _, g := range cmd.FlagGroups {
+		if g.Name == "" {
+			continue
+		}
+		ug := UsageFlagGroup{Name: g.Name}
+		for _, name := range g.Flags {
+			if f := cmd.Flags.Lookup(name); f != nil && v.hasFlag(cmd, f) {
+				ug.Flags = append(ug.Flags, usageFlag(f))
+			}
+		}
+		if len(ug.Flags) > 0 {
+			groups = append(groups, ug)
+		}
+	}
+
+	return groups
+}
+
+// HelpCommand returns a "help" command to add to the Commands of another
+// command.
+// When run without arguments it outputs the usage message of the command it
+// was added to, otherwise the arguments are the path of the sub-command to
+// output the usage message of, or of the help topic to output the LongDesc of.
+// With the -json flag it outputs the command's [Usage] as JSON to standard
+// output instead, for IDE plugins and other wrappers to present natively.
+func HelpCommand() *Command {
+	fset := flag.NewFlagSet("help", flag.ContinueOnError)
+	fset.Bool("json", false, "output the usage as JSON")
+
+	return &Command{
+		Name:      "help",
+		ShortDesc: "show help for a command or topic",
+		Flags:     fset,
+		Runner: func(cmd *Command, args []string) error {
+			target := cmd.parent
+			if target == nil {
+				return fmt.Errorf("%w: %w", ErrCmd, fmt.Errorf(cmd.messages().NoParent, cmd.Name))
+			}
+
+			for _, arg := range args {
+				sub := target.Find(arg)
+				if sub == nil {
+					return newCommandError(target, arg, ErrUnknownCommand, fmt.Sprintf(cmd.messages().UnknownHelp, arg))
+				}
+				sub, err := sub.loaded()
+				if err != nil {
+					return err
+				}
+				// A copy with the parent chain, so that the synopsis has the
+				// full path, the way parsing it would have.
+				sub.loadFlags()
+				target = sub.parseCopy(target)
+			}
+
+			// The flag is looked up so that this works with Command.ParseArgs.
+			if cmd.Flags.Lookup("json").Value.String() == "true" {
+				enc := json.NewEncoder(cmd.Output())
+				enc.SetIndent("", "\t")
+				return enc.Encode(target.Usage())
+			}
+
+			if target.IsTopic() {
+				fmt.Fprintf(cmd.parent.usageOutput(), "%s\n", strings.TrimRight(target.LongDesc, "\n"))
+				return nil
+			}
+
+			target.loadFlags()
+			if target.Flags != nil && target.Flags.Usage != nil {
+				target.Flags.Usage()
+			} else {
+				target.DefaultUsage()()
+			}
+			return nil
+		},
+	}
+}
+
+// DefaultUsage returns a usage message for use in [flag.FlagSet.Usage] that
+// outputs the [Command.Synopsis] on the first line followed by the long description,
+// the sub-command names and short descriptions on the right of the names,
+// the help topics, the flags for the current command and finally the examples.
+// The output of UsageHeader and UsageFooter, if set, is output before and after
+// all of that.
+// When it's output to a terminal the descriptions are wrapped at it's
+// [term.Width].
+func (cmd *Command) DefaultUsage() func() {
+	return func() {
+		w := cmd.usageOutput()
+		io.WriteString(w, cmd.usageString(term.Width(w)))
+	}
+}
+
+// UsageString returns the usage message that [Command.DefaultUsage] outputs,
+// for tests and generating documentation without setting the output.
+//
+// The usage message is cached until cmd or it's sub-commands change in a way
+// that changes it, so that outputting it again for large trees doesn't lay it
+// out again.
+// [Command.Reset] clears the cache.
+func (cmd *Command) UsageString() string {
+	return cmd.usageString(0)
+}
+
+// usageString returns the usage message with the descriptions wrapped at
+// width.
+func (cmd *Command) usageString(width int) string {
+	cmd.adoptGlobalFlags()
+	cmd.loadFlags()
+	key := cmd.usageKey()
+	key.width = width
+	if c, _ := cmd.usageCache.Load().(*usageCache); c != nil && c.valid(key, cmd.Commands) {
+		var header, footer string
+		if cmd.UsageHeader != nil {
+			header = strings.TrimRight(cmd.UsageHeader(cmd), "\n")
+		}
+		if cmd.UsageFooter != nil {
+			footer = strings.TrimRight(cmd.UsageFooter(cmd), "\n")
+		}
+		return c.text(header, footer)
+	}
+
+	u := cmd.Usage()
+	u.Width = width
+	header, footer := u.Header, u.Footer
+	u.Header, u.Footer = "", ""
+	b := usageBuffers.Get().(*bytes.Buffer)
+	defer putUsageBuffer(b)
+	u.write(b)
+
+	c := &usageCache{key: key, body: b.String()}
+	for _, sub := range cmd.Commands {
+		c.subs = append(c.subs, sub.usageSubKey())
+	}
+	cmd.usageCache.Store(c)
+	return c.text(header, footer)
+}
+
+// usageCache is the usage message of a command without the header and
+// footer and what it was made from.
+type usageCache struct {
+	key  usageKey
+	subs []usageSubKey
+	body string
+}
+
+// usageKey is what the usage message of a command is made from, except for
+// it's sub-commands, content is a hash of the flags, FlagMeta, FlagGroups,
+// FlagOrder, Examples, UserAliases and Messages, so that changing them in place is
+// noticed too.
+type usageKey struct {
+	name, synopsis, shortDesc, longDesc, docsURL string
+
+	hideAliases, hyperlinks bool
+	width                   int
+
+	flags   *flag.FlagSet
+	content uint64
+}
+
+// usageSubKey is what a sub-command is listed with in the usage message of
+// it's parent.
+type usageSubKey struct {
+	name, shortDesc, docsURL, aliases string
+	hidden, topic                     bool
+}
+
+func (cmd *Command) usageKey() usageKey {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%q", *cmd.messages())
+	if cmd.Flags != nil {
+		cmd.Flags.VisitAll(func(f *flag.Flag) {
+			fmt.Fprintf(h, "%q %q %q", f.Name, FlagUsage(f), f.DefValue)
+			if m := cmd.FlagMeta[f.Name]; m != nil {
+				fmt.Fprintf(h, " %v %q %v %v %q %q", m.Hidden, m.Deprecated, m.Required, m.Secret, m.Env, m.Choices)
+			}
+			h.Write([]byte{0})
+		})
+	}
+	fmt.Fprintf(h, "%q %q %d %q", cmd.FlagGroups, cmd.Examples, cmd.FlagOrder, cmd.declared)
+	aliases, _ := cmd.aliases()
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(h, "%q %q", name, aliases[name])
+	}
+
+	return usageKey{
+		name:        cmd.Name,
+		synopsis:    cmd.Synopsis(),
+		shortDesc:   cmd.Short(),
+		longDesc:    cmd.LongDesc,
+		docsURL:     cmd.DocsURL,
+		hideAliases: cmd.HideAliases,
+		hyperlinks:  cmd.hyperlinks(),
+		flags:       cmd.Flags,
+		content:     h.Sum64(),
+	}
+}
+
+func (cmd *Command) usageSubKey() usageSubKey {
+	return usageSubKey{
+		name:      cmd.Name,
+		shortDesc: cmd.Short(),
+		docsURL:   cmd.DocsURL,
+		aliases:   strings.Join(cmd.Aliases, "\x00"),
+		hidden:    cmd.Hidden,
+		topic:     cmd.IsTopic(),
+	}
+}
+
+// valid reports whether c is the usage message for key and commands.
+func (c *usageCache) valid(key usageKey, commands []*Command) bool {
+	if c.key != key || len(c.subs) != len(commands) {
+		return false
+	}
+	for i, sub := range commands {
+		if c.subs[i] != sub.usageSubKey() {
+			return false
+		}
+	}
+	return true
+}
+
+// text returns the usage message with header and footer, the same way as
+// [Usage.write] outputs them.
+func (c *usageCache) text(header, footer string) string {
+	if header == "" && footer == "" {
+		return c.body
+	}
+	var b strings.Builder
+	if header != "" {
+		b.WriteString(header + "\n\n")
+	}
+	b.WriteString(c.body)
+	if footer != "" {
+		b.WriteString("\n" + footer + "\n")
+	}
+	return b.String()
+}
+
+// usageBuffers are the buffers that usage messages are written to before
+// they're output with a single write, instead of one for every line.
+var usageBuffers = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// maxUsageBuffer is the largest buffer that's kept in usageBuffers, so that
+// one huge usage message doesn't keep it's memory around, like fmt does.
+const maxUsageBuffer = 64 << 10
+
+func putUsageBuffer(b *bytes.Buffer) {
+	if b.Cap() > maxUsageBuffer {
+		return
+	}
+	b.Reset()
+	usageBuffers.Put(b)
+}
+
+// usageOutput returns the writer that the usage message of cmd is output to,
+// the output of it's FlagSet if it's set or otherwise [Command.ErrOutput].
+func (cmd *Command) usageOutput() io.Writer {
+	if cmd.Flags != nil {
+		if w := cmd.Flags.Output(); w != os.Stderr {
+			return w
+		}
+	}
+	return cmd.ErrOutput()
+}
+
+func (u *Usage) write(w io.Writer) {
+	m := u.Messages
+	if m == nil {
+		m = &DefaultMessages
+	}
+
+	if u.Header != "" {
+		fmt.Fprintf(w, "%s\n\n", u.Header)
+	}
+
+	if u.Synopsis == "" {
+		fmt.Fprintf(w, "%s\n", m.Usage)
+	} else {
+		fmt.Fprintf(w, "%s %s\n", m.Usage, u.Synopsis)
+	}
+
+	if u.LongDesc != "" {
+		fmt.Fprintf(w, "\n%s\n", u.LongDesc)
+	}
+
+	if u.DocsURL != "" {
+		fmt.Fprintf(w, "\n%s %s\n", m.Documentation, u.link(u.DocsURL, u.DocsURL))
+	}
+
+	cmds := &ui.Table{Indent: 2, Gap: 3, Width: u.Width}
+	topics := &ui.Table{Indent: 2, Gap: 3, Width: u.Width}
+	aliases := &ui.Table{Indent: 2, Gap: 3, Width: u.Width}
+	for _, uc := range u.Commands {
+		cmds.Add(u.commandName(uc), uc.ShortDesc)
+	}
+	for _, uc := range u.Topics {
+		topics.Add(u.commandName(uc), uc.ShortDesc)
+	}
+	for _, ua := range u.Aliases {
+		aliases.Add(ua.Name, strings.Join(ua.Args, " "))
+	}
+
+	// Commands, topics and aliases are aligned with each other.
+	var longestCmd int
+	for _, t := range []*ui.Table{cmds, topics, aliases} {
+		if l := t.LeftWidth(); l > longestCmd {
+			longestCmd = l
+		}
+	}
+	cmds.MinLeft = longestCmd
+	topics.MinLeft = longestCmd
+	aliases.MinLeft = longestCmd
+
+	if cmds.Len() > 0 {
+		fmt.Fprintf(w, "\n%s\n", m.Commands)
+		cmds.WriteTo(w)
+	}
+
+	if topics.Len() > 0 {
+		fmt.Fprintf(w, "\n%s\n", m.Topics)
+		topics.WriteTo(w)
+	}
+
+	if aliases.Len() > 0 {
+		fmt.Fprintf(w, "\n%s\n", m.UserAliases)
+		aliases.WriteTo(w)
+	}
+
+	// All flag groups are aligned with each other.
+	flags := make([]*ui.Table, len(u.FlagGroups))
+	var longest int
+	for i, g := range u.FlagGroups {
+		flags[i] = &ui.Table{Indent: 2, Gap: 3, Width: u.Width}
+		for _, f := range g.Flags {
+			// So that flags with and without usage string are aligned equally.
+			usage := f.Usage
+			if usage != "" {
+				usage += " "
+			}
+
+			flags[i].Add("-"+f.Name, usage+fmt.Sprintf(m.FlagDefault, f.Default))
+		}
+		if l := flags[i].LeftWidth(); l > longest {
+			longest = l
+		}
+	}
+
+	for i, g := range u.FlagGroups {
+		if g.Name == "" {
+			fmt.Fprintf(w, "\n%s\n", m.Flags)
+		} else {
+			fmt.Fprintf(w, "\n%s:\n", g.Name)
+		}
+
+		flags[i].MinLeft = longest
+		flags[i].WriteTo(w)
+	}
+
+	if len(u.Examples) > 0 {
+		fmt.Fprintf(w, "\n%s\n", m.Examples)
+		for i, ex := range u.Examples {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			if ex.Comment != "" {
+				fmt.Fprintf(w, "  # %s\n", ex.Comment)
+			}
+			for _, line := range strings.Split(ex.Command, "\n") {
+				fmt.Fprintf(w, "  %s\n", line)
+			}
+		}
+	}
+
+	if u.Footer != "" {
+		fmt.Fprintf(w, "\n%s\n", u.Footer)
+	}
+}
+
+// commandName returns the name of uc as it's listed in the usage message,
+// followed by it's aliases and as a hyperlink to it's DocsURL.
+func (u *Usage) commandName(uc UsageCommand) string {
+	name := uc.Name
+	if len(uc.Aliases) > 0 {
+		name = strings.Join(append([]string{uc.Name}, uc.Aliases...), ", ")
+	}
+	if uc.DocsURL != "" {
+		name = u.link(name, uc.DocsURL)
+	}
+	return name
+}
+
+// link returns text as an OSC 8 terminal hyperlink to url if u.Hyperlinks is
+// set, otherwise it returns text as is.
+func (u *Usage) link(text, url string) string {
+	if !u.Hyperlinks {
+		return text
+	}
+	return "\x1b]8;;" + url + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
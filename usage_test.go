@@ -0,0 +1,430 @@
+package cmds
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestHelpTopic(t *testing.T) {
+	var b strings.Builder
+	cmd := &Command{
+		Name: "test",
+		Flags: func() *flag.FlagSet {
+			fset := flag.NewFlagSet("test", flag.ContinueOnError)
+			fset.SetOutput(&b)
+			return fset
+		}(),
+		ErrorHandling: ReturnOnError,
+		Stderr:        &b,
+		Commands: []*Command{
+			{
+				Name:      "sub",
+				ShortDesc: "a command",
+				Runner:    nopRunner,
+			},
+			{
+				Name:      "topic",
+				ShortDesc: "a help topic",
+				LongDesc:  "About the topic.",
+			},
+			HelpCommand(),
+		},
+	}
+	cmd.Flags.Usage = cmd.DefaultUsage()
+
+	expectTrue(t, cmd.Find("topic").IsTopic())
+	expectFalse(t, cmd.Find("sub").IsTopic())
+	expectErrorIs(t, cmd.ParseRun([]string{"topic"}), ErrCmd)
+
+	expectErrorNone(t, cmd.ParseRun([]string{"help", "topic"}))
+	expectEq(t, b.String(), "About the topic.\n")
+	b.Reset()
+
+	expectErrorNone(t, cmd.ParseRun([]string{"help"}))
+	expectEq(t, b.String(), `Usage: test <command> [command flags] [args]
+
+Commands:
+  sub     a command
+  help    show help for a command or topic
+
+Additional help topics:
+  topic   a help topic
+`)
+
+	// The synopsis has the full path of the command.
+	b.Reset()
+	expectErrorNone(t, cmd.ParseRun([]string{"help", "sub"}))
+	expectEq(t, b.String(), "Usage: test sub\n")
+	b.Reset()
+	r, err := cmd.ParseArgs([]string{"help", "sub"})
+	expectErrorNone(t, err)
+	expectErrorNone(t, r.Run(context.Background()))
+	expectEq(t, b.String(), "Usage: test sub\n")
+
+	err = cmd.ParseRun([]string{"help", "invalid"})
+	expectErrorIs(t, err, ErrUnknownCommand)
+	var cmdErr *CommandError
+	expectTrue(t, errors.As(err, &cmdErr))
+	expectEq(t, cmdErr.Arg, "invalid")
+}
+
+func TestUsageExamples(t *testing.T) {
+	cmd := &Command{
+		Name: "test",
+		Examples: []Example{
+			{Comment: "print the arguments", Command: "test a b"},
+			{Command: "test \\\n  c"},
+		},
+	}
+
+	expectEq(t, usage(cmd), `Usage: test
+
+Examples:
+  # print the arguments
+  test a b
+
+  test \
+    c
+`)
+}
+
+func TestUsageHeaderFooter(t *testing.T) {
+	cmd := &Command{
+		Name:        "test",
+		UsageHeader: UsageText("test v1.0"),
+		UsageFooter: func(cmd *Command) string {
+			return fmt.Sprintf("Run '%s help <command>' for details.\n", cmd.Name)
+		},
+	}
+
+	expectEq(t, usage(cmd), `test v1.0
+
+Usage: test
+
+Run 'test help <command>' for details.
+`)
+
+	cmd.UsageHeader = UsageText("")
+	expectEq(t, usage(cmd), `Usage: test
+
+Run 'test help <command>' for details.
+`)
+}
+
+func TestUsageAliases(t *testing.T) {
+	cmd := &Command{
+		Name: "test",
+		Commands: []*Command{
+			{Name: "remove", Aliases: []string{"rm"}, ShortDesc: "remove things"},
+			{Name: "list", ShortDesc: "list things"},
+		},
+	}
+
+	expectEq(t, usage(cmd), `Usage: test <command> [command flags] [args]
+
+Commands:
+  remove, rm   remove things
+  list         list things
+`)
+
+	cmd.HideAliases = true
+	expectEq(t, usage(cmd), `Usage: test <command> [command flags] [args]
+
+Commands:
+  remove   remove things
+  list     list things
+`)
+}
+
+func TestUsageFlagGroups(t *testing.T) {
+	cmd := &Command{
+		Name: "test",
+		Flags: func() *flag.FlagSet {
+			fset := flag.NewFlagSet("test", flag.ContinueOnError)
+			fset.String("user", "", "user name")
+			fset.String("password", "", "password")
+			fset.Bool("v", false, "")
+			fset.Bool("z", false, "")
+			fset.Bool("a", false, "")
+			return fset
+		}(),
+		FlagGroups: []FlagGroup{
+			{Name: "Authentication", Flags: []string{"user", "password"}},
+			{Flags: []string{"z"}},
+		},
+	}
+
+	expectEq(t, usage(cmd), `Usage: test [flags]
+
+Flags:
+  -z          (default: false)
+  -a          (default: false)
+  -v          (default: false)
+
+Authentication:
+  -user       user name (default: )
+  -password   password (default: )
+`)
+}
+
+func TestUsageFlagOrder(t *testing.T) {
+	cmd := &Command{Name: "test", Flags: flag.NewFlagSet("test", flag.ContinueOnError)}
+	cmd.Flags.Bool("v", false, "")
+	cmd.Flags.String("user", "", "user name")
+	cmd.Flags.String("password", "", "password")
+	cmd.DeclareFlags("user", "password")
+
+	expectEq(t, usage(cmd), `Usage: test [-password string] [-user string] [-v]
+
+Flags:
+  -password   password (default: )
+  -user       user name (default: )
+  -v          (default: false)
+`)
+
+	cmd.FlagOrder = DeclaredFlags
+	expectEq(t, usage(cmd), `Usage: test [-user string] [-password string] [-v]
+
+Flags:
+  -user       user name (default: )
+  -password   password (default: )
+  -v          (default: false)
+`)
+
+	type options struct {
+		Zone string
+		Addr string
+	}
+	cmd, err := FromStruct(&options{})
+	expectErrorNone(t, err)
+	cmd.FlagOrder = DeclaredFlags
+	expectEq(t, cmd.Synopsis(), "options [-zone string] [-addr string]")
+}
+
+func TestUsageHyperlinks(t *testing.T) {
+	cmd := &Command{
+		Name:    "test",
+		DocsURL: "https://example.com",
+		Commands: []*Command{
+			{Name: "sub", DocsURL: "https://example.com/sub", Runner: nopRunner},
+			{Name: "other", Runner: nopRunner},
+		},
+	}
+
+	const usage0 = `Usage: test <command> [command flags] [args]
+
+Documentation: %s
+
+Commands:
+  %s
+  other
+`
+	expectEq(t, usage(cmd), fmt.Sprintf(usage0, "https://example.com", "sub"))
+
+	cmd.Hyperlinks = true
+	expectEq(t, usage(cmd), fmt.Sprintf(usage0,
+		"\x1b]8;;https://example.com\x1b\\https://example.com\x1b]8;;\x1b\\",
+		"\x1b]8;;https://example.com/sub\x1b\\sub\x1b]8;;\x1b\\"))
+}
+
+func TestUsageHidden(t *testing.T) {
+	cmd := testCompletionTree()
+	expectEq(t, usage(cmd), `Usage: tool [global flags] <command> [command flags] [args]
+
+Commands:
+  db
+  serve
+  start
+
+Additional help topics:
+  topic   About
+
+Flags:
+  -old   (default: false)
+  -v     (default: false)
+`)
+}
+
+func TestSynopsis(t *testing.T) {
+	cmd := &Command{
+		Name: "tool",
+		Flags: func() *flag.FlagSet {
+			fset := flag.NewFlagSet("tool", flag.ContinueOnError)
+			fset.Bool("v", false, "")
+			return fset
+		}(),
+		Commands: []*Command{
+			{
+				Name:      "echo",
+				ArgsUsage: "[text...]",
+				Runner:    nopRunner,
+				Flags: func() *flag.FlagSet {
+					fset := flag.NewFlagSet("echo", flag.ContinueOnError)
+					fset.Bool("c", false, "")
+					fset.String("s", "", "a `separator`")
+					return fset
+				}(),
+			},
+		},
+	}
+
+	expectEq(t, cmd.Synopsis(), "tool [global flags] <command> [command flags] [args]")
+	expectErrorNone(t, cmd.ParseRun([]string{"echo"}))
+	expectEq(t, cmd.Commands[0].Path(), []string{"tool", "echo"})
+	expectEq(t, cmd.Commands[0].Synopsis(), "tool echo [-c] [-s separator] [text...]")
+}
+
+func TestUsageModel(t *testing.T) {
+	cmd := &Command{
+		Name:      "test",
+		ShortDesc: "testing",
+		Flags: func() *flag.FlagSet {
+			fset := flag.NewFlagSet("test", flag.ContinueOnError)
+			fset.Bool("a", false, "all")
+			return fset
+		}(),
+		Commands: []*Command{
+			{Name: "sub", Aliases: []string{"s"}, Runner: nopRunner},
+			{Name: "topic", LongDesc: "About."},
+		},
+	}
+
+	u := cmd.Usage()
+	expectEq(t, u, &Usage{
+		Name:      "test",
+		Synopsis:  "test [global flags] <command> [command flags] [args]",
+		ShortDesc: "testing",
+		Commands:  []UsageCommand{{Name: "sub", Aliases: []string{"s"}}},
+		Topics:    []UsageCommand{{Name: "topic", ShortDesc: "About"}},
+		FlagGroups: []UsageFlagGroup{
+			{Flags: []UsageFlag{{Name: "a", Usage: "all", Default: "false"}}},
+		},
+	})
+
+	b, err := json.Marshal(u)
+	expectErrorNone(t, err)
+	expectEq(t, string(b), `{"name":"test","synopsis":"test [global flags] \u003ccommand\u003e [command flags] [args]","shortDesc":"testing","commands":[{"name":"sub","aliases":["s"]}],"topics":[{"name":"topic","shortDesc":"About"}],"flagGroups":[{"flags":[{"name":"a","usage":"all","default":"false"}]}]}`)
+}
+
+// usage returns the output of [Command.DefaultUsage] for cmd.
+// writeCounter counts the writes to it.
+type writeCounter struct {
+	b      strings.Builder
+	writes int
+}
+
+func (w *writeCounter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.b.Write(p)
+}
+
+func TestUsageString(t *testing.T) {
+	cmd := &Command{
+		Name:      "test",
+		LongDesc:  "Test things.",
+		Flags:     flag.NewFlagSet("test", flag.ContinueOnError),
+		Commands:  []*Command{{Name: "a", ShortDesc: "do a"}},
+		Examples:  []Example{{Command: "test a"}},
+		ShortDesc: "test",
+	}
+	cmd.Flags.Bool("v", false, "verbose")
+
+	var w writeCounter
+	cmd.Flags.SetOutput(&w)
+	cmd.DefaultUsage()()
+	expectEq(t, w.writes, 1)
+	expectEq(t, cmd.UsageString(), w.b.String())
+	expectEq(t, cmd.UsageString(), `Usage: test [global flags] <command> [command flags] [args]
+
+Test things.
+
+Commands:
+  a   do a
+
+Flags:
+  -v   verbose (default: false)
+
+Examples:
+  test a
+`)
+}
+
+func TestUsageCache(t *testing.T) {
+	var headers int
+	cmd := &Command{
+		Name:     "test",
+		Flags:    flag.NewFlagSet("test", flag.ContinueOnError),
+		Commands: []*Command{{Name: "a", ShortDesc: "do a", Runner: nopRunner}},
+		UsageHeader: func(*Command) string {
+			headers++
+			return fmt.Sprintf("header %d", headers)
+		},
+	}
+
+	text := cmd.UsageString()
+	cache := cmd.usageCache.Load()
+	expectTrue(t, strings.HasPrefix(text, "header 1\n\n"))
+	expectEq(t, strings.TrimPrefix(cmd.UsageString(), "header 2"), strings.TrimPrefix(text, "header 1"))
+	expectTrue(t, cmd.usageCache.Load() == cache)
+
+	cmd.Commands[0].ShortDesc = "do b"
+	expectTrue(t, strings.Contains(cmd.UsageString(), "do b"))
+	cmd.Commands = append(cmd.Commands, &Command{Name: "c", Runner: nopRunner})
+	expectTrue(t, strings.Contains(cmd.UsageString(), "  c"))
+	cmd.Flags.Bool("v", false, "verbose")
+	expectTrue(t, strings.Contains(cmd.UsageString(), "verbose"))
+	expectEq(t, headers, 5)
+
+	// Descriptions changed in place after the first render.
+	cmd.Flags.Lookup("v").Usage = "more output"
+	expectTrue(t, strings.Contains(cmd.UsageString(), "more output"))
+	cmd.Examples = []Example{{Comment: "first", Command: "test a"}}
+	expectTrue(t, strings.Contains(cmd.UsageString(), "# first"))
+	cmd.Examples[0].Comment = "second"
+	expectTrue(t, strings.Contains(cmd.UsageString(), "# second"))
+	cmd.Commands[0].Aliases = []string{"x"}
+	expectTrue(t, strings.Contains(cmd.UsageString(), "a, x"))
+	cmd.Commands[0].Aliases[0] = "y"
+	expectTrue(t, strings.Contains(cmd.UsageString(), "a, y"))
+
+	cmd.Reset()
+	expectTrue(t, cmd.usageCache.Load().(*usageCache) == nil)
+}
+
+func usage(cmd *Command) string {
+	if cmd.Flags == nil {
+		cmd.Flags = flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	}
+	var b strings.Builder
+	out := cmd.Flags.Output()
+	cmd.Flags.SetOutput(&b)
+	cmd.DefaultUsage()()
+	cmd.Flags.SetOutput(out)
+	return b.String()
+}
+
+func TestUsageWidth(t *testing.T) {
+	cmd := &Command{
+		Name:     "test",
+		Flags:    flag.NewFlagSet("test", flag.ContinueOnError),
+		Commands: []*Command{{Name: "a", ShortDesc: "do a thing that isn't short"}},
+	}
+	expectEq(t, cmd.usageString(20), `Usage: test <command> [command flags] [args]
+
+Commands:
+  a   do a thing
+      that isn't
+      short
+`)
+	// The cache isn't shared between widths.
+	expectEq(t, cmd.usageString(0), `Usage: test <command> [command flags] [args]
+
+Commands:
+  a   do a thing that isn't short
+`)
+}
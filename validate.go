@@ -0,0 +1,190 @@
+package cmds
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// ArgsFunc validates the positional arguments of a command.
+type ArgsFunc func(args []string) error
+
+// ExactArgs returns an [ArgsFunc] that requires exactly n arguments.
+func ExactArgs(n int) ArgsFunc {
+	return RangeArgs(n, n)
+}
+
+// MinArgs returns an [ArgsFunc] that requires at least n arguments.
+func MinArgs(n int) ArgsFunc {
+	return RangeArgs(n, -1)
+}
+
+// MaxArgs returns an [ArgsFunc] that allows at most n arguments.
+func MaxArgs(n int) ArgsFunc {
+	return RangeArgs(0, n)
+}
+
+// RangeArgs returns an [ArgsFunc] that requires at least min and at most max
+// arguments, if max is negative there's no maximum.
+func RangeArgs(min, max int) ArgsFunc {
+	return func(args []string) error {
+		n := len(args)
+		if n >= min && (max < 0 || n <= max) {
+			return nil
+		}
+
+		m := &DefaultMessages
+		var expected string
+		switch {
+		case min == max:
+			expected = arguments(m, min)
+		case max < 0:
+			expected = fmt.Sprintf(m.ArgsAtLeast, arguments(m, min))
+		case min == 0:
+			expected = fmt.Sprintf(m.ArgsAtMost, arguments(m, max))
+		default:
+			expected = fmt.Sprintf(m.ArgsRange, min, arguments(m, max))
+		}
+		return fmt.Errorf(m.ArgsExpected, expected, n)
+	}
+}
+
+func arguments(m *Messages, n int) string {
+	if n == 1 {
+		return fmt.Sprintf(m.Argument, n)
+	}
+	return fmt.Sprintf(m.Arguments, n)
+}
+
+// validateFlags returns the errors for the parsed flags of cmd that are invalid
+// according to their [FlagMeta].
+// It's called after resolveFlags, a flag is set if it has a Source other than
+// SourceDefault.
+func (cmd *Command) validateFlags() []error {
+	if len(cmd.FlagMeta) == 0 {
+		return nil
+	}
+
+	var errs []error
+	cmd.Flags.VisitAll(func(f *flag.Flag) {
+		meta := cmd.FlagMeta[f.Name]
+		if meta == nil {
+			return
+		}
+
+		if meta.Required && cmd.sources[f.Name] == SourceDefault {
+			errs = append(errs, newFlagErrorReason(cmd, f.Name, ErrFlagRequired,
+				fmt.Sprintf(cmd.messages().FlagRequired, f.Name)))
+		}
+
+		if len(meta.Choices) > 0 && cmd.sources[f.Name] != SourceDefault {
+			value := f.Value.String()
+			for _, choice := range meta.Choices {
+				if value == choice {
+					return
+				}
+			}
+			errs = append(errs, newFlagErrorReason(cmd, f.Name, ErrFlagChoice,
+				fmt.Sprintf(cmd.messages().FlagChoice, value, f.Name, strings.Join(meta.Choices, ", "))))
+		}
+	})
+
+	return errs
+}
+
+// joinErrors returns the validation errors in errs joined with err, which
+// can be nil, or nil if there are none.
+// A single error is returned as is, so that it's message doesn't change.
+func joinErrors(errs []error, err error) error {
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
+
+// Validate returns the problems with the tree of cmd that would only show when
+// a user runs into them, joined, or nil if there are none, so that programs can
+// check their tree in a test:
+//
+//   - sub-commands without a name, or with a name that starts with "-" and
+//     would be parsed as a flag,
+//   - sub-commands with the same name or alias as another one of their parent,
+//   - unreachable sub-commands, the ones without a name and the ones whose
+//     names and aliases are all parsed as flags or match other sub-commands
+//     first in [Command.Find],
+//   - leaf commands, other than help topics, without a Runner,
+//   - flags with the same name as a flag of one of the parents of their
+//     command, which shadow it in [Command.ValueSource] and
+//     [Command.UnmarshalConfig],
+//   - FlagSets shared by more than one command.
+//
+// The FlagsFunc of the commands are called, the commands with a LoadFunc
+// aren't loaded and only their names are checked.
+func (cmd *Command) Validate() error {
+	var errs []error
+	fsets := make(map[*flag.FlagSet]string)
+
+	var validate func(c *Command, path string, inherited map[string]string)
+	validate = func(c *Command, path string, inherited map[string]string) {
+		if c.LoadFunc != nil {
+			return
+		}
+
+		c.loadFlags()
+		flags := inherited
+		if c.Flags != nil {
+			if other, ok := fsets[c.Flags]; ok {
+				errs = append(errs, fmt.Errorf("%s: shares it's FlagSet with %s", path, other))
+			}
+			fsets[c.Flags] = path
+
+			flags = make(map[string]string, len(inherited))
+			for name, p := range inherited {
+				flags[name] = p
+			}
+			c.Flags.VisitAll(func(f *flag.Flag) {
+				if p, ok := inherited[f.Name]; ok {
+					errs = append(errs, fmt.Errorf("%s: flag -%s shadows the one of %s", path, f.Name, p))
+				}
+				flags[f.Name] = path
+			})
+		}
+
+		if len(c.Commands) == 0 && c.Runner == nil && !c.IsTopic() {
+			errs = append(errs, fmt.Errorf("%s: leaf command without a Runner", path))
+		}
+
+		c.nameCommands()
+		names := make(map[string]bool)
+		for i, sub := range c.Commands {
+			if sub.Name == "" {
+				errs = append(errs, fmt.Errorf("%s: sub-command %d without a name is unreachable", path, i))
+				continue
+			}
+			subPath := path + " " + sub.Name
+			reachable := false
+			for _, name := range append([]string{sub.Name}, sub.Aliases...) {
+				if strings.HasPrefix(name, "-") {
+					errs = append(errs, fmt.Errorf("%s: name \"%s\" is parsed as a flag", subPath, name))
+				} else if findCommand(c.Commands, name) == sub {
+					reachable = true
+				}
+				if names[name] {
+					errs = append(errs, fmt.Errorf("%s: name \"%s\" is already used by another sub-command of %s", subPath, name, path))
+				}
+				names[name] = true
+			}
+			if !reachable {
+				errs = append(errs, fmt.Errorf("%s: unreachable, it's names are parsed as flags or find other sub-commands of %s", subPath, path))
+			}
+			validate(sub, subPath, flags)
+		}
+	}
+	validate(cmd, strings.Join(cmd.Path(), " "), nil)
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,127 @@
+package cmds
+
+import (
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestArgsFuncs(t *testing.T) {
+	args := func(n int) []string {
+		return make([]string, n)
+	}
+
+	expectErrorNone(t, ExactArgs(1)(args(1)))
+	expectEq(t, ExactArgs(1)(args(2)).Error(), "expected 1 argument, got 2")
+	expectErrorNone(t, MinArgs(2)(args(3)))
+	expectEq(t, MinArgs(2)(args(1)).Error(), "expected at least 2 arguments, got 1")
+	expectErrorNone(t, MaxArgs(1)(args(0)))
+	expectEq(t, MaxArgs(1)(args(2)).Error(), "expected at most 1 argument, got 2")
+	expectErrorNone(t, RangeArgs(1, 2)(args(2)))
+	expectEq(t, RangeArgs(1, 2)(args(0)).Error(), "expected 1 to 2 arguments, got 0")
+	expectEq(t, ExactArgs(0)(args(1)).Error(), "expected 0 arguments, got 1")
+}
+
+func TestValidate(t *testing.T) {
+	expectErrorNone(t, testValidateCmd().ParseRun([]string{"-token", "x", "req", "url"}))
+	expectErrorNone(t, testValidateCmd().ParseRun([]string{"-token", "x", "req", "-m", "HEAD", "url"}))
+
+	err := testValidateCmd().ParseRun([]string{"req", "-m", "PUT"})
+	expectErrorIs(t, err, Err)
+	expectErrorIs(t, err, ErrFlag)
+	expectErrorIs(t, err, ErrFlagRequired)
+	expectErrorIs(t, err, ErrFlagChoice)
+	expectErrorIs(t, err, ErrCmd)
+	expectErrorIs(t, err, ErrArgs)
+	expectEq(t, strings.Split(err.Error(), "\n"), []string{
+		"command error: flag parse error: missing required flag -token",
+		"flag parse error: invalid choice \"PUT\" for flag -m, expected one of GET, HEAD",
+		"command parse error: invalid arguments: expected 1 argument, got 0",
+	})
+
+	var flagErr *FlagError
+	if !errors.As(err, &flagErr) {
+		t.Fatalf("expected *FlagError, got %#v", err)
+	}
+	expectEq(t, flagErr.Path, []string{"test"})
+	expectEq(t, flagErr.Flag, "token")
+
+	// Validation errors are reported along with the error that stopped
+	// parsing.
+	err = testValidateCmd().ParseRun([]string{"invalid"})
+	expectErrorIs(t, err, ErrFlagRequired)
+	expectErrorIs(t, err, ErrUnknownCommand)
+}
+
+func testValidateCmd() *Command {
+	cmd := &Command{
+		Name: "test",
+		Flags: func() *flag.FlagSet {
+			fset := flag.NewFlagSet("test", flag.ContinueOnError)
+			fset.String("token", "", "")
+			return fset
+		}(),
+		Commands: []*Command{
+			{
+				Name:   "req",
+				Runner: nopRunner,
+				Args:   ExactArgs(1),
+				Flags: func() *flag.FlagSet {
+					fset := flag.NewFlagSet("req", flag.ContinueOnError)
+					fset.String("m", "GET", "")
+					return fset
+				}(),
+			},
+		},
+	}
+	cmd.Meta("token").Required = true
+	cmd.Commands[0].Meta("m").Choices = []string{"GET", "HEAD"}
+	return cmd
+}
+
+func TestValidateTree(t *testing.T) {
+	expectErrorNone(t, testValidateCmd().Validate())
+
+	fset := flag.NewFlagSet("shared", flag.ContinueOnError)
+	cmd := &Command{
+		Name:  "test",
+		Flags: flag.NewFlagSet("test", flag.ContinueOnError),
+		Commands: []*Command{
+			{Name: "a", Aliases: []string{"b"}, Runner: nopRunner, Flags: fset},
+			{Name: "b", Runner: nopRunner, Flags: fset},
+			{Name: "-c", Runner: nopRunner},
+			{Name: "d", Runner: nopRunner},
+			{Name: "e", Aliases: []string{"d"}, Runner: nopRunner},
+			{Name: "d", Aliases: []string{"a"}, Runner: nopRunner},
+			{Runner: func(*Command, []string) error { return nil }},
+			{Name: "leaf"},
+			{Name: "topic", LongDesc: "About things."},
+			{Name: "lazy", LoadFunc: func() (*Command, error) { return nil, errors.New("loaded") }},
+			{
+				Name:   "v",
+				Runner: nopRunner,
+				FlagsFunc: func() *flag.FlagSet {
+					fset := flag.NewFlagSet("v", flag.ContinueOnError)
+					fset.Bool("v", false, "")
+					return fset
+				},
+			},
+		},
+	}
+	cmd.Flags.Bool("v", false, "")
+
+	expectEq(t, strings.Split(cmd.Validate().Error(), "\n"), []string{
+		"test b: name \"b\" is already used by another sub-command of test",
+		"test b: shares it's FlagSet with test a",
+		"test -c: name \"-c\" is parsed as a flag",
+		"test -c: unreachable, it's names are parsed as flags or find other sub-commands of test",
+		"test e: name \"d\" is already used by another sub-command of test",
+		"test d: name \"d\" is already used by another sub-command of test",
+		"test d: name \"a\" is already used by another sub-command of test",
+		"test d: unreachable, it's names are parsed as flags or find other sub-commands of test",
+		"test: sub-command 6 without a name is unreachable",
+		"test leaf: leaf command without a Runner",
+		"test v: flag -v shadows the one of test",
+	})
+}
@@ -0,0 +1,54 @@
+package cmds
+
+import "flag"
+
+// treeView is a filtered view of the command tree, so that everything that
+// presents the tree to users treats hidden and deprecated commands and flags
+// the same way.
+type treeView struct {
+	// deprecated includes the deprecated commands and flags.
+	deprecated bool
+}
+
+// commands returns the sub-commands of cmd that are in the view, without
+// help topics and commands without a name.
+func (v treeView) commands(cmd *Command) []*Command {
+	cmd, _ = cmd.loaded()
+	cmd.nameCommands()
+	var subs []*Command
+	for _, sub := range cmd.Commands {
+		if sub.Name == "" || sub.Hidden || sub.IsTopic() {
+			continue
+		}
+		if sub.Deprecated != "" && !v.deprecated {
+			continue
+		}
+		subs = append(subs, sub)
+	}
+	return subs
+}
+
+// flags returns the flags of cmd that are in the view in it's FlagOrder.
+func (v treeView) flags(cmd *Command) []*flag.Flag {
+	var flags []*flag.Flag
+	cmd, _ = cmd.loaded()
+	cmd.loadFlags()
+	if cmd.Flags == nil {
+		return nil
+	}
+	cmd.visitFlags(func(f *flag.Flag) {
+		if v.hasFlag(cmd, f) {
+			flags = append(flags, f)
+		}
+	})
+	return flags
+}
+
+// hasFlag reports whether f of cmd is in the view.
+func (v treeView) hasFlag(cmd *Command, f *flag.Flag) bool {
+	meta := cmd.FlagMeta[f.Name]
+	if meta == nil {
+		return true
+	}
+	return !meta.Hidden && (meta.Deprecated == "" || v.deprecated)
+}
@@ -0,0 +1,66 @@
+package cmds
+
+import (
+	"flag"
+	"fmt"
+	"io"
+)
+
+// SetWarnOutput sets the writer that [Command.Warnf] outputs warnings to for
+// cmd and it's sub-commands unless they set their own, the default is
+// [Command.ErrOutput].
+// Warnings can be silenced by setting it to [io.Discard].
+func (cmd *Command) SetWarnOutput(w io.Writer) {
+	cmd.warnOutput = w
+}
+
+// WarnOutput returns the writer set with [Command.SetWarnOutput] for cmd or
+// the closest of it's parents, or [Command.ErrOutput] if none was set.
+func (cmd *Command) WarnOutput() io.Writer {
+	for c := cmd; c != nil; c = c.parent {
+		if c.warnOutput != nil {
+			return c.warnOutput
+		}
+	}
+	return cmd.ErrOutput()
+}
+
+// Warnf outputs a warning to [Command.WarnOutput], for things that the user
+// should know about but that aren't errors, like the use of deprecated
+// commands and flags.
+// A newline is added if the message doesn't end with one.
+func (cmd *Command) Warnf(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if len(msg) == 0 || msg[len(msg)-1] != '\n' {
+		msg += "\n"
+	}
+	fmt.Fprintf(cmd.WarnOutput(), "warning: %s", msg)
+}
+
+// Infof outputs an informational message to [Command.ErrOutput] unless
+// [Command.IsQuiet], for things like what the command is doing that aren't
+// the data it outputs.
+// A newline is added if the message doesn't end with one.
+func (cmd *Command) Infof(format string, args ...any) {
+	if cmd.IsQuiet() {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	if len(msg) == 0 || msg[len(msg)-1] != '\n' {
+		msg += "\n"
+	}
+	io.WriteString(cmd.ErrOutput(), msg)
+}
+
+// warnDeprecated warns about the deprecated flags of cmd that are set.
+func (cmd *Command) warnDeprecated() {
+	if len(cmd.FlagMeta) == 0 {
+		return
+	}
+
+	cmd.visitSet(func(f *flag.Flag) {
+		if msg := cmd.FlagMeta[f.Name].deprecated(); msg != "" {
+			cmd.Warnf(cmd.messages().DeprecatedFlag, f.Name, msg)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package cmds
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWarnf(t *testing.T) {
+	var b strings.Builder
+	cmd := testCompletionTree()
+	cmd.SetWarnOutput(&b)
+
+	expectErrorNone(t, cmd.ParseRun([]string{"serve"}))
+	expectEq(t, b.String(), "")
+
+	expectErrorNone(t, cmd.ParseRun([]string{"-old", "start"}))
+	expectEq(t, b.String(), "warning: flag -old is deprecated, use -v\nwarning: command \"start\" is deprecated, use \"serve\"\n")
+	b.Reset()
+
+	cmd = testCompletionTree()
+	cmd.SetWarnOutput(&b)
+	expectErrorNone(t, cmd.ParseRun([]string{"db", "-legacy", "upgrade"}))
+	expectEq(t, b.String(), "warning: flag -legacy is deprecated, use -dsn\nwarning: command \"upgrade\" is deprecated, use \"migrate\"\n")
+	b.Reset()
+
+	cmd.Commands[0].Warnf("custom %d\n", 1)
+	expectEq(t, b.String(), "warning: custom 1\n")
+	b.Reset()
+
+	var e strings.Builder
+	cmd.SetWarnOutput(nil)
+	cmd.SetErrOutput(&e)
+	cmd.Warnf("to errors")
+	expectEq(t, e.String(), "warning: to errors\n")
+
+	cmd.SetWarnOutput(io.Discard)
+	cmd.Warnf("silenced")
+	expectEq(t, e.String(), "warning: to errors\n")
+}
+
+func TestInfof(t *testing.T) {
+	var stdout, stderr strings.Builder
+	cmd := &Command{
+		Name:   "test",
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Commands: []*Command{
+			{Name: "sub", Runner: func(cmd *Command, args []string) error {
+				cmd.Infof("fetching %d items", 2)
+				cmd.Warnf("slow")
+				return cmd.Print([]string{"a", "b"})
+			}},
+		},
+	}
+	cmd.AddLogFlags()
+
+	expectErrorNone(t, cmd.ParseRun([]string{"sub"}))
+	expectEq(t, stderr.String(), "fetching 2 items\nwarning: slow\n")
+	expectEq(t, stdout.String(), "a\nb\n")
+
+	// Only the informational output is silenced.
+	stdout.Reset()
+	stderr.Reset()
+	cmd.Reset()
+	expectErrorNone(t, cmd.ParseRun([]string{"-quiet", "sub"}))
+	expectTrue(t, cmd.Commands[0].IsQuiet())
+	expectEq(t, stderr.String(), "warning: slow\n")
+	expectEq(t, stdout.String(), "a\nb\n")
+}
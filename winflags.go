@@ -0,0 +1,58 @@
+package cmds
+
+import (
+	"runtime"
+	"strings"
+)
+
+// goos is runtime.GOOS, it's a variable for the tests.
+var goos = runtime.GOOS
+
+// WindowsFlagArgs returns args with the flags in the style of native Windows
+// tools translated to the standard form, "/name" to "-name", "/name:value"
+// and "/name=value" to "-name=value" and "/?" to "-h".
+// Only arguments where name is made of letters, digits, "-" and "_" are
+// translated, so that paths like "/tmp/file" and "/file.txt" are left as they
+// are, and none after a "--" argument.
+// On other systems than Windows args are returned as they are, since
+// arguments like "/tmp" are paths there.
+func WindowsFlagArgs(args []string) []string {
+	if goos != "windows" {
+		return args
+	}
+	translated := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			return append(translated, args[i:]...)
+		}
+		translated = append(translated, windowsFlag(arg))
+	}
+	return translated
+}
+
+func windowsFlag(arg string) string {
+	if arg == "/?" {
+		return "-h"
+	}
+	if len(arg) < 2 || arg[0] != '/' {
+		return arg
+	}
+
+	name, value, hasValue := strings.Cut(arg[1:], ":")
+	if n, v, ok := strings.Cut(arg[1:], "="); ok && (!hasValue || len(n) < len(name)) {
+		name, value, hasValue = n, v, ok
+	}
+	if name == "" {
+		return arg
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return arg
+		}
+	}
+
+	if hasValue {
+		return "-" + name + "=" + value
+	}
+	return "-" + name
+}
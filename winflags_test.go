@@ -0,0 +1,33 @@
+package cmds
+
+import "testing"
+
+func TestWindowsFlagArgs(t *testing.T) {
+	orig := goos
+	t.Cleanup(func() { goos = orig })
+
+	goos = "windows"
+	expectEq(t, WindowsFlagArgs([]string{
+		"/v", "/out:C:\\file", "/level=2", "/url:http://x?a=b", "/?", "sub",
+		"/tmp/file", "/file.txt", "/", "/:x", "-name", "--", "/v",
+	}), []string{
+		"-v", "-out=C:\\file", "-level=2", "-url=http://x?a=b", "-h", "sub",
+		"/tmp/file", "/file.txt", "/", "/:x", "-name", "--", "/v",
+	})
+
+	// Paths like "/tmp" aren't flags on other systems.
+	goos = "linux"
+	expectEq(t, WindowsFlagArgs([]string{"/tmp", "/v"}), []string{"/tmp", "/v"})
+
+	var got []string
+	cmd := &Command{
+		Name:         "test",
+		WindowsFlags: true,
+		Runner: func(cmd *Command, args []string) error {
+			got = args
+			return nil
+		},
+	}
+	expectErrorNone(t, cmd.ParseRun([]string{"/tmp"}))
+	expectEq(t, got, []string{"/tmp"})
+}